@@ -2,17 +2,14 @@ package main
 
 import (
 	"contact-list-api-1/config"
+	"contact-list-api-1/database"
 	"contact-list-api-1/handlers"
 	middleware "contact-list-api-1/middlewares"
-	"contact-list-api-1/models"
+	"contact-list-api-1/migrations"
 	"contact-list-api-1/repositories"
 	"contact-list-api-1/services"
-	"fmt"
 	"log"
 	"net/http"
-
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
 )
 
 func main() {
@@ -20,38 +17,89 @@ func main() {
 	if err != nil {
 		log.Fatal("Error loading configuration: ", err)
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Name)
+	var listRepo repositories.ListRepository
+	var contactRepo repositories.ContactRepository
+	var trashRepo repositories.TrashRepository
+	var importRepo repositories.ImportRepository
+	var addressBookRepo repositories.AddressBookRepository
+	if cfg.DB.Driver == config.DriverMemory {
+		store := repositories.NewMemoryStore()
+		listRepo = repositories.NewMemoryListRepository(store)
+		contactRepo = repositories.NewMemoryContactRepository(store)
+		trashRepo = repositories.NewMemoryTrashRepository(store)
+		importRepo = repositories.NewMemoryImportRepository(store)
+		addressBookRepo = repositories.NewMemoryAddressBookRepository(store)
+		log.Println("Using in-memory storage")
+	} else {
+		db, err := database.Open(cfg.DB)
+		if err != nil {
+			log.Fatal("Error connecting to database: ", err)
+		}
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			log.Fatal("Error checking schema version: ", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("Database schema is behind by %d migration(s), run `migrate up` first", len(pending))
+		}
+		log.Println("Database connected succesfully")
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal("Error connecting to database: ", err)
-	}
-	err = db.AutoMigrate(&models.List{}, &models.Contact{})
-	if err != nil {
-		log.Fatal(err)
+		listRepo = repositories.NewListRepository(db)
+		contactRepo = repositories.NewContactRepository(db)
+		trashRepo = repositories.NewTrashRepository(db)
+		importRepo = repositories.NewImportRepository(db)
+		addressBookRepo = repositories.NewAddressBookRepository(db)
 	}
-	log.Println("Database connected succesfully")
 
-	listRepo := repositories.NewListRepository(db)
-
-	contactRepo := repositories.NewContactRepository(db)
 	listService := services.NewListService(listRepo)
 	contactService := services.NewContactService(contactRepo)
+	trashService := services.NewTrashService(trashRepo, listRepo, contactRepo)
+	importService := services.NewImportService(importRepo, contactRepo, contactService)
+	addressBookService := services.NewAddressBookService(addressBookRepo, listRepo, contactRepo, contactService)
+	services.StartPurger(trashService, cfg.Trash.Retention(), cfg.Trash.PurgeInterval())
 
 	listHandler := handlers.NewListHandler(listService)
 	contactHandler := handlers.NewContactHandler(contactService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	importHandler := handlers.NewImportHandler(importService)
+	cardDAVHandler := handlers.NewCardDAVHandler(addressBookService)
 
 	http.Handle("GET /lists", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.GetAllLists)))
 	http.Handle("GET /lists/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.GetListByUUID)))
 	http.Handle("POST /lists", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.CreateList)))
 	http.Handle("PUT /lists/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.UpdateList)))
+	http.Handle("PATCH /lists/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.PatchList)))
 	http.Handle("DELETE /lists/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.DeleteList)))
+	http.Handle("POST /lists/{uuid}/restore", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(listHandler.RestoreList)))
+	http.Handle("GET /lists/{uuid}/contacts", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.GetListContacts)))
+	http.Handle("GET /lists/{uuid}/export", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.ExportListContacts)))
+	http.Handle("POST /lists/{uuid}/contacts", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.CreateListContact)))
+	http.Handle("PUT /lists/{uuid}/contacts/{contactUUID}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.AddListContact)))
+	http.Handle("DELETE /lists/{uuid}/contacts/{contactUUID}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.RemoveListContact)))
+	http.Handle("POST /lists/{uuid}/import", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(importHandler.ImportContacts)))
+	http.Handle("GET /lists/{uuid}/imports/{importUUID}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(importHandler.GetImport)))
+	http.Handle("GET /lists/{uuid}/imports/{importUUID}/errors", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(importHandler.GetImportErrors)))
 
 	http.Handle("GET /contacts", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.GetAllContacts)))
+	http.Handle("GET /contacts/export", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.ExportContacts)))
+	http.Handle("GET /contacts/duplicates", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.GetDuplicateContacts)))
 	http.Handle("GET /contacts/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.GetContactByUUID)))
 	http.Handle("POST /contacts", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.CreateContact)))
 	http.Handle("PUT /contacts/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.UpdateContact)))
+	http.Handle("PATCH /contacts/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.PatchContact)))
 	http.Handle("DELETE /contacts/{uuid}", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.DeleteContact)))
+	http.Handle("POST /contacts/{uuid}/restore", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.RestoreContact)))
+	http.Handle("POST /contacts/merge", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.MergeContacts)))
+	http.Handle("POST /contacts:batch", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.BatchContacts)))
+	http.Handle("GET /contacts/{uuid}/merges", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.GetContactMerges)))
+
+	http.Handle("GET /search", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(contactHandler.SearchContacts)))
+
+	http.Handle("GET /trash", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(trashHandler.GetTrash)))
+	http.Handle("POST /trash/{uuid}/restore", middleware.AuthMiddleware(cfg.AuthToken, http.HandlerFunc(trashHandler.RestoreTrashItem)))
+
+	http.Handle("/.well-known/carddav", http.HandlerFunc(cardDAVHandler.WellKnown))
+	http.Handle("/carddav/", middleware.DAVAuthMiddleware(cfg.AuthToken, cardDAVHandler))
 
 	log.Println("Starting server on port 8080...")
 	log.Fatal(http.ListenAndServe(":8080", nil))
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"contact-list-api-1/config"
+	"contact-list-api-1/database"
+	"contact-list-api-1/migrations"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+const usage = `usage:
+  migrate up        apply all pending migrations
+  migrate down N    roll back the last N applied migrations
+  migrate status    list migrations and whether they are applied`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg, err := config.LoadConfig("config.json")
+	if err != nil {
+		log.Fatal("Error loading configuration: ", err)
+	}
+	if cfg.DB.Driver == config.DriverMemory {
+		log.Fatal("The in-memory storage backend has no schema to migrate")
+	}
+	db, err := database.Open(cfg.DB)
+	if err != nil {
+		log.Fatal("Error connecting to database: ", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := migrations.Up(db)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		steps, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			log.Fatalf("Invalid number of migrations %q", os.Args[2])
+		}
+		rolledBack, err := migrations.Down(db, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrations.GetStatus(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
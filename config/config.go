@@ -3,18 +3,60 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"time"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
 )
 
 type DBConfig struct {
+	Driver   string `json:"driver"`
 	User     string `json:"user"`
 	Password string `json:"password"`
 	Host     string `json:"host"`
+	Port     int    `json:"port"`
 	Name     string `json:"name"`
+	Path     string `json:"path"`
+	SSLMode  string `json:"sslmode"`
+	Schema   string `json:"schema"`
+}
+
+const (
+	DefaultTrashRetentionDays        = 30
+	DefaultTrashPurgeIntervalMinutes = 60
+)
+
+type TrashConfig struct {
+	RetentionDays        int `json:"retention_days"`
+	PurgeIntervalMinutes int `json:"purge_interval_minutes"`
+}
+
+// Retention is how long deleted lists and contacts stay in the trash before
+// they are purged.
+func (c TrashConfig) Retention() time.Duration {
+	days := c.RetentionDays
+	if days <= 0 {
+		days = DefaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (c TrashConfig) PurgeInterval() time.Duration {
+	minutes := c.PurgeIntervalMinutes
+	if minutes <= 0 {
+		minutes = DefaultTrashPurgeIntervalMinutes
+	}
+	return time.Duration(minutes) * time.Minute
 }
 
 type Config struct {
-	DB        DBConfig `json:"db"`
-	AuthToken string   `json:"auth_token"`
+	DB        DBConfig    `json:"db"`
+	AuthToken string      `json:"auth_token"`
+	Trash     TrashConfig `json:"trash"`
 }
 type ConfigTest struct {
 	DB DBConfig `json:"db"`
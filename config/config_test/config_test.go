@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -36,6 +37,46 @@ func TestLoadConfig(t *testing.T) {
 			},
 			expectedErr: false,
 		},
+		{
+			name: "SQLite Config",
+			fileContent: `{
+				"db": {
+					"driver": "sqlite",
+					"path": "/tmp/contacts.db"
+				},
+				"auth_token": "my-secret-token"
+			}`,
+			expected: &config.Config{
+				DB: config.DBConfig{
+					Driver: config.DriverSQLite,
+					Path:   "/tmp/contacts.db",
+				},
+				AuthToken: "my-secret-token",
+			},
+			expectedErr: false,
+		},
+		{
+			name: "Trash Config",
+			fileContent: `{
+				"db": {
+					"driver": "sqlite"
+				},
+				"trash": {
+					"retention_days": 7,
+					"purge_interval_minutes": 15
+				}
+			}`,
+			expected: &config.Config{
+				DB: config.DBConfig{
+					Driver: config.DriverSQLite,
+				},
+				Trash: config.TrashConfig{
+					RetentionDays:        7,
+					PurgeIntervalMinutes: 15,
+				},
+			},
+			expectedErr: false,
+		},
 		{
 			name: "Invalid JSON",
 			fileContent: `{
@@ -93,6 +134,38 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+func TestTrashConfigDefaults(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		trash                 config.TrashConfig
+		expectedRetention     time.Duration
+		expectedPurgeInterval time.Duration
+	}{
+		{
+			name:                  "Unset",
+			trash:                 config.TrashConfig{},
+			expectedRetention:     config.DefaultTrashRetentionDays * 24 * time.Hour,
+			expectedPurgeInterval: config.DefaultTrashPurgeIntervalMinutes * time.Minute,
+		},
+		{
+			name:                  "Configured",
+			trash:                 config.TrashConfig{RetentionDays: 7, PurgeIntervalMinutes: 15},
+			expectedRetention:     7 * 24 * time.Hour,
+			expectedPurgeInterval: 15 * time.Minute,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trash.Retention(); got != tt.expectedRetention {
+				t.Errorf("Expected retention %v, got %v", tt.expectedRetention, got)
+			}
+			if got := tt.trash.PurgeInterval(); got != tt.expectedPurgeInterval {
+				t.Errorf("Expected purge interval %v, got %v", tt.expectedPurgeInterval, got)
+			}
+		})
+	}
+}
 func TestLoadTestConfig(t *testing.T) {
 	testCases := []struct {
 		name        string
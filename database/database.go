@@ -0,0 +1,56 @@
+package database
+
+import (
+	"contact-list-api-1/config"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func Open(cfg config.DBConfig) (*gorm.DB, error) {
+	switch cfg.Driver {
+	case "", config.DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", cfg.User, cfg.Password, cfg.Host, cfg.Name)
+		return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	case config.DriverPostgres:
+		return gorm.Open(postgres.Open(PostgresDSN(cfg)), &gorm.Config{})
+	case config.DriverSQLite:
+		path := cfg.Path
+		if path == "" {
+			path = "contact-list.db"
+		}
+		return gorm.Open(sqlite.Open(path), &gorm.Config{})
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+func PostgresDSN(cfg config.DBConfig) string {
+	port := cfg.Port
+	if port == 0 {
+		port = 5432
+	}
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSNValue(cfg.Host), port, quoteDSNValue(cfg.User), quoteDSNValue(cfg.Password), quoteDSNValue(cfg.Name), quoteDSNValue(sslMode))
+	if cfg.Schema != "" {
+		dsn += " search_path=" + quoteDSNValue(cfg.Schema)
+	}
+	return dsn
+}
+
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
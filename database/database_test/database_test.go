@@ -0,0 +1,102 @@
+package database
+
+import (
+	"contact-list-api-1/config"
+	"contact-list-api-1/database"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cfg         config.DBConfig
+		expectedErr bool
+	}{
+		{
+			name: "SQLite",
+			cfg: config.DBConfig{
+				Driver: config.DriverSQLite,
+				Path:   filepath.Join(t.TempDir(), "test.db"),
+			},
+			expectedErr: false,
+		},
+		{
+			name:        "UnsupportedDriver",
+			cfg:         config.DBConfig{Driver: "oracle"},
+			expectedErr: true,
+		},
+		{
+			name:        "MemoryIsNotADatabase",
+			cfg:         config.DBConfig{Driver: config.DriverMemory},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := database.Open(tt.cfg)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("Expected error: %v, got: %v", tt.expectedErr, err)
+			}
+			if err == nil {
+				if err := db.Exec("SELECT 1").Error; err != nil {
+					t.Errorf("Expected usable connection, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgresDSN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.DBConfig
+		expected string
+	}{
+		{
+			name: "Defaults",
+			cfg: config.DBConfig{
+				Driver:   config.DriverPostgres,
+				User:     "admin",
+				Password: "secret",
+				Host:     "localhost",
+				Name:     "contacts",
+			},
+			expected: "host=localhost port=5432 user=admin password=secret dbname=contacts sslmode=disable",
+		},
+		{
+			name: "WithSSLModeAndSchema",
+			cfg: config.DBConfig{
+				Driver:   config.DriverPostgres,
+				User:     "admin",
+				Password: "secret",
+				Host:     "db.internal",
+				Port:     6432,
+				Name:     "contacts",
+				SSLMode:  "require",
+				Schema:   "crm",
+			},
+			expected: "host=db.internal port=6432 user=admin password=secret dbname=contacts sslmode=require search_path=crm",
+		},
+		{
+			name: "QuotesSpecialCharacters",
+			cfg: config.DBConfig{
+				Driver:   config.DriverPostgres,
+				User:     "admin",
+				Password: `it's a secret`,
+				Host:     "localhost",
+				Name:     "contacts",
+			},
+			expected: `host=localhost port=5432 user=admin password='it\'s a secret' dbname=contacts sslmode=disable`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if dsn := database.PostgresDSN(tt.cfg); dsn != tt.expected {
+				t.Errorf("Expected DSN %q, got %q", tt.expected, dsn)
+			}
+		})
+	}
+}
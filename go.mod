@@ -5,9 +5,18 @@ go 1.22.5
 require (
 	github.com/go-sql-driver/mysql v1.7.0 // indirect
 	github.com/google/uuid v1.6.0 // direct
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/pgx/v5 v5.5.5 // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.22 // indirect
+	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/sync v0.2.0 // indirect
+	golang.org/x/text v0.14.0 // direct
 	gorm.io/driver/mysql v1.5.7 // direct
+	gorm.io/driver/postgres v1.5.9 // direct
+	gorm.io/driver/sqlite v1.5.6 // direct
 	gorm.io/gorm v1.25.11 // direct
 )
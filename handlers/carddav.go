@@ -0,0 +1,693 @@
+package handlers
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"contact-list-api-1/services/vcard"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// davRoot is the principal of the one user and the home of the address books,
+// one per list at davRoot/{list uuid}/, with the members as
+// davRoot/{list uuid}/{contact uuid}.vcf.
+const davRoot = "/carddav/"
+
+// syncTokenPrefix makes URIs of sync tokens, as RFC 6578 requires.
+const syncTokenPrefix = "data:,"
+
+const davAllow = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+
+// CardDAVHandler serves the lists as CardDAV address books (RFC 6352), with
+// sync-collection reports (RFC 6578) for clients that sync incrementally.
+type CardDAVHandler struct {
+	service services.AddressBookService
+}
+
+func NewCardDAVHandler(service services.AddressBookService) *CardDAVHandler {
+	return &CardDAVHandler{service: service}
+}
+
+// WellKnown sends clients that discover the server by /.well-known/carddav
+// (RFC 6764) to the principal.
+func (h *CardDAVHandler) WellKnown(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, davRoot, http.StatusMovedPermanently)
+}
+
+// davPath is a path under davRoot. The root has no list; an address book has
+// no card. card is set for every path that names a resource in an address
+// book, while contact is only set if the name is a UUID followed by .vcf.
+// UUIDs must be in the lowercase dashed form of our own hrefs: clients compare
+// hrefs literally, so a card stored under another spelling of its UUID would
+// come back under a name they do not know.
+type davPath struct {
+	list    uuid.UUID
+	card    bool
+	contact uuid.UUID
+}
+
+func parseDAVPath(p string) (davPath, bool) {
+	rest, ok := strings.CutPrefix(p, davRoot)
+	if !ok {
+		return davPath{}, p+"/" == davRoot
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if parts[0] == "" {
+		return davPath{}, len(parts) == 1
+	}
+	var path davPath
+	if path.list, ok = parseCanonicalUUID(parts[0]); !ok || len(parts) > 2 {
+		return davPath{}, false
+	}
+	if len(parts) == 2 {
+		path.card = true
+		if name, ok := strings.CutSuffix(parts[1], ".vcf"); ok {
+			path.contact, _ = parseCanonicalUUID(name)
+		}
+	}
+	return path, true
+}
+
+// parseCanonicalUUID only accepts the form UUID.String returns, not the
+// uppercase, braced, urn:uuid: or undashed forms uuid.Parse also takes.
+func parseCanonicalUUID(s string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(s)
+	if err != nil || id.String() != s {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func bookHref(listUUID uuid.UUID) string {
+	return davRoot + listUUID.String() + "/"
+}
+
+func cardHref(listUUID, contactUUID uuid.UUID) string {
+	return bookHref(listUUID) + contactUUID.String() + ".vcf"
+}
+
+func (h *CardDAVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := parseDAVPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("DAV", "1, 3, addressbook")
+	switch {
+	case r.Method == http.MethodOptions:
+		w.Header().Set("Allow", davAllow)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "PROPFIND":
+		h.propfind(w, r, path)
+	case r.Method == "REPORT" && path.list != uuid.Nil && !path.card:
+		h.report(w, r, path.list)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && path.card:
+		h.getCard(w, r, path)
+	case r.Method == http.MethodPut && path.card:
+		h.putCard(w, r, path)
+	case r.Method == http.MethodDelete && path.card:
+		h.deleteCard(w, r, path)
+	default:
+		w.Header().Set("Allow", davAllow)
+		http.Error(w, "Method not allowed on this resource", http.StatusMethodNotAllowed)
+	}
+}
+
+// propRequest is what a PROPFIND or a report asks for: the named properties,
+// all of them, or only their names.
+type propRequest struct {
+	props     []davElement
+	all       bool
+	namesOnly bool
+}
+
+func parsePropRequest(e *davElement) propRequest {
+	switch {
+	case e == nil || e.child(davName(nsDAV, "allprop")) != nil:
+		return propRequest{all: true}
+	case e.child(davName(nsDAV, "propname")) != nil:
+		return propRequest{namesOnly: true}
+	case e.child(davName(nsDAV, "prop")) != nil:
+		return propRequest{props: e.child(davName(nsDAV, "prop")).Children}
+	}
+	return propRequest{all: true}
+}
+
+// davResource is the root, an address book or a card as PROPFIND and reports
+// describe it. names are the properties allprop returns; prop returns false
+// for properties the resource does not have.
+type davResource struct {
+	href  string
+	names []xml.Name
+	prop  func(requested davElement) (davElement, bool, error)
+}
+
+func (resource davResource) describe(request propRequest) (davElement, error) {
+	requested := request.props
+	if request.all || request.namesOnly {
+		requested = make([]davElement, len(resource.names))
+		for i, name := range resource.names {
+			requested[i] = newElement(name)
+		}
+	}
+	if request.namesOnly {
+		return propResponse(resource.href, requested, nil), nil
+	}
+	var found, missing []davElement
+	for _, prop := range requested {
+		value, ok, err := resource.prop(prop)
+		if err != nil {
+			return davElement{}, err
+		}
+		if ok {
+			found = append(found, value)
+		} else {
+			missing = append(missing, newElement(prop.XMLName))
+		}
+	}
+	return propResponse(resource.href, found, missing), nil
+}
+
+func privileges(names ...string) davElement {
+	set := newElement(davName(nsDAV, "current-user-privilege-set"))
+	for _, name := range names {
+		set.Children = append(set.Children, newElement(davName(nsDAV, "privilege"), newElement(davName(nsDAV, name))))
+	}
+	return set
+}
+
+// commonProp answers the properties every resource has.
+func commonProp(name xml.Name) (davElement, bool) {
+	switch name {
+	case davName(nsDAV, "current-user-principal"), davName(nsDAV, "principal-URL"):
+		return newElement(name, hrefElement(davRoot)), true
+	}
+	return davElement{}, false
+}
+
+func rootResource() davResource {
+	return davResource{
+		href: davRoot,
+		names: []xml.Name{
+			davName(nsDAV, "resourcetype"), davName(nsDAV, "displayname"), davName(nsDAV, "current-user-principal"),
+			davName(nsDAV, "principal-URL"), davName(nsCardDAV, "addressbook-home-set"), davName(nsDAV, "current-user-privilege-set"),
+		},
+		prop: func(requested davElement) (davElement, bool, error) {
+			name := requested.XMLName
+			switch name {
+			case davName(nsDAV, "resourcetype"):
+				return newElement(name, newElement(davName(nsDAV, "collection")), newElement(davName(nsDAV, "principal"))), true, nil
+			case davName(nsDAV, "displayname"):
+				return textElement(name, "Contacts"), true, nil
+			case davName(nsCardDAV, "addressbook-home-set"):
+				return newElement(name, hrefElement(davRoot)), true, nil
+			case davName(nsDAV, "current-user-privilege-set"):
+				return privileges("read"), true, nil
+			}
+			value, ok := commonProp(name)
+			return value, ok, nil
+		},
+	}
+}
+
+func (h *CardDAVHandler) bookResource(list models.List) davResource {
+	var token string
+	syncToken := func() (string, error) {
+		if token != "" {
+			return token, nil
+		}
+		t, err := h.service.SyncToken(list.UUID)
+		if err != nil {
+			return "", err
+		}
+		token = syncTokenPrefix + t
+		return token, nil
+	}
+	return davResource{
+		href: bookHref(list.UUID),
+		names: []xml.Name{
+			davName(nsDAV, "resourcetype"), davName(nsDAV, "displayname"), davName(nsDAV, "current-user-principal"),
+			davName(nsDAV, "sync-token"), davName(nsCalendarServer, "getctag"), davName(nsDAV, "getlastmodified"),
+			davName(nsCardDAV, "supported-address-data"), davName(nsDAV, "supported-report-set"), davName(nsDAV, "current-user-privilege-set"),
+		},
+		prop: func(requested davElement) (davElement, bool, error) {
+			name := requested.XMLName
+			switch name {
+			case davName(nsDAV, "resourcetype"):
+				return newElement(name, newElement(davName(nsDAV, "collection")), newElement(davName(nsCardDAV, "addressbook"))), true, nil
+			case davName(nsDAV, "displayname"):
+				return textElement(name, list.Name), true, nil
+			case davName(nsDAV, "sync-token"), davName(nsCalendarServer, "getctag"):
+				token, err := syncToken()
+				return textElement(name, token), err == nil, err
+			case davName(nsDAV, "getlastmodified"):
+				return textElement(name, list.UpdatedAt.UTC().Format(http.TimeFormat)), true, nil
+			case davName(nsCardDAV, "supported-address-data"):
+				data := newElement(name)
+				for _, version := range []string{vcard.Version3, vcard.Version4} {
+					data.Children = append(data.Children, davElement{XMLName: davName(nsCardDAV, "address-data-type"), Attrs: []xml.Attr{
+						{Name: xml.Name{Local: "content-type"}, Value: "text/vcard"},
+						{Name: xml.Name{Local: "version"}, Value: version},
+					}})
+				}
+				return data, true, nil
+			case davName(nsDAV, "supported-report-set"):
+				set := newElement(name)
+				for _, report := range []xml.Name{davName(nsCardDAV, "addressbook-multiget"), davName(nsCardDAV, "addressbook-query"), davName(nsDAV, "sync-collection")} {
+					set.Children = append(set.Children, newElement(davName(nsDAV, "supported-report"), newElement(davName(nsDAV, "report"), newElement(report))))
+				}
+				return set, true, nil
+			case davName(nsDAV, "current-user-privilege-set"):
+				return privileges("read", "write", "write-content", "bind", "unbind"), true, nil
+			}
+			value, ok := commonProp(name)
+			return value, ok, nil
+		},
+	}
+}
+
+func cardResource(listUUID uuid.UUID, contact models.Contact) davResource {
+	return davResource{
+		href: cardHref(listUUID, contact.UUID),
+		names: []xml.Name{
+			davName(nsDAV, "resourcetype"), davName(nsDAV, "getetag"), davName(nsDAV, "getcontenttype"),
+			davName(nsDAV, "getlastmodified"), davName(nsDAV, "current-user-principal"), davName(nsDAV, "current-user-privilege-set"),
+		},
+		prop: func(requested davElement) (davElement, bool, error) {
+			name := requested.XMLName
+			switch name {
+			case davName(nsDAV, "resourcetype"):
+				return newElement(name), true, nil
+			case davName(nsDAV, "getetag"):
+				return textElement(name, formatETag(contact.Version)), true, nil
+			case davName(nsDAV, "getcontenttype"):
+				return textElement(name, vcardContentType), true, nil
+			case davName(nsDAV, "getlastmodified"):
+				return textElement(name, contact.UpdatedAt.UTC().Format(http.TimeFormat)), true, nil
+			case davName(nsDAV, "current-user-privilege-set"):
+				return privileges("read", "write", "write-content"), true, nil
+			case davName(nsCardDAV, "address-data"):
+				// The version is 3.0 unless asked for (RFC 6352, section 10.4).
+				contentType := requested.attr("content-type")
+				if contentType == "" {
+					contentType = "text/vcard"
+				}
+				params := map[string]string{"version": vcard.Version3}
+				if version := requested.attr("version"); version != "" {
+					params["version"] = version
+				}
+				version, ok, err := vcardVersion(contentType, params)
+				if !ok || err != nil {
+					return davElement{}, false, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "supported-address-data"), message: "address data is available as text/vcard 3.0 or 4.0"}
+				}
+				var data strings.Builder
+				vcard.Write(&data, contact, version)
+				return textElement(name, data.String()), true, nil
+			}
+			value, ok := commonProp(name)
+			return value, ok, nil
+		},
+	}
+}
+
+func (h *CardDAVHandler) propfind(w http.ResponseWriter, r *http.Request, path davPath) {
+	depth := r.Header.Get("Depth")
+	if depth != "0" && depth != "1" {
+		writeDAVError(w, &davError{status: http.StatusForbidden, condition: davName(nsDAV, "propfind-finite-depth"), message: "Depth must be 0 or 1"})
+		return
+	}
+	body, err := readDAVBody(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid XML: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	request := parsePropRequest(body)
+
+	var resources []davResource
+	switch {
+	case path.list == uuid.Nil:
+		resources = append(resources, rootResource())
+		if depth == "1" {
+			lists, err := h.service.GetAddressBooks()
+			if err != nil {
+				writeCardDAVError(w, err)
+				return
+			}
+			for _, list := range lists {
+				resources = append(resources, h.bookResource(list))
+			}
+		}
+	case !path.card:
+		list, err := h.service.GetAddressBook(path.list)
+		if err != nil {
+			writeCardDAVError(w, err)
+			return
+		}
+		resources = append(resources, h.bookResource(*list))
+		if depth == "1" {
+			cards, err := h.service.GetCards(path.list, nil)
+			if err != nil {
+				writeCardDAVError(w, err)
+				return
+			}
+			for _, card := range cards {
+				resources = append(resources, cardResource(path.list, card))
+			}
+		}
+	default:
+		card, err := h.getPathCard(path)
+		if err != nil {
+			writeCardDAVError(w, err)
+			return
+		}
+		resources = append(resources, cardResource(path.list, *card))
+	}
+	h.writeResources(w, resources, request)
+}
+
+func (h *CardDAVHandler) writeResources(w http.ResponseWriter, resources []davResource, request propRequest, extra ...davElement) {
+	multistatus := newElement(davName(nsDAV, "multistatus"))
+	for _, resource := range resources {
+		response, err := resource.describe(request)
+		if err != nil {
+			writeCardDAVError(w, err)
+			return
+		}
+		multistatus.Children = append(multistatus.Children, response)
+	}
+	multistatus.Children = append(multistatus.Children, extra...)
+	writeDAVXML(w, http.StatusMultiStatus, multistatus)
+}
+
+func (h *CardDAVHandler) report(w http.ResponseWriter, r *http.Request, listUUID uuid.UUID) {
+	body, err := readDAVBody(r.Body)
+	if err != nil || body == nil {
+		http.Error(w, "Invalid XML: the body must be a report", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.service.GetAddressBook(listUUID); err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	request := parsePropRequest(body)
+	switch body.XMLName {
+	case davName(nsCardDAV, "addressbook-multiget"):
+		h.multiget(w, listUUID, body, request)
+	case davName(nsCardDAV, "addressbook-query"):
+		h.query(w, listUUID, body, request)
+	case davName(nsDAV, "sync-collection"):
+		h.syncCollection(w, listUUID, body, request)
+	default:
+		writeDAVError(w, &davError{status: http.StatusForbidden, condition: davName(nsDAV, "supported-report"), message: "Unsupported report " + body.XMLName.Local})
+	}
+}
+
+// multiget returns the cards of the hrefs, in their order. Hrefs that are not
+// cards of the address book are answered with 404.
+func (h *CardDAVHandler) multiget(w http.ResponseWriter, listUUID uuid.UUID, body *davElement, request propRequest) {
+	var hrefs []string
+	var contactUUIDs []uuid.UUID
+	for _, child := range body.Children {
+		if child.XMLName != davName(nsDAV, "href") {
+			continue
+		}
+		href := strings.TrimSpace(child.Text)
+		hrefs = append(hrefs, href)
+		if path, ok := parseHref(href); ok && path.list == listUUID && path.contact != uuid.Nil {
+			contactUUIDs = append(contactUUIDs, path.contact)
+		}
+	}
+	cards, err := h.service.GetCards(listUUID, contactUUIDs)
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	found := make(map[uuid.UUID]models.Contact, len(cards))
+	for _, card := range cards {
+		found[card.UUID] = card
+	}
+
+	multistatus := newElement(davName(nsDAV, "multistatus"))
+	for _, href := range hrefs {
+		path, _ := parseHref(href)
+		card, ok := found[path.contact]
+		if !ok || path.list != listUUID {
+			multistatus.Children = append(multistatus.Children, statusResponse(href, http.StatusNotFound))
+			continue
+		}
+		response, err := cardResource(listUUID, card).describe(request)
+		if err != nil {
+			writeCardDAVError(w, err)
+			return
+		}
+		multistatus.Children = append(multistatus.Children, response)
+	}
+	writeDAVXML(w, http.StatusMultiStatus, multistatus)
+}
+
+// query returns the cards matching the filter. When a limit leaves some out,
+// the address book itself is answered with 507 (RFC 6352, section 8.6.1).
+func (h *CardDAVHandler) query(w http.ResponseWriter, listUUID uuid.UUID, body *davElement, request propRequest) {
+	filter, err := parseCardFilter(body.child(davName(nsCardDAV, "filter")))
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	limit, err := body.nresults(nsCardDAV)
+	if err != nil {
+		http.Error(w, "Invalid limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	cards, err := h.service.GetCards(listUUID, nil)
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	var resources []davResource
+	var extra []davElement
+	for _, card := range cards {
+		if !filter.matches(card) {
+			continue
+		}
+		if limit > 0 && len(resources) == limit {
+			extra = append(extra, statusResponse(bookHref(listUUID), http.StatusInsufficientStorage))
+			break
+		}
+		resources = append(resources, cardResource(listUUID, card))
+	}
+	h.writeResources(w, resources, request, extra...)
+}
+
+// syncCollection returns the cards changed and removed since the sync token
+// of the request, or every card for an empty token, followed by the new token
+// (RFC 6578).
+func (h *CardDAVHandler) syncCollection(w http.ResponseWriter, listUUID uuid.UUID, body *davElement, request propRequest) {
+	var token string
+	if element := body.child(davName(nsDAV, "sync-token")); element != nil && strings.TrimSpace(element.Text) != "" {
+		var ok bool
+		if token, ok = strings.CutPrefix(strings.TrimSpace(element.Text), syncTokenPrefix); !ok {
+			writeCardDAVError(w, services.ErrInvalidSyncToken)
+			return
+		}
+	}
+	limit, err := body.nresults(nsDAV)
+	if err != nil {
+		http.Error(w, "Invalid limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	changes, err := h.service.GetChanges(listUUID, token, limit)
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+
+	resources := make([]davResource, len(changes.Changed))
+	for i, card := range changes.Changed {
+		resources[i] = cardResource(listUUID, card)
+	}
+	var extra []davElement
+	for _, contactUUID := range changes.Removed {
+		extra = append(extra, statusResponse(cardHref(listUUID, contactUUID), http.StatusNotFound))
+	}
+	if changes.Truncated {
+		extra = append(extra, statusResponse(bookHref(listUUID), http.StatusInsufficientStorage))
+	}
+	extra = append(extra, textElement(davName(nsDAV, "sync-token"), syncTokenPrefix+changes.Token))
+	h.writeResources(w, resources, request, extra...)
+}
+
+// parseHref takes the path of an href, which clients may send as a full URL.
+func parseHref(href string) (davPath, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return davPath{}, false
+	}
+	return parseDAVPath(u.Path)
+}
+
+func (h *CardDAVHandler) getPathCard(path davPath) (*models.Contact, error) {
+	if path.contact == uuid.Nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return h.service.GetCard(path.list, path.contact)
+}
+
+// getCard answers with vCard 3.0, unless Accept asks for text/vcard, which is
+// version 4.0 unless its version parameter says otherwise.
+func (h *CardDAVHandler) getCard(w http.ResponseWriter, r *http.Request, path davPath) {
+	version := vcard.Version3
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if v, ok, err := vcardVersion(mediaType, params); ok && err == nil {
+			version = v
+			break
+		}
+	}
+	card, err := h.getPathCard(path)
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	// getetag and If-Match refer to the default vCard 3.0, so only 4.0 gets a
+	// tag of its own.
+	etag := formatETag(card.Version)
+	if version != vcard.Version3 {
+		etag = formatVariantETag(card.Version, "vcard-"+version)
+	}
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", card.UpdatedAt.UTC().Format(http.TimeFormat))
+	if etagListMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", vcardContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	vcard.Write(w, *card, version)
+}
+
+// putCard stores a single vCard as a member of the list. The card is named by
+// the UUID of the contact; its own UID is not used. Only the fields a contact
+// has are kept, so no ETag is returned and clients fetch the card again.
+func (h *CardDAVHandler) putCard(w http.ResponseWriter, r *http.Request, path davPath) {
+	if path.contact == uuid.Nil {
+		http.Error(w, "Cards are named by a lowercase UUID followed by .vcf", http.StatusForbidden)
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "text/vcard" && mediaType != "text/x-vcard" {
+		http.Error(w, "Content-Type must be text/vcard", http.StatusUnsupportedMediaType)
+		return
+	}
+	decoder := vcard.NewDecoder(r.Body)
+	contact, _, err := decoder.Decode()
+	if err == nil {
+		if _, _, next := decoder.Decode(); next != io.EOF {
+			err = errors.New("the body must be exactly one vCard")
+		}
+	}
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("the body must be exactly one vCard")
+		}
+		writeDAVError(w, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "valid-address-data"), message: err.Error()})
+		return
+	}
+	contact.UUID = path.contact
+
+	var version uint
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if ifMatch != "" || ifNoneMatch != "" {
+		existing, err := h.service.GetCard(path.list, path.contact)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			writeCardDAVError(w, err)
+			return
+		}
+		if (ifNoneMatch == "*" && existing != nil) || (ifMatch != "" && (existing == nil || !etagMatches(ifMatch, existing.Version, false))) {
+			http.Error(w, "Card has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+			return
+		}
+		if ifMatch != "" {
+			version = existing.Version
+		}
+	}
+
+	created, err := h.service.PutCard(path.list, contact, version)
+	if err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// deleteCard removes the contact from the list, as DELETE
+// /lists/{uuid}/contacts/{contactUUID} does.
+func (h *CardDAVHandler) deleteCard(w http.ResponseWriter, r *http.Request, path davPath) {
+	var version uint
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		card, err := h.getPathCard(path)
+		if err != nil {
+			writeCardDAVError(w, err)
+			return
+		}
+		if !etagMatches(ifMatch, card.Version, false) {
+			http.Error(w, "Card has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+			return
+		}
+		version = card.Version
+	}
+	if path.contact == uuid.Nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.service.DeleteCard(path.list, path.contact, version); err != nil {
+		writeCardDAVError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCardDAVError(w http.ResponseWriter, err error) {
+	var (
+		condition        *davError
+		validationErrors *services.ValidationErrors
+		conflictErr      *repositories.ConflictError
+	)
+	switch {
+	case errors.As(err, &condition):
+		writeDAVError(w, condition)
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, repositories.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidSyncToken):
+		writeDAVError(w, &davError{status: http.StatusForbidden, condition: davName(nsDAV, "valid-sync-token"), message: "The sync token has expired, sync the address book again"})
+	case errors.As(err, &validationErrors):
+		messages := make([]string, len(validationErrors.Errors))
+		for i, validationError := range validationErrors.Errors {
+			messages[i] = validationError.Field + ": " + validationError.Message
+		}
+		writeDAVError(w, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "valid-address-data"), message: strings.Join(messages, "; ")})
+	case errors.As(err, &conflictErr):
+		http.Error(w, conflictMessage(conflictErr), http.StatusConflict)
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		http.Error(w, "Card has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+	default:
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
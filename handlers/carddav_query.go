@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"contact-list-api-1/models"
+	"net/http"
+	"strings"
+)
+
+// cardFilter is the filter of an addressbook-query report (RFC 6352, section
+// 10.5), matched against the properties the cards of this server have.
+type cardFilter struct {
+	allOf bool
+	props []propFilter
+}
+
+type propFilter struct {
+	name       string
+	allOf      bool
+	notDefined bool
+	texts      []textMatch
+}
+
+type textMatch struct {
+	text      string
+	matchType string
+	negate    bool
+	fold      bool
+}
+
+func parseCardFilter(e *davElement) (cardFilter, error) {
+	var f cardFilter
+	if e == nil {
+		return f, nil
+	}
+	f.allOf = e.attr("test") == "allof"
+	for _, child := range e.Children {
+		if child.XMLName != davName(nsCardDAV, "prop-filter") {
+			continue
+		}
+		prop := propFilter{name: strings.ToUpper(child.attr("name")), allOf: child.attr("test") == "allof"}
+		for _, test := range child.Children {
+			switch test.XMLName {
+			case davName(nsCardDAV, "is-not-defined"):
+				prop.notDefined = true
+			case davName(nsCardDAV, "text-match"):
+				match, err := parseTextMatch(test)
+				if err != nil {
+					return f, err
+				}
+				prop.texts = append(prop.texts, match)
+			case davName(nsCardDAV, "param-filter"):
+				return f, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "supported-filter"), message: "param-filter is not supported"}
+			}
+		}
+		f.props = append(f.props, prop)
+	}
+	return f, nil
+}
+
+func parseTextMatch(e davElement) (textMatch, error) {
+	match := textMatch{text: e.Text, matchType: e.attr("match-type"), negate: e.attr("negate-condition") == "yes"}
+	switch e.attr("collation") {
+	case "", "i;unicode-casemap", "i;ascii-casemap":
+		match.fold = true
+		match.text = strings.ToLower(match.text)
+	case "i;octet":
+	default:
+		return match, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "supported-collation"), message: "unsupported collation " + e.attr("collation")}
+	}
+	switch match.matchType {
+	case "":
+		match.matchType = "contains"
+	case "equals", "contains", "starts-with", "ends-with":
+	default:
+		return match, &davError{status: http.StatusForbidden, condition: davName(nsCardDAV, "supported-filter"), message: "unsupported match-type " + match.matchType}
+	}
+	return match, nil
+}
+
+// matches is true for a filter without prop-filters, which selects every card.
+func (f cardFilter) matches(contact models.Contact) bool {
+	if len(f.props) == 0 {
+		return true
+	}
+	return test(f.allOf, len(f.props), func(i int) bool { return f.props[i].matches(contact) })
+}
+
+func (p propFilter) matches(contact models.Contact) bool {
+	value, defined := cardProperty(contact, p.name)
+	if p.notDefined {
+		return !defined
+	}
+	if !defined {
+		return false
+	}
+	if len(p.texts) == 0 {
+		return true
+	}
+	return test(p.allOf, len(p.texts), func(i int) bool { return p.texts[i].matches(value) })
+}
+
+func (m textMatch) matches(value string) bool {
+	if m.fold {
+		value = strings.ToLower(value)
+	}
+	var matched bool
+	switch m.matchType {
+	case "equals":
+		matched = value == m.text
+	case "starts-with":
+		matched = strings.HasPrefix(value, m.text)
+	case "ends-with":
+		matched = strings.HasSuffix(value, m.text)
+	default:
+		matched = strings.Contains(value, m.text)
+	}
+	return matched != m.negate
+}
+
+// test is anyof or, with allOf, allof over n tests.
+func test(allOf bool, n int, passes func(int) bool) bool {
+	for i := 0; i < n; i++ {
+		if passes(i) != allOf {
+			return !allOf
+		}
+	}
+	return allOf
+}
+
+// cardProperty is the value of a vCard property as the cards of the contact
+// have it, before escaping.
+func cardProperty(contact models.Contact, name string) (string, bool) {
+	var value string
+	switch name {
+	case "UID":
+		value = "urn:uuid:" + contact.UUID.String()
+	case "FN":
+		value = strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+	case "N":
+		value = contact.LastName + ";" + contact.FirstName + ";;;"
+	case "TEL":
+		value = contact.Mobile
+	case "EMAIL":
+		value = contact.Email
+	case "ADR":
+		value = ";;;;;;" + contact.CountryCode
+	}
+	return value, value != ""
+}
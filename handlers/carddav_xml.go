@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	nsDAV            = "DAV:"
+	nsCardDAV        = "urn:ietf:params:xml:ns:carddav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// davElement is any element of a WebDAV request or response. Requests are read
+// into it whole, since their elements come in any order and with any prefix.
+type davElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Text     string       `xml:",chardata"`
+	Children []davElement `xml:",any"`
+}
+
+func davName(space, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+func newElement(name xml.Name, children ...davElement) davElement {
+	return davElement{XMLName: name, Children: children}
+}
+
+func textElement(name xml.Name, text string) davElement {
+	return davElement{XMLName: name, Text: text}
+}
+
+func hrefElement(href string) davElement {
+	return textElement(davName(nsDAV, "href"), href)
+}
+
+func (e *davElement) child(name xml.Name) *davElement {
+	for i := range e.Children {
+		if e.Children[i].XMLName == name {
+			return &e.Children[i]
+		}
+	}
+	return nil
+}
+
+func (e *davElement) attr(local string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// nresults reads the number in a limit element of either namespace, or 0 when
+// there is none.
+func (e *davElement) nresults(space string) (int, error) {
+	limit := e.child(davName(space, "limit"))
+	if limit == nil {
+		return 0, nil
+	}
+	nresults := limit.child(davName(space, "nresults"))
+	if nresults == nil {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(nresults.Text))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid nresults %q", nresults.Text)
+	}
+	return n, nil
+}
+
+// readDAVBody parses the request body, or returns nil for an empty one.
+func readDAVBody(body io.Reader) (*davElement, error) {
+	var root davElement
+	if err := xml.NewDecoder(body).Decode(&root); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &root, nil
+}
+
+// davError is a failed precondition, answered with a DAV:error body naming
+// it (RFC 4918, section 16).
+type davError struct {
+	status    int
+	condition xml.Name
+	message   string
+}
+
+func (e *davError) Error() string {
+	return e.message
+}
+
+func writeDAVError(w http.ResponseWriter, err *davError) {
+	body := newElement(davName(nsDAV, "error"), newElement(err.condition))
+	if err.message != "" {
+		body.Children = append(body.Children, textElement(davName(nsDAV, "responsedescription"), err.message))
+	}
+	writeDAVXML(w, err.status, body)
+}
+
+func writeDAVXML(w http.ResponseWriter, status int, body davElement) {
+	w.Header().Set("Content-Type", `application/xml; charset="utf-8"`)
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(body)
+}
+
+func statusElement(status int) davElement {
+	return textElement(davName(nsDAV, "status"), fmt.Sprintf("HTTP/1.1 %d %s", status, http.StatusText(status)))
+}
+
+// propResponse describes a resource with the properties it has and, under
+// 404, those it does not have.
+func propResponse(href string, found, missing []davElement) davElement {
+	response := newElement(davName(nsDAV, "response"), hrefElement(href))
+	if len(found) > 0 {
+		response.Children = append(response.Children, newElement(davName(nsDAV, "propstat"),
+			newElement(davName(nsDAV, "prop"), found...), statusElement(http.StatusOK)))
+	}
+	if len(missing) > 0 {
+		response.Children = append(response.Children, newElement(davName(nsDAV, "propstat"),
+			newElement(davName(nsDAV, "prop"), missing...), statusElement(http.StatusNotFound)))
+	}
+	return response
+}
+
+func statusResponse(href string, status int) davElement {
+	return newElement(davName(nsDAV, "response"), hrefElement(href), statusElement(status))
+}
@@ -3,14 +3,17 @@ package handlers
 import (
 	"contact-list-api-1/models"
 	"encoding/json"
+	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/google/uuid"
 
-	//"contact-list-api-1/repositories"
+	"contact-list-api-1/repositories"
 	"contact-list-api-1/services"
+	"contact-list-api-1/services/vcard"
 	"errors"
+	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -28,25 +31,172 @@ func (h *ContactHandler) GetAllContacts(w http.ResponseWriter, r *http.Request)
 	name := queryParams.Get("name")
 	mobile := queryParams.Get("mobile")
 	email := queryParams.Get("email")
-	pageStr := queryParams.Get("page")
-	pageSizeStr := queryParams.Get("pageSize")
-	pageNum, err := strconv.Atoi(pageStr)
-
-	if err != nil || pageNum <= 0 {
-		pageNum = 1
+	includeDeleted, err := parseBool(queryParams, "include_deleted", false)
+	if err != nil {
+		http.Error(w, "Invalid include_deleted value", http.StatusBadRequest)
+		return
 	}
-	pageSizeNum, err := strconv.Atoi(pageSizeStr)
-	if err != nil || pageSizeNum <= 0 {
-		pageSizeNum = 10
+	sort, err := parseSort(queryParams, contactSortFields)
+	if err != nil {
+		http.Error(w, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
 	}
-	contacts, err := h.service.GetAllContacts(name, mobile, email, pageNum, pageSizeNum)
+	pageNum, pageSizeNum := parsePagination(queryParams)
+	if queryParams.Has("cursor") {
+		if queryParams.Has("page") {
+			http.Error(w, "cursor and page cannot be combined", http.StatusBadRequest)
+			return
+		}
+		contacts, nextCursor, err := h.service.GetContactsAfter(name, mobile, email, queryParams.Get("filter"), includeDeleted, sort, queryParams.Get("cursor"), pageSizeNum)
+		if err != nil {
+			var validationErrors *services.ValidationErrors
+			if errors.Is(err, services.ErrInvalidCursor) {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			} else if errors.As(err, &validationErrors) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(validationErrors)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		setCursorHeaders(w, r, pageSizeNum, nextCursor)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(contacts)
+		return
+	}
+	contacts, total, err := h.service.GetAllContacts(name, mobile, email, queryParams.Get("filter"), includeDeleted, sort, pageNum, pageSizeNum)
 	if err != nil {
+		var validationErrors *services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	setPaginationHeaders(w, r, pageNum, pageSizeNum, total)
 	json.NewEncoder(w).Encode(contacts)
 
 }
+
+// ExportContacts streams every contact matching the filters of GetAllContacts
+// as CSV, NDJSON or vCards.
+func (h *ContactHandler) ExportContacts(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	includeDeleted, err := parseBool(queryParams, "include_deleted", false)
+	if err != nil {
+		http.Error(w, "Invalid include_deleted value", http.StatusBadRequest)
+		return
+	}
+	sort, err := parseSort(queryParams, contactSortFields)
+	if err != nil {
+		http.Error(w, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	export, ok := newContactExport(w, r)
+	if !ok {
+		return
+	}
+	err = h.service.ExportContacts(queryParams.Get("name"), queryParams.Get("mobile"), queryParams.Get("email"), queryParams.Get("filter"), includeDeleted, sort, export.write)
+	if err == nil {
+		err = export.finish()
+	}
+	if err != nil {
+		export.fail(err, writeExportError)
+	}
+}
+
+// ExportListContacts streams the members of a list matching the filters of
+// GetListContacts as CSV, NDJSON or vCards.
+func (h *ContactHandler) ExportListContacts(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	queryParams := r.URL.Query()
+	sort, err := parseSort(queryParams, contactSortFields)
+	if err != nil {
+		http.Error(w, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	export, ok := newContactExport(w, r)
+	if !ok {
+		return
+	}
+	err = h.service.ExportListContacts(listUUID, queryParams.Get("name"), queryParams.Get("mobile"), queryParams.Get("email"), queryParams.Get("filter"), sort, export.write)
+	if err == nil {
+		err = export.finish()
+	}
+	if err != nil {
+		export.fail(err, writeExportError)
+	}
+}
+
+func newContactExport(w http.ResponseWriter, r *http.Request) (*exportWriter, bool) {
+	format, version, err := exportFormat(r)
+	if errors.Is(err, errNotAcceptable) {
+		http.Error(w, "Export is available as text/csv, application/x-ndjson or text/vcard", http.StatusNotAcceptable)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Invalid format: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return newExportWriter(w, format, version, "contacts"), true
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	var validationErrors *services.ValidationErrors
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+	} else if errors.As(err, &validationErrors) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+	} else {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *ContactHandler) SearchContacts(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	pageNum, pageSizeNum := parsePagination(queryParams)
+	contacts, total, err := h.service.SearchContacts(queryParams.Get("q"), pageNum, pageSizeNum)
+	if err != nil {
+		var validationErrors *services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setPaginationHeaders(w, r, pageNum, pageSizeNum, total)
+	json.NewEncoder(w).Encode(contacts)
+}
+func (h *ContactHandler) GetDuplicateContacts(w http.ResponseWriter, r *http.Request) {
+	minScore := services.DefaultDuplicateScore
+	if value := r.URL.Query().Get("min_score"); value != "" {
+		var err error
+		minScore, err = strconv.ParseFloat(value, 64)
+		if err != nil || minScore <= 0 || minScore > 1 {
+			http.Error(w, "min_score must be a number greater than 0 and at most 1", http.StatusBadRequest)
+			return
+		}
+	}
+	pageNum, pageSizeNum := parsePagination(r.URL.Query())
+	groups, total, err := h.service.FindDuplicates(minScore, pageNum, pageSizeNum)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	setPaginationHeaders(w, r, pageNum, pageSizeNum, total)
+	json.NewEncoder(w).Encode(groups)
+}
 func (h *ContactHandler) GetContactByUUID(w http.ResponseWriter, r *http.Request) {
 
 	id := r.PathValue("uuid")
@@ -55,6 +205,11 @@ func (h *ContactHandler) GetContactByUUID(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
 		return
 	}
+	version, asVCard, err := acceptsVCard(r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, "Invalid Accept: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	contact, err := h.service.GetContactByUUID(uuid)
 	if err != nil {
@@ -65,12 +220,32 @@ func (h *ContactHandler) GetContactByUUID(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	etag := formatETag(contact.Version)
+	if asVCard {
+		etag = formatVariantETag(contact.Version, "vcard-"+version)
+	}
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("ETag", etag)
+	if etagListMatches(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if asVCard {
+		w.Header().Set("Content-Type", vcardContentType)
+		vcard.Write(w, *contact, version)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(contact)
 }
 func (h *ContactHandler) CreateContact(w http.ResponseWriter, r *http.Request) {
+	checkDuplicates, err := parseBool(r.URL.Query(), "check_duplicates", false)
+	if err != nil {
+		http.Error(w, "Invalid check_duplicates value", http.StatusBadRequest)
+		return
+	}
 	var contact models.Contact
-	err := json.NewDecoder(r.Body).Decode(&contact)
+	err = json.NewDecoder(r.Body).Decode(&contact)
 	if err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
@@ -82,12 +257,17 @@ func (h *ContactHandler) CreateContact(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.service.CreateContact(contact); err != nil {
 		var validationErrors *services.ValidationErrors
+		var conflictErr *repositories.ConflictError
 		if errors.As(err, &validationErrors) {
 
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(validationErrors)
 			return
 		}
+		if errors.As(err, &conflictErr) {
+			http.Error(w, conflictMessage(conflictErr), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -97,10 +277,167 @@ func (h *ContactHandler) CreateContact(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to retrieve created contact", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("ETag", formatETag(createdContact.Version))
+	if checkDuplicates {
+		// The contact is already created, so a failed lookup only loses the
+		// warning.
+		if matches, err := h.service.FindDuplicatesOf(contact.UUID, services.DefaultDuplicateScore); err == nil && len(matches) > 0 {
+			uuids := make([]string, len(matches))
+			for i, match := range matches {
+				uuids[i] = match.Contact.UUID.String()
+			}
+			w.Header().Set("X-Possible-Duplicates", strings.Join(uuids, ","))
+		}
+	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdContact)
 }
 
+type mergeRequest struct {
+	PrimaryUUID    uuid.UUID            `json:"primary_uuid"`
+	SecondaryUUIDs []uuid.UUID          `json:"secondary_uuids"`
+	Fields         map[string]uuid.UUID `json:"fields"`
+}
+
+func (h *ContactHandler) MergeContacts(w http.ResponseWriter, r *http.Request) {
+	var request mergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	contact, err := h.service.MergeContacts(request.PrimaryUUID, request.SecondaryUUIDs, request.Fields)
+	if err != nil {
+		var validationErrors *services.ValidationErrors
+		var conflictErr *repositories.ConflictError
+		if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+		} else if errors.Is(err, repositories.ErrVersionMismatch) {
+			http.Error(w, "A contact was modified during the merge, retry", http.StatusConflict)
+		} else if errors.As(err, &conflictErr) {
+			http.Error(w, conflictMessage(conflictErr), http.StatusConflict)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("ETag", formatETag(contact.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
+
+func (h *ContactHandler) GetContactMerges(w http.ResponseWriter, r *http.Request) {
+	uuid, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	merges, err := h.service.GetContactMerges(uuid)
+	if err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(merges)
+}
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchOperation carries the contact fields next to op. A delete only needs
+// uuid, and like an update it checks version when one is given.
+type batchOperation struct {
+	Op string `json:"op"`
+	models.Contact
+}
+
+type batchResult struct {
+	Op     string                     `json:"op"`
+	UUID   uuid.UUID                  `json:"uuid"`
+	Status int                        `json:"status"`
+	Error  string                     `json:"error,omitempty"`
+	Errors []services.ValidationError `json:"errors,omitempty"`
+}
+
+// BatchContacts applies up to 1000 creates, updates and deletes. Every
+// operation gets the status the single contact endpoint would answer with.
+// With atomic=true nothing is applied unless everything succeeds; the response
+// then has the status of the failed operation and the others report 424.
+func (h *ContactHandler) BatchContacts(w http.ResponseWriter, r *http.Request) {
+	atomic, err := parseBool(r.URL.Query(), "atomic", false)
+	if err != nil {
+		http.Error(w, "Invalid atomic value", http.StatusBadRequest)
+		return
+	}
+	var request batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	operations := make([]repositories.ContactOperation, len(request.Operations))
+	for i, operation := range request.Operations {
+		operations[i] = repositories.ContactOperation{Op: operation.Op, Contact: operation.Contact}
+	}
+
+	errs, err := h.service.BatchContacts(operations, atomic)
+	if err != nil {
+		var validationErrors *services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	results := make([]batchResult, len(operations))
+	for i, operation := range operations {
+		results[i] = newBatchResult(operation, errs[i])
+		if atomic && errs[i] != nil && !errors.Is(errs[i], repositories.ErrRolledBack) {
+			status = results[i].Status
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string][]batchResult{"results": results})
+}
+
+func newBatchResult(operation repositories.ContactOperation, err error) batchResult {
+	result := batchResult{Op: operation.Op, UUID: operation.Contact.UUID}
+	var validationErrors *services.ValidationErrors
+	var conflictErr *repositories.ConflictError
+	switch {
+	case err == nil && operation.Op == repositories.BatchCreate:
+		result.Status = http.StatusCreated
+	case err == nil:
+		result.Status = http.StatusNoContent
+	case errors.As(err, &validationErrors):
+		result.Status = http.StatusBadRequest
+		result.Errors = validationErrors.Errors
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		result.Status = http.StatusNotFound
+		result.Error = "Contact not found"
+	case errors.Is(err, repositories.ErrVersionMismatch):
+		result.Status = http.StatusPreconditionFailed
+		result.Error = "Contact has been modified, fetch it again and retry"
+	case errors.As(err, &conflictErr):
+		result.Status = http.StatusConflict
+		result.Error = conflictMessage(conflictErr)
+	case errors.Is(err, repositories.ErrRolledBack):
+		result.Status = http.StatusFailedDependency
+		result.Error = err.Error()
+	default:
+		result.Status = http.StatusInternalServerError
+		result.Error = err.Error()
+	}
+	return result
+}
+
 func (h *ContactHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
 
 	id := r.PathValue("uuid")
@@ -116,23 +453,99 @@ func (h *ContactHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	contact.UUID = uuid
+	version, ok := checkIfMatch(w, r, "Contact", h.contactVersion(uuid))
+	if !ok {
+		return
+	}
+	contact.Version = version
 
 	if err = h.service.UpdateContact(contact); err != nil {
-		var validationErrors *services.ValidationErrors
+		writeUpdateContactError(w, err)
+		return
+
+	}
+	w.WriteHeader(http.StatusNoContent)
+	json.NewEncoder(w).Encode(contact)
+}
+
+// PatchContact applies a JSON merge patch to the contact and stores the result
+// as a full replacement, so the patched contact is validated as a whole.
+func (h *ContactHandler) PatchContact(w http.ResponseWriter, r *http.Request) {
+	uuid, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if !isMergePatch(r) {
+		http.Error(w, "Content-Type must be "+mergePatchContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.service.GetContactByUUID(uuid)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "Contact not found", http.StatusNotFound)
-		} else if errors.As(err, &validationErrors) {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(validationErrors)
-			return
 		} else {
 			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
 		}
 		return
+	}
+	if _, ok := checkIfMatch(w, r, "Contact", func() (uint, error) { return existing.Version, nil }); !ok {
+		return
+	}
+	document, err := json.Marshal(existing)
+	if err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	patched, err := applyMergePatch(document, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch", http.StatusBadRequest)
+		return
+	}
+	var contact models.Contact
+	if err := json.Unmarshal(patched, &contact); err != nil {
+		http.Error(w, "Invalid merge patch", http.StatusBadRequest)
+		return
+	}
+	// The patch was applied to this version, so a change made since then
+	// fails the update instead of being overwritten.
+	contact.UUID = uuid
+	contact.Version = existing.Version
+	if err := h.service.UpdateContact(contact); err != nil {
+		writeUpdateContactError(w, err)
+		return
+	}
 
+	updated, err := h.service.GetContactByUUID(uuid)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated contact", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", formatETag(updated.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func writeUpdateContactError(w http.ResponseWriter, err error) {
+	var validationErrors *services.ValidationErrors
+	var conflictErr *repositories.ConflictError
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Contact not found", http.StatusNotFound)
+	} else if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, "Contact has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+	} else if errors.As(err, &conflictErr) {
+		http.Error(w, conflictMessage(conflictErr), http.StatusConflict)
+	} else if errors.As(err, &validationErrors) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+	} else {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
 	}
-	w.WriteHeader(http.StatusNoContent)
-	json.NewEncoder(w).Encode(contact)
 }
 
 func (h *ContactHandler) DeleteContact(w http.ResponseWriter, r *http.Request) {
@@ -143,9 +556,15 @@ func (h *ContactHandler) DeleteContact(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
 		return
 	}
-	if err := h.service.DeleteContact(uuid); err != nil {
+	version, ok := checkIfMatch(w, r, "Contact", h.contactVersion(uuid))
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteContact(uuid, version); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "Contact not found", http.StatusNotFound)
+		} else if errors.Is(err, repositories.ErrVersionMismatch) {
+			http.Error(w, "Contact has been modified, fetch it again and retry", http.StatusPreconditionFailed)
 		} else {
 			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
 		}
@@ -153,3 +572,164 @@ func (h *ContactHandler) DeleteContact(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *ContactHandler) RestoreContact(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("uuid")
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.RestoreContact(uuid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	contact, err := h.service.GetContactByUUID(uuid)
+	if err != nil {
+		http.Error(w, "Failed to retrieve restored contact", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", formatETag(contact.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contact)
+}
+
+func (h *ContactHandler) GetListContacts(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	queryParams := r.URL.Query()
+	name := queryParams.Get("name")
+	mobile := queryParams.Get("mobile")
+	email := queryParams.Get("email")
+	sort, err := parseSort(queryParams, contactSortFields)
+	if err != nil {
+		http.Error(w, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageNum, pageSizeNum := parsePagination(queryParams)
+
+	contacts, total, err := h.service.GetContactsByList(listUUID, name, mobile, email, queryParams.Get("filter"), sort, pageNum, pageSizeNum)
+	if err != nil {
+		var validationErrors *services.ValidationErrors
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	setPaginationHeaders(w, r, pageNum, pageSizeNum, total)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contacts)
+}
+
+func (h *ContactHandler) CreateListContact(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	var contact models.Contact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if contact.UUID == uuid.Nil {
+		contact.UUID = uuid.New()
+	}
+
+	if err := h.service.CreateContactInList(listUUID, contact); err != nil {
+		var validationErrors *services.ValidationErrors
+		var conflictErr *repositories.ConflictError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+		} else if errors.As(err, &conflictErr) {
+			http.Error(w, conflictMessage(conflictErr), http.StatusConflict)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	createdContact, err := h.service.GetContactByUUID(contact.UUID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve created contact", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdContact)
+}
+
+func (h *ContactHandler) AddListContact(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	contactUUID, err := uuid.Parse(r.PathValue("contactUUID"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.AddContactToList(listUUID, contactUUID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "List or contact not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ContactHandler) RemoveListContact(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	contactUUID, err := uuid.Parse(r.PathValue("contactUUID"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.RemoveContactFromList(listUUID, contactUUID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, repositories.ErrNotFound) {
+			http.Error(w, "List or contact not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ContactHandler) contactVersion(uuid uuid.UUID) func() (uint, error) {
+	return func() (uint, error) {
+		contact, err := h.service.GetContactByUUID(uuid)
+		if err != nil {
+			return 0, err
+		}
+		return contact.Version, nil
+	}
+}
+
+func conflictMessage(err *repositories.ConflictError) string {
+	if err.Field == "" {
+		return "Contact already exists"
+	}
+	return "A contact with this " + err.Field + " already exists"
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func formatETag(version uint) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// formatVariantETag tags another representation of a version, such as a vCard
+// next to the JSON of formatETag. Strong validators have to differ between the
+// representations of a resource, or a cache could answer with the wrong one.
+func formatVariantETag(version uint, variant string) string {
+	return fmt.Sprintf("\"%d-%s\"", version, variant)
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header matches the
+// given version. If-Match requires strong comparison, so weak tags only match
+// when weak is true.
+func etagMatches(header string, version uint, weak bool) bool {
+	return etagListMatches(header, formatETag(version), weak)
+}
+
+func etagListMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch evaluates the If-Match header against the current version of a
+// resource and returns the version a change has to be applied to, or 0 when
+// the request has no If-Match header. It returns false after writing an error
+// response.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, resource string, currentVersion func() (uint, error)) (uint, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return 0, true
+	}
+	version, err := currentVersion()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, resource+" not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return 0, false
+	}
+	if !etagMatches(ifMatch, version, false) {
+		http.Error(w, resource+" has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}
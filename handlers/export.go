@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/vcard"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+	exportVCard  = "vcard"
+)
+
+var exportContentTypes = map[string]string{
+	exportCSV:    "text/csv",
+	exportNDJSON: "application/x-ndjson",
+	exportVCard:  vcardContentType,
+}
+
+var exportExtensions = map[string]string{
+	exportCSV:    "csv",
+	exportNDJSON: "ndjson",
+	exportVCard:  "vcf",
+}
+
+// exportColumns start with the fields of a CSV import, so an export can be
+// imported into another list as it is.
+var exportColumns = []string{"first_name", "last_name", "mobile", "email", "country_code", "uuid", "list_uuids", "version", "created_at", "updated_at", "deleted_at"}
+
+var errNotAcceptable = errors.New("not acceptable")
+
+// exportFormat takes the format parameter, or else the first type in Accept
+// that is supported. CSV is the default. The second result is the vCard
+// version, taken from the version parameter or from the vCard type in Accept.
+func exportFormat(r *http.Request) (string, string, error) {
+	queryParams := r.URL.Query()
+	if format := queryParams.Get("format"); format != "" {
+		if _, ok := exportContentTypes[format]; !ok {
+			return "", "", errors.New("unknown format " + strconv.Quote(format) + ", expected csv, ndjson or vcard")
+		}
+		if format != exportVCard {
+			return format, "", nil
+		}
+		version := queryParams.Get("version")
+		if version == "" {
+			version = vcard.Version4
+		}
+		version, _, err := vcardVersion("text/vcard", map[string]string{"version": version})
+		return format, version, err
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportCSV, "", nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv", "text/*", "*/*":
+			return exportCSV, "", nil
+		case "application/x-ndjson", "application/ndjson":
+			return exportNDJSON, "", nil
+		}
+		if version, ok, err := vcardVersion(mediaType, params); ok {
+			return exportVCard, version, err
+		}
+	}
+	return "", "", errNotAcceptable
+}
+
+// exportWriter sends nothing before the first contact, so an error up to then
+// can still be answered with a status code.
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	version string
+	name    string
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+}
+
+func newExportWriter(w http.ResponseWriter, format, version, name string) *exportWriter {
+	return &exportWriter{w: w, format: format, version: version, name: name}
+}
+
+func (e *exportWriter) start() error {
+	e.started = true
+	e.w.Header().Set("Content-Type", exportContentTypes[e.format])
+	e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.name+"."+exportExtensions[e.format]+`"`)
+	e.w.WriteHeader(http.StatusOK)
+	switch e.format {
+	case exportNDJSON:
+		e.json = json.NewEncoder(e.w)
+		return nil
+	case exportVCard:
+		return nil
+	}
+	e.csv = csv.NewWriter(e.w)
+	return e.csv.Write(exportColumns)
+}
+
+func (e *exportWriter) write(contact models.Contact) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	if e.json != nil {
+		return e.json.Encode(contact)
+	}
+	if e.format == exportVCard {
+		return vcard.Write(e.w, contact, e.version)
+	}
+	listUUIDs := make([]string, len(contact.ListUUIDs))
+	for i, listUUID := range contact.ListUUIDs {
+		listUUIDs[i] = listUUID.String()
+	}
+	var deletedAt string
+	if contact.DeletedAt.Valid {
+		deletedAt = contact.DeletedAt.Time.Format(time.RFC3339)
+	}
+	return e.csv.Write([]string{
+		contact.FirstName,
+		contact.LastName,
+		contact.Mobile,
+		contact.Email,
+		contact.CountryCode,
+		contact.UUID.String(),
+		strings.Join(listUUIDs, " "),
+		strconv.FormatUint(uint64(contact.Version), 10),
+		contact.CreatedAt.Format(time.RFC3339),
+		contact.UpdatedAt.Format(time.RFC3339),
+		deletedAt,
+	})
+}
+
+// finish sends the header of an empty export and flushes the rest.
+func (e *exportWriter) finish() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// fail reports an error that ended the export. Once the status is sent the
+// connection is dropped instead, so the client cannot take a cut off export
+// for a complete one.
+func (e *exportWriter) fail(err error, writeError func(http.ResponseWriter, error)) {
+	if e.started {
+		panic(http.ErrAbortHandler)
+	}
+	writeError(e.w, err)
+}
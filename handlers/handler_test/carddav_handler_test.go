@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"contact-list-api-1/handlers"
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Status   string `xml:"DAV: status"`
+		Propstat []struct {
+			Prop struct {
+				DisplayName  string `xml:"DAV: displayname"`
+				ETag         string `xml:"DAV: getetag"`
+				SyncToken    string `xml:"DAV: sync-token"`
+				AddressData  string `xml:"urn:ietf:params:xml:ns:carddav address-data"`
+				ResourceType struct {
+					AddressBook *struct{} `xml:"urn:ietf:params:xml:ns:carddav addressbook"`
+				} `xml:"DAV: resourcetype"`
+				HomeSet struct {
+					Href string `xml:"DAV: href"`
+				} `xml:"urn:ietf:params:xml:ns:carddav addressbook-home-set"`
+				Unknown *struct{} `xml:"http://example.com/ns color"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+	SyncToken string `xml:"DAV: sync-token"`
+}
+
+// statuses maps the hrefs of a multistatus to their status, or to the ETag
+// of cards that were found.
+func (m multistatus) statuses() map[string]string {
+	statuses := make(map[string]string)
+	for _, response := range m.Responses {
+		statuses[response.Href] = response.Status
+		if len(response.Propstat) > 0 && response.Propstat[0].Prop.ETag != "" {
+			statuses[response.Href] = response.Propstat[0].Prop.ETag
+		}
+	}
+	return statuses
+}
+
+func TestCardDAV(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	contactRepo := repositories.NewContactRepository(db)
+	contactService := services.NewContactService(contactRepo)
+	handler := handlers.NewCardDAVHandler(services.NewAddressBookService(repositories.NewAddressBookRepository(db), repositories.NewListRepository(db), contactRepo, contactService))
+	list := models.List{UUID: uuid.New(), Name: "Friends"}
+	other := models.List{UUID: uuid.New(), Name: "Work"}
+	for _, l := range []*models.List{&list, &other} {
+		if err := db.Create(l).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+	ana := models.Contact{UUID: uuid.New(), FirstName: "Ana", LastName: "Doe", Mobile: "+1555000001", Email: "ana@example.com", CountryCode: "USA", ListUUIDs: []uuid.UUID{list.UUID}}
+	bob := models.Contact{UUID: uuid.New(), FirstName: "Bob", LastName: "Roe", Mobile: "+1555000002", Email: "bob@example.com", CountryCode: "CAN", ListUUIDs: []uuid.UUID{list.UUID}}
+	outsider := models.Contact{UUID: uuid.New(), FirstName: "Cid", LastName: "Poe", Mobile: "+1555000003", Email: "cid@example.com", CountryCode: "USA", ListUUIDs: []uuid.UUID{other.UUID}}
+	for _, contact := range []models.Contact{ana, bob, outsider} {
+		if err := contactService.CreateContact(contact); err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+
+	book := "/carddav/" + list.UUID.String() + "/"
+	card := func(contactUUID uuid.UUID) string { return book + contactUUID.String() + ".vcf" }
+	request := func(method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	dav := func(method, path, depth, body string) multistatus {
+		t.Helper()
+		rr := request(method, path, map[string]string{"Depth": depth, "Content-Type": "application/xml"}, body)
+		if rr.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status code %d for %s %s, got %d: %s", http.StatusMultiStatus, method, path, rr.Code, rr.Body.String())
+		}
+		var result multistatus
+		if err := xml.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Could not decode multistatus: %v", err)
+		}
+		return result
+	}
+	syncCollection := func(token, limit string) multistatus {
+		t.Helper()
+		return dav("REPORT", book, "0", `<?xml version="1.0"?><d:sync-collection xmlns:d="DAV:"><d:sync-token>`+token+`</d:sync-token><d:sync-level>1</d:sync-level>`+limit+`<d:prop><d:getetag/></d:prop></d:sync-collection>`)
+	}
+	vcardBody := func(contact models.Contact) string {
+		return "BEGIN:VCARD\r\nVERSION:3.0\r\nN:" + contact.LastName + ";" + contact.FirstName + ";;;\r\nTEL;TYPE=CELL:" + contact.Mobile + "\r\nEMAIL:" + contact.Email + "\r\nADR:;;;;;;" + contact.CountryCode + "\r\nEND:VCARD\r\n"
+	}
+
+	t.Run("Discovery", func(t *testing.T) {
+		if rr := request("OPTIONS", "/carddav/", nil, ""); rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("DAV"), "addressbook") {
+			t.Errorf("Expected the addressbook DAV class, got %d %v", rr.Code, rr.Header())
+		}
+		root := dav("PROPFIND", "/carddav/", "1", `<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav" xmlns:x="http://example.com/ns"><d:prop><d:resourcetype/><d:displayname/><c:addressbook-home-set/><x:color/></d:prop></d:propfind>`)
+		if len(root.Responses) != 3 || root.Responses[0].Propstat[0].Prop.HomeSet.Href != "/carddav/" {
+			t.Fatalf("Expected the principal and an address book per list, got %+v", root.Responses)
+		}
+		if len(root.Responses[0].Propstat) != 2 || root.Responses[0].Propstat[1].Prop.Unknown == nil || !strings.Contains(root.Responses[0].Propstat[1].Status, "404") {
+			t.Errorf("Expected the unknown property under 404, got %+v", root.Responses[0].Propstat)
+		}
+		addressBook := root.Responses[1]
+		if addressBook.Href != book || addressBook.Propstat[0].Prop.DisplayName != "Friends" || addressBook.Propstat[0].Prop.ResourceType.AddressBook == nil {
+			t.Errorf("Expected the list as an address book, got %+v", addressBook)
+		}
+
+		members := dav("PROPFIND", book, "1", `<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`)
+		if statuses := members.statuses(); len(statuses) != 3 || statuses[card(ana.UUID)] != `"1"` || statuses[card(bob.UUID)] != `"1"` {
+			t.Errorf("Expected the address book and its two cards, got %v", statuses)
+		}
+		if rr := request("PROPFIND", book, map[string]string{"Depth": "infinity"}, ""); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "propfind-finite-depth") {
+			t.Errorf("Expected infinite depth to be refused, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if rr := request("PROPFIND", "/carddav/"+uuid.New().String()+"/", map[string]string{"Depth": "0"}, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for an unknown list, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("GetCard", func(t *testing.T) {
+		rr := request("GET", card(ana.UUID), nil, "")
+		if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"1"` || !strings.Contains(rr.Body.String(), "VERSION:3.0\r\n") || !strings.Contains(rr.Body.String(), "EMAIL;TYPE=INTERNET:ana@example.com\r\n") {
+			t.Errorf("Expected Ana as vCard 3.0, got %d %v: %q", rr.Code, rr.Header(), rr.Body.String())
+		}
+		if rr := request("GET", card(ana.UUID), map[string]string{"Accept": "text/vcard;version=4.0"}, ""); !strings.Contains(rr.Body.String(), "VERSION:4.0\r\n") || rr.Header().Get("ETag") != `"1-vcard-4.0"` || rr.Header().Get("Vary") != "Accept" {
+			t.Errorf("Expected vCard 4.0 with an ETag of its own, got %v: %q", rr.Header(), rr.Body.String())
+		}
+		if rr := request("GET", card(outsider.UUID), nil, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for a contact off the list, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Reports", func(t *testing.T) {
+		missing := card(uuid.New())
+		multiget := dav("REPORT", book, "1", `<c:addressbook-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav"><d:prop><d:getetag/><c:address-data content-type="text/vcard" version="4.0"/></d:prop><d:href>`+card(bob.UUID)+`</d:href><d:href>`+missing+`</d:href></c:addressbook-multiget>`)
+		if len(multiget.Responses) != 2 || !strings.Contains(multiget.Responses[0].Propstat[0].Prop.AddressData, "N:Roe;Bob;;;\r\n") || !strings.Contains(multiget.Responses[0].Propstat[0].Prop.AddressData, "VERSION:4.0") {
+			t.Errorf("Expected Bob as vCard 4.0, got %+v", multiget.Responses)
+		}
+		if statuses := multiget.statuses(); !strings.Contains(statuses[missing], "404") {
+			t.Errorf("Expected 404 for the unknown card, got %v", statuses)
+		}
+
+		query := dav("REPORT", book, "1", `<c:addressbook-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav"><d:prop><d:getetag/></d:prop><c:filter test="anyof"><c:prop-filter name="EMAIL"><c:text-match match-type="starts-with">BOB@</c:text-match></c:prop-filter><c:prop-filter name="ADR" test="allof"><c:text-match match-type="ends-with">;mex</c:text-match></c:prop-filter></c:filter></c:addressbook-query>`)
+		if statuses := query.statuses(); len(statuses) != 1 || statuses[card(bob.UUID)] == "" {
+			t.Errorf("Expected only Bob to match, got %v", statuses)
+		}
+		limited := dav("REPORT", book, "1", `<c:addressbook-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav"><d:prop><d:getetag/></d:prop><c:limit><c:nresults>1</c:nresults></c:limit></c:addressbook-query>`)
+		if statuses := limited.statuses(); len(statuses) != 2 || !strings.Contains(statuses[book], "507") {
+			t.Errorf("Expected one card and 507 for the rest, got %v", statuses)
+		}
+		if rr := request("REPORT", book, nil, `<c:addressbook-query xmlns:c="urn:ietf:params:xml:ns:carddav"><c:filter><c:prop-filter name="FN"><c:text-match collation="i;klingon">x</c:text-match></c:prop-filter></c:filter></c:addressbook-query>`); rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "supported-collation") {
+			t.Errorf("Expected an unknown collation to be refused, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Sync", func(t *testing.T) {
+		initial := syncCollection("", "")
+		if statuses := initial.statuses(); len(statuses) != 2 || !strings.HasPrefix(initial.SyncToken, "data:,") {
+			t.Fatalf("Expected both cards and a token, got %v and %q", statuses, initial.SyncToken)
+		}
+		if again := syncCollection(initial.SyncToken, ""); len(again.Responses) != 0 || again.SyncToken != initial.SyncToken {
+			t.Errorf("Expected no changes and the same token, got %+v", again)
+		}
+
+		// A new card, a changed card and a card that left the list.
+		dan := models.Contact{UUID: uuid.New(), FirstName: "Dan", LastName: "Moe", Mobile: "+1555000004", Email: "dan@example.com", CountryCode: "USA"}
+		if rr := request("PUT", card(dan.UUID), map[string]string{"Content-Type": "text/vcard", "If-None-Match": "*"}, vcardBody(dan)); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		if rr := request("PUT", card(dan.UUID), map[string]string{"Content-Type": "text/vcard", "If-None-Match": "*"}, vcardBody(dan)); rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status code %d for an existing card, got %d", http.StatusPreconditionFailed, rr.Code)
+		}
+		ana.FirstName = "Anna"
+		if rr := request("PUT", card(ana.UUID), map[string]string{"Content-Type": "text/vcard", "If-Match": `"7"`}, vcardBody(ana)); rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected status code %d for a stale ETag, got %d", http.StatusPreconditionFailed, rr.Code)
+		}
+		if rr := request("PUT", card(ana.UUID), map[string]string{"Content-Type": "text/vcard", "If-Match": `"1"`}, vcardBody(ana)); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		if rr := request("DELETE", card(bob.UUID), map[string]string{"If-Match": `"1"`}, ""); rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+		}
+		var stored models.Contact
+		if err := db.Where("uuid = ?", ana.UUID).First(&stored).Error; err != nil || stored.FirstName != "Anna" {
+			t.Errorf("Expected the card to update the contact, got %+v, %v", stored, err)
+		}
+		if err := db.Where("uuid = ?", bob.UUID).First(&models.Contact{}).Error; err != nil {
+			t.Errorf("Expected the contact to be kept when its card is deleted, got %v", err)
+		}
+
+		changes := syncCollection(initial.SyncToken, "")
+		statuses := changes.statuses()
+		if len(statuses) != 3 || statuses[card(ana.UUID)] != `"2"` || statuses[card(dan.UUID)] != `"1"` || !strings.Contains(statuses[card(bob.UUID)], "404") {
+			t.Errorf("Expected Anna changed, Dan new and Bob removed, got %v", statuses)
+		}
+		if changes.SyncToken == initial.SyncToken {
+			t.Errorf("Expected a new token after changes")
+		}
+
+		first := syncCollection(initial.SyncToken, `<d:limit><d:nresults>2</d:nresults></d:limit>`)
+		if statuses := first.statuses(); len(statuses) != 3 || !strings.Contains(statuses[book], "507") {
+			t.Fatalf("Expected two changes and 507 for the rest, got %v", statuses)
+		}
+		rest := syncCollection(first.SyncToken, "")
+		if statuses := rest.statuses(); len(statuses) != 1 || statuses[card(bob.UUID)] == "" || rest.SyncToken != changes.SyncToken {
+			t.Errorf("Expected the removal left out before and the current token, got %v and %q", statuses, rest.SyncToken)
+		}
+
+		rr := request("REPORT", book, map[string]string{"Depth": "0"}, `<d:sync-collection xmlns:d="DAV:"><d:sync-token>data:,unknown</d:sync-token><d:prop><d:getetag/></d:prop></d:sync-collection>`)
+		if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "valid-sync-token") {
+			t.Errorf("Expected an unknown token to be refused, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("PutErrors", func(t *testing.T) {
+		invalid := models.Contact{FirstName: "Eve", LastName: "Doe", Mobile: "123", Email: "eve@", CountryCode: "USA"}
+		valid := models.Contact{FirstName: "Dee", LastName: "Doe", Mobile: "+1555000010", Email: "dee@example.com", CountryCode: "USA"}
+		testCases := []struct {
+			name           string
+			path           string
+			contentType    string
+			body           string
+			expectedStatus int
+			expectedBody   string
+		}{
+			{name: "NotVCard", path: card(uuid.New()), contentType: "application/json", body: "{}", expectedStatus: http.StatusUnsupportedMediaType},
+			{name: "NameNotUUID", path: book + "eve.vcf", contentType: "text/vcard", body: vcardBody(invalid), expectedStatus: http.StatusForbidden},
+			{name: "NameUppercase", path: book + strings.ToUpper(uuid.New().String()) + ".vcf", contentType: "text/vcard", body: vcardBody(valid), expectedStatus: http.StatusForbidden},
+			{name: "NameUndashed", path: book + strings.ReplaceAll(uuid.New().String(), "-", "") + ".vcf", contentType: "text/vcard", body: vcardBody(valid), expectedStatus: http.StatusForbidden},
+			{name: "NameURN", path: book + "urn:uuid:" + uuid.New().String() + ".vcf", contentType: "text/vcard", body: vcardBody(valid), expectedStatus: http.StatusForbidden},
+			{name: "ListUppercase", path: "/carddav/" + strings.ToUpper(list.UUID.String()) + "/" + uuid.New().String() + ".vcf", contentType: "text/vcard", body: vcardBody(valid), expectedStatus: http.StatusNotFound},
+			{name: "Invalid", path: card(uuid.New()), contentType: "text/vcard", body: vcardBody(invalid), expectedStatus: http.StatusForbidden, expectedBody: "valid-address-data"},
+			{name: "TwoCards", path: card(uuid.New()), contentType: "text/vcard", body: vcardBody(invalid) + vcardBody(invalid), expectedStatus: http.StatusForbidden, expectedBody: "exactly one vCard"},
+			{name: "EmailTaken", path: card(uuid.New()), contentType: "text/vcard", body: vcardBody(models.Contact{FirstName: "Eve", LastName: "Doe", Mobile: "+1555000009", Email: "cid@example.com", CountryCode: "USA"}), expectedStatus: http.StatusForbidden, expectedBody: "Email"},
+			{name: "ListNotFound", path: "/carddav/" + uuid.New().String() + "/" + uuid.New().String() + ".vcf", contentType: "text/vcard", body: vcardBody(outsider), expectedStatus: http.StatusNotFound},
+		}
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := request("PUT", tt.path, map[string]string{"Content-Type": tt.contentType}, tt.body)
+				if rr.Code != tt.expectedStatus || !strings.Contains(rr.Body.String(), tt.expectedBody) {
+					t.Errorf("Expected status code %d with %q, got %d: %s", tt.expectedStatus, tt.expectedBody, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+}
@@ -5,15 +5,19 @@ import (
 	"contact-list-api-1/models"
 	"contact-list-api-1/repositories"
 	"contact-list-api-1/services"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func TestGetAllContacts(t *testing.T) {
@@ -40,7 +44,7 @@ func TestGetAllContacts(t *testing.T) {
 			Mobile:      "+1234567890",
 			Email:       "test.test@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListIDs:     []uint{list.ID},
 		},
 		{
 			UUID:        uuid.New(),
@@ -49,7 +53,7 @@ func TestGetAllContacts(t *testing.T) {
 			Mobile:      "+1987654321",
 			Email:       "test1.test1@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListIDs:     []uint{list.ID},
 		},
 		{
 			UUID:        uuid.New(),
@@ -58,7 +62,7 @@ func TestGetAllContacts(t *testing.T) {
 			Mobile:      "+1122334455",
 			Email:       "test2.test2@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListIDs:     []uint{list.ID},
 		},
 	}
 
@@ -159,11 +163,20 @@ func TestGetContactByUUID(t *testing.T) {
 		Mobile:      "+123456789",
 		Email:       "test.test@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
 	}
 	if err := db.Create(&testContact).Error; err != nil {
 		t.Fatalf("Could not create test contact: %v", err)
 	}
+	membership := models.ListMembership{ListID: list.ID, ContactID: testContact.ID, AddedAt: time.Now(), Source: models.MembershipSourceAPI}
+	if err := db.Create(&membership).Error; err != nil {
+		t.Fatalf("Could not create test membership: %v", err)
+	}
+	var expectedContact models.Contact
+	if err := db.First(&expectedContact, testContact.ID).Error; err != nil {
+		t.Fatalf("Could not reload test contact: %v", err)
+	}
+	expectedContact.ID = 0
+	expectedContact.ListUUIDs = []uuid.UUID{list.UUID}
 
 	testCases := []struct {
 		name               string
@@ -175,7 +188,7 @@ func TestGetContactByUUID(t *testing.T) {
 			name:               "ValidUUID",
 			uuid:               testUUID.String(),
 			expectedStatusCode: http.StatusOK,
-			expectedContact:    &testContact,
+			expectedContact:    &expectedContact,
 		},
 		{
 			name:               "InvalidUUID",
@@ -209,6 +222,15 @@ func TestGetContactByUUID(t *testing.T) {
 			}
 
 			if tt.expectedContact != nil {
+				var rawContact map[string]any
+				if err := json.Unmarshal(rr.Body.Bytes(), &rawContact); err != nil {
+					t.Fatalf("Could not decode response body: %v", err)
+				}
+				for _, key := range []string{"ID", "id", "list_ids"} {
+					if _, ok := rawContact[key]; ok {
+						t.Errorf("Expected response not to expose internal field %q, got %v", key, rawContact)
+					}
+				}
 				var gotContact models.Contact
 				if err := json.NewDecoder(rr.Body).Decode(&gotContact); err != nil {
 					t.Fatalf("Could not decode response body: %v", err)
@@ -256,8 +278,8 @@ func TestCreateContact(t *testing.T) {
 				"mobile": "+123456789",
 				"email": "test.test@example.com",
 				"country_code": "USA",
-				"list_id": %d
-			}`, list.ID),
+				"list_uuids": ["%s"]
+			}`, list.UUID),
 			expectedStatusCode:  http.StatusCreated,
 			expectedFirstName:   "Test",
 			expectedLastName:    "Test",
@@ -335,7 +357,7 @@ func TestUpdateContact(t *testing.T) {
 		Mobile:      "+123456789",
 		Email:       "test.test@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 	if err := db.Create(&testContact).Error; err != nil {
 		t.Fatalf("Could not create test contact: %v", err)
@@ -347,8 +369,8 @@ func TestUpdateContact(t *testing.T) {
 	    "mobile": "+123456789",
 	    "email": "test.test1@example.com",
 	    "country_code": "USA",
-	    "list_id": %d
-	}`, list.ID)
+	    "list_uuids": ["%s"]
+	}`, list.UUID)
 
 	testCases := []struct {
 		name               string
@@ -429,7 +451,7 @@ func TestDeleteContact(t *testing.T) {
 		Mobile:      "+123456789",
 		Email:       "test.test@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 	if err := db.Create(&testContact).Error; err != nil {
 		t.Fatalf("Could not create test data: %v", err)
@@ -480,3 +502,1254 @@ func TestDeleteContact(t *testing.T) {
 	}
 
 }
+
+func TestGetListContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	handler := handlers.NewContactHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	otherList := models.List{UUID: uuid.New(), Name: "Other List"}
+	for _, l := range []*models.List{&list, &otherList} {
+		if err := db.Create(l).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	contacts := []models.Contact{
+		{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+1234567890", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Test 1", LastName: "Test 1", Mobile: "+1987654321", Email: "test1.test1@example.com", CountryCode: "USA", ListIDs: []uint{list.ID, otherList.ID}},
+		{UUID: uuid.New(), FirstName: "Other", LastName: "Other", Mobile: "+1122334455", Email: "other@example.com", CountryCode: "USA", ListIDs: []uint{otherList.ID}},
+	}
+	for _, contact := range contacts {
+		if err := repo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		uuid               string
+		query              string
+		expectedStatusCode int
+		expectedCount      int
+	}{
+		{
+			name:               "AllMembers",
+			uuid:               list.UUID.String(),
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      2,
+		},
+		{
+			name:               "WithMobileFilter",
+			uuid:               list.UUID.String(),
+			query:              "?mobile=%2B1987654321",
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      1,
+		},
+		{
+			name:               "WithPagination",
+			uuid:               list.UUID.String(),
+			query:              "?page=2&pageSize=1",
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      1,
+		},
+		{
+			name:               "NonExistentList",
+			uuid:               uuid.New().String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "InvalidUUID",
+			uuid:               "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/"+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			req.SetPathValue("uuid", tt.uuid)
+			rr := httptest.NewRecorder()
+			handler.GetListContacts(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+			if tt.expectedStatusCode == http.StatusOK {
+				var gotContacts []models.Contact
+				if err := json.NewDecoder(rr.Body).Decode(&gotContacts); err != nil {
+					t.Fatalf("Could not decode response body: %v", err)
+				}
+				if len(gotContacts) != tt.expectedCount {
+					t.Errorf("Expected %d contacts, got %d", tt.expectedCount, len(gotContacts))
+				}
+			}
+		})
+	}
+}
+
+func TestCreateListContact(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	handler := handlers.NewContactHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	validBody := `{
+		"first_name": "Test",
+		"last_name": "Test",
+		"mobile": "+123456789",
+		"email": "test.test@example.com",
+		"country_code": "USA"
+	}`
+
+	testCases := []struct {
+		name               string
+		uuid               string
+		body               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "ValidCreate",
+			uuid:               list.UUID.String(),
+			body:               validBody,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "NonExistentList",
+			uuid:               uuid.New().String(),
+			body:               validBody,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "InvalidContact",
+			uuid:               list.UUID.String(),
+			body:               `{"first_name": "Test"}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			req.SetPathValue("uuid", tt.uuid)
+			rr := httptest.NewRecorder()
+			handler.CreateListContact(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+			if tt.expectedStatusCode == http.StatusCreated {
+				var createdContact models.Contact
+				if err := json.NewDecoder(rr.Body).Decode(&createdContact); err != nil {
+					t.Fatalf("Could not decode response body: %v", err)
+				}
+				if !reflect.DeepEqual(createdContact.ListUUIDs, []uuid.UUID{list.UUID}) {
+					t.Errorf("Expected contact to belong to list %v, got %v", list.UUID, createdContact.ListUUIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestAddAndRemoveListContact(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	handler := handlers.NewContactHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	otherList := models.List{UUID: uuid.New(), Name: "Other List"}
+	for _, l := range []*models.List{&list, &otherList} {
+		if err := db.Create(l).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	contact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+1234567890", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(contact); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		method             string
+		listUUID           string
+		contactUUID        string
+		expectedStatusCode int
+		expectedListIDs    []uint
+	}{
+		{
+			name:               "AddToOtherList",
+			method:             "PUT",
+			listUUID:           otherList.UUID.String(),
+			contactUUID:        contact.UUID.String(),
+			expectedStatusCode: http.StatusNoContent,
+			expectedListIDs:    []uint{list.ID, otherList.ID},
+		},
+		{
+			name:               "AddAgainIsIdempotent",
+			method:             "PUT",
+			listUUID:           otherList.UUID.String(),
+			contactUUID:        contact.UUID.String(),
+			expectedStatusCode: http.StatusNoContent,
+			expectedListIDs:    []uint{list.ID, otherList.ID},
+		},
+		{
+			name:               "RemoveFromList",
+			method:             "DELETE",
+			listUUID:           list.UUID.String(),
+			contactUUID:        contact.UUID.String(),
+			expectedStatusCode: http.StatusNoContent,
+			expectedListIDs:    []uint{otherList.ID},
+		},
+		{
+			name:               "RemoveNonMember",
+			method:             "DELETE",
+			listUUID:           list.UUID.String(),
+			contactUUID:        contact.UUID.String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "NonExistentContact",
+			method:             "PUT",
+			listUUID:           list.UUID.String(),
+			contactUUID:        uuid.New().String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "InvalidContactUUID",
+			method:             "DELETE",
+			listUUID:           list.UUID.String(),
+			contactUUID:        "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "/", nil)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			req.SetPathValue("uuid", tt.listUUID)
+			req.SetPathValue("contactUUID", tt.contactUUID)
+			rr := httptest.NewRecorder()
+			if tt.method == "PUT" {
+				handler.AddListContact(rr, req)
+			} else {
+				handler.RemoveListContact(rr, req)
+			}
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+			if tt.expectedListIDs != nil {
+				updated, err := repo.GetByUUID(contact.UUID)
+				if err != nil {
+					t.Fatalf("Could not fetch contact: %v", err)
+				}
+				if !reflect.DeepEqual(updated.ListIDs, tt.expectedListIDs) {
+					t.Errorf("Expected list IDs %v, got %v", tt.expectedListIDs, updated.ListIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreContact(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	handler := handlers.NewContactHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	testContact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+123456789", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(testContact); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	if err := repo.Delete(testContact.UUID, 0); err != nil {
+		t.Fatalf("Could not delete test data: %v", err)
+	}
+
+	for query, expectedCount := range map[string]int{"": 0, "?include_deleted=true": 1} {
+		rr := httptest.NewRecorder()
+		handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts"+query, nil))
+		var gotContacts []models.Contact
+		if err := json.NewDecoder(rr.Body).Decode(&gotContacts); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+		if len(gotContacts) != expectedCount {
+			t.Errorf("Expected %d contacts for %q, got %d", expectedCount, query, len(gotContacts))
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		uuid               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "ValidRestore",
+			uuid:               testContact.UUID.String(),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "UUIDNotFound",
+			uuid:               uuid.New().String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "InvalidUUIDFormat",
+			uuid:               "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/", nil)
+			req.SetPathValue("uuid", tt.uuid)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.RestoreContact(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+			if tt.expectedStatusCode == http.StatusOK {
+				var gotContact models.Contact
+				if err := json.NewDecoder(rr.Body).Decode(&gotContact); err != nil {
+					t.Fatalf("Could not decode response body: %v", err)
+				}
+				if len(gotContact.ListUUIDs) != 1 || gotContact.ListUUIDs[0] != list.UUID {
+					t.Errorf("Expected restored contact to keep list %v, got %v", list.UUID, gotContact.ListUUIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestContactETags(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	handler := handlers.NewContactHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	testContact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+123456789", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(testContact); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	replacement := func(firstName string) string {
+		return fmt.Sprintf(`{"first_name": %q, "last_name": "Test", "mobile": "+123456789", "email": "test.test@example.com", "country_code": "USA", "list_uuids": [%q]}`, firstName, list.UUID)
+	}
+
+	send := func(method string, headers map[string]string, body string, handle http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/contacts/"+testContact.UUID.String(), strings.NewReader(body))
+		req.SetPathValue("uuid", testContact.UUID.String())
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, req)
+		return rr
+	}
+
+	testCases := []struct {
+		name               string
+		method             string
+		headers            map[string]string
+		body               string
+		handle             http.HandlerFunc
+		expectedStatusCode int
+		expectedETag       string
+	}{
+		{
+			name:               "GetReturnsETag",
+			method:             "GET",
+			handle:             handler.GetContactByUUID,
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"1"`,
+		},
+		{
+			name:               "GetWithMatchingIfNoneMatch",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": `W/"1"`},
+			handle:             handler.GetContactByUUID,
+			expectedStatusCode: http.StatusNotModified,
+			expectedETag:       `"1"`,
+		},
+		{
+			name:               "UpdateWithCurrentETag",
+			method:             "PUT",
+			headers:            map[string]string{"If-Match": `"1"`},
+			body:               replacement("Updated"),
+			handle:             handler.UpdateContact,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "UpdateWithStaleETag",
+			method:             "PUT",
+			headers:            map[string]string{"If-Match": `"1"`},
+			body:               replacement("Stale"),
+			handle:             handler.UpdateContact,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "GetWithStaleIfNoneMatch",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": `"1"`},
+			handle:             handler.GetContactByUUID,
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"2"`,
+		},
+		{
+			name:               "DeleteWithStaleETag",
+			method:             "DELETE",
+			headers:            map[string]string{"If-Match": `"1"`},
+			handle:             handler.DeleteContact,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "DeleteWithCurrentETag",
+			method:             "DELETE",
+			headers:            map[string]string{"If-Match": `"3", "2"`},
+			handle:             handler.DeleteContact,
+			expectedStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := send(tt.method, tt.headers, tt.body, tt.handle)
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedStatusCode, status, rr.Body.String())
+			}
+			if tt.expectedETag != "" && rr.Header().Get("ETag") != tt.expectedETag {
+				t.Errorf("Expected ETag %s, got %s", tt.expectedETag, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+// staleUniquenessRepository hides existing contacts from the uniqueness checks,
+// as if a concurrent request had not committed yet.
+type staleUniquenessRepository struct {
+	repositories.ContactRepository
+}
+
+func (staleUniquenessRepository) FindByEmail(email string) (*models.Contact, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (staleUniquenessRepository) FindByMobile(mobile string) (*models.Contact, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestCreateContact_Conflict(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	handler := handlers.NewContactHandler(services.NewContactService(staleUniquenessRepository{repo}))
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+123456789", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(existing); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		mobile        string
+		email         string
+		expectedField string
+	}{
+		{
+			name:          "DuplicateMobile",
+			mobile:        existing.Mobile,
+			email:         "other@example.com",
+			expectedField: "mobile",
+		},
+		{
+			name:          "DuplicateEmail",
+			mobile:        "+1987654321",
+			email:         existing.Email,
+			expectedField: "email",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"first_name": "New", "last_name": "Contact", "mobile": %q, "email": %q, "country_code": "USA", "list_uuids": [%q]}`, tt.mobile, tt.email, list.UUID)
+			rr := httptest.NewRecorder()
+			handler.CreateContact(rr, httptest.NewRequest("POST", "/contacts", strings.NewReader(body)))
+
+			if rr.Code != http.StatusConflict {
+				t.Fatalf("Expected status code %d, got %d (%s)", http.StatusConflict, rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedField) {
+				t.Errorf("Expected response to name field %q, got %q", tt.expectedField, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetAllContacts_Cursor(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	var expected []uuid.UUID
+	for i := 0; i < 5; i++ {
+		contact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "USA"}
+		if err := db.Create(&contact).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+		expected = append(expected, contact.UUID)
+	}
+
+	var got []uuid.UUID
+	target := "/contacts?cursor=&pageSize=2"
+	for page := 0; target != ""; page++ {
+		if page > 3 {
+			t.Fatalf("Expected the scan to end, still going at %s", target)
+		}
+		rr := httptest.NewRecorder()
+		handler.GetAllContacts(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		if rr.Header().Get("X-Total-Count") != "" {
+			t.Errorf("Expected no total count in cursor mode")
+		}
+		var contacts []models.Contact
+		if err := json.NewDecoder(rr.Body).Decode(&contacts); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+		for _, contact := range contacts {
+			got = append(got, contact.UUID)
+		}
+
+		target = ""
+		if next := rr.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/contacts?cursor=" + next + "&pageSize=2"
+			if link := rr.Header().Get("Link"); !strings.Contains(link, "cursor="+next) || !strings.Contains(link, `rel="next"`) {
+				t.Errorf("Expected Link header to point at the next cursor, got %s", link)
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected contacts %v, got %v", expected, got)
+	}
+
+	for _, query := range []string{"?cursor=garbage", "?cursor=&page=2"} {
+		rr := httptest.NewRecorder()
+		handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %q, got %d", http.StatusBadRequest, query, rr.Code)
+		}
+	}
+}
+
+func TestGetAllContacts_Sort(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	for i, lastName := range []string{"Bravo", "Alpha", "Charlie"} {
+		contact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: lastName, Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "USA"}
+		if err := db.Create(&contact).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedLastNames  []string
+	}{
+		{
+			name:               "Ascending",
+			query:              "?sort=last_name",
+			expectedStatusCode: http.StatusOK,
+			expectedLastNames:  []string{"Alpha", "Bravo", "Charlie"},
+		},
+		{
+			name:               "DescendingWithPagination",
+			query:              "?sort=-last_name,created_at&page=2&pageSize=2",
+			expectedStatusCode: http.StatusOK,
+			expectedLastNames:  []string{"Alpha"},
+		},
+		{
+			name:               "UnknownField",
+			query:              "?sort=password",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "FieldGivenTwice",
+			query:              "?sort=last_name,-last_name",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts"+tt.query, nil))
+			if rr.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, rr.Code)
+			}
+			if tt.expectedStatusCode != http.StatusOK {
+				return
+			}
+			var contacts []models.Contact
+			if err := json.NewDecoder(rr.Body).Decode(&contacts); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			var lastNames []string
+			for _, contact := range contacts {
+				lastNames = append(lastNames, contact.LastName)
+			}
+			if !reflect.DeepEqual(lastNames, tt.expectedLastNames) {
+				t.Errorf("Expected %v, got %v", tt.expectedLastNames, lastNames)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts?cursor=&pageSize=1&sort=last_name", nil))
+	next := rr.Header().Get("X-Next-Cursor")
+	if next == "" {
+		t.Fatalf("Expected a next cursor")
+	}
+	rr = httptest.NewRecorder()
+	handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts?pageSize=1&sort=-last_name&cursor="+next, nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected a cursor to be rejected for a different sort, got %d", rr.Code)
+	}
+}
+
+func TestGetAllContacts_Filter(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	for i, countryCode := range []string{"SRB", "USA", "SRB"} {
+		contact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: countryCode}
+		if err := db.Create(&contact).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	query := url.Values{"filter": {`country_code eq "SRB" and email starts_with "contact2"`}}
+	handler.GetAllContacts(rr, httptest.NewRequest("GET", "/contacts?"+query.Encode(), nil))
+	var contacts []models.Contact
+	if err := json.NewDecoder(rr.Body).Decode(&contacts); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(contacts) != 1 || contacts[0].Email != "contact2@example.com" {
+		t.Errorf("Expected only contact2@example.com, got %+v", contacts)
+	}
+
+	for _, target := range []string{"/contacts?", "/contacts?cursor=&"} {
+		rr := httptest.NewRecorder()
+		query := url.Values{"filter": {`country_code eq SRB`}}
+		handler.GetAllContacts(rr, httptest.NewRequest("GET", target+query.Encode(), nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		var validationErrors services.ValidationErrors
+		if err := json.NewDecoder(rr.Body).Decode(&validationErrors); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+		if len(validationErrors.Errors) != 1 || validationErrors.Errors[0].Field != "filter" || !strings.Contains(validationErrors.Errors[0].Message, "position 17") {
+			t.Errorf("Expected a filter error at position 17, got %+v", validationErrors)
+		}
+	}
+}
+
+func TestSearchContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	for i, name := range [][2]string{{"Sara", "Savic"}, {"Savo", "Saric"}, {"Ana", "Sarac"}} {
+		contact := models.Contact{UUID: uuid.New(), FirstName: name[0], LastName: name[1], Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "SRB"}
+		if err := db.Create(&contact).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler.SearchContacts(rr, httptest.NewRequest("GET", "/search?q=savic+sara", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var contacts []models.Contact
+	if err := json.NewDecoder(rr.Body).Decode(&contacts); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(contacts) != 1 || contacts[0].FirstName != "Sara" || rr.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Expected only Sara Savic, got %+v (X-Total-Count %q)", contacts, rr.Header().Get("X-Total-Count"))
+	}
+
+	for _, target := range []string{"/search", "/search?q=+", "/search?q=" + strings.Repeat("a+", 11)} {
+		rr := httptest.NewRecorder()
+		handler.SearchContacts(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusBadRequest, target, rr.Code)
+		}
+	}
+}
+
+func TestContactDuplicates(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	list := models.List{UUID: uuid.New(), Name: "Friends"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "Jon", LastName: "Smith", Mobile: "+381641234567", Email: "jon@example.com", CountryCode: "SRB"}
+	if err := repositories.NewContactRepository(db).Create(existing); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	create := func(target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.CreateContact(rr, httptest.NewRequest("POST", target, strings.NewReader(body)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		return rr
+	}
+	rr := create("/contacts", `{"first_name":"Jane","last_name":"Doe","mobile":"+1555000111","email":"jane@example.com","country_code":"USA","list_uuids":["`+list.UUID.String()+`"]}`)
+	if header := rr.Header().Get("X-Possible-Duplicates"); header != "" {
+		t.Errorf("Expected no duplicate check without check_duplicates, got %q", header)
+	}
+	rr = create("/contacts?check_duplicates=true", `{"first_name":"John","last_name":"Smith","mobile":"+3810641234567","email":"john@example.com","country_code":"SRB","list_uuids":["`+list.UUID.String()+`"]}`)
+	if header := rr.Header().Get("X-Possible-Duplicates"); header != existing.UUID.String() {
+		t.Errorf("Expected X-Possible-Duplicates %q, got %q", existing.UUID, header)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.GetDuplicateContacts(rr, httptest.NewRequest("GET", "/contacts/duplicates", nil))
+	var groups []services.DuplicateGroup
+	if err := json.NewDecoder(rr.Body).Decode(&groups); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Contacts) != 2 || groups[0].Contacts[0].UUID != existing.UUID {
+		t.Errorf("Expected one group with Jon and John Smith, got %+v", groups)
+	}
+	if total := rr.Header().Get("X-Total-Count"); total != "1" {
+		t.Errorf("Expected X-Total-Count 1, got %q", total)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.GetDuplicateContacts(rr, httptest.NewRequest("GET", "/contacts/duplicates?page=2", nil))
+	if body := strings.TrimSpace(rr.Body.String()); body != "[]" {
+		t.Errorf("Expected no groups on page 2, got %s", body)
+	}
+
+	for _, minScore := range []string{"abc", "0", "1.5"} {
+		rr := httptest.NewRecorder()
+		handler.GetDuplicateContacts(rr, httptest.NewRequest("GET", "/contacts/duplicates?min_score="+minScore, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for min_score %s, got %d", http.StatusBadRequest, minScore, rr.Code)
+		}
+	}
+}
+
+func TestMergeContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	primary := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Smith", Mobile: "+1555000111", Email: "john.old@example.com", CountryCode: "USA"}
+	secondary := models.Contact{UUID: uuid.New(), FirstName: "Jon", LastName: "Smith", Mobile: "+1555000222", Email: "john@example.com", CountryCode: "USA"}
+	for _, contact := range []*models.Contact{&primary, &secondary} {
+		if err := db.Create(contact).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "InvalidPayload", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "NoSecondaries", body: fmt.Sprintf(`{"primary_uuid":%q}`, primary.UUID), expectedStatus: http.StatusBadRequest},
+		{name: "PrimaryAsSecondary", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q]}`, primary.UUID, primary.UUID), expectedStatus: http.StatusBadRequest},
+		{name: "UnknownField", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q],"fields":{"list_uuids":%q}}`, primary.UUID, secondary.UUID, secondary.UUID), expectedStatus: http.StatusBadRequest},
+		{name: "FieldFromOutsideTheMerge", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q],"fields":{"email":%q}}`, primary.UUID, secondary.UUID, uuid.New()), expectedStatus: http.StatusBadRequest},
+		{name: "MissingSecondary", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q]}`, primary.UUID, uuid.New()), expectedStatus: http.StatusNotFound},
+		{name: "Merge", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q],"fields":{"email":%q}}`, primary.UUID, secondary.UUID, secondary.UUID), expectedStatus: http.StatusOK},
+		{name: "SecondaryAlreadyMerged", body: fmt.Sprintf(`{"primary_uuid":%q,"secondary_uuids":[%q]}`, primary.UUID, secondary.UUID), expectedStatus: http.StatusNotFound},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.MergeContacts(rr, httptest.NewRequest("POST", "/contacts/merge", strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var merged models.Contact
+			if err := json.NewDecoder(rr.Body).Decode(&merged); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if merged.UUID != primary.UUID || merged.Email != "john@example.com" || merged.FirstName != "John" || rr.Header().Get("ETag") != `"2"` {
+				t.Errorf("Expected the primary with the secondary's email at version 2, got %+v (ETag %s)", merged, rr.Header().Get("ETag"))
+			}
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/contacts/"+primary.UUID.String()+"/merges", nil)
+	req.SetPathValue("uuid", primary.UUID.String())
+	rr := httptest.NewRecorder()
+	handler.GetContactMerges(rr, req)
+	var merges []models.ContactMerge
+	if err := json.NewDecoder(rr.Body).Decode(&merges); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(merges) != 1 || len(merges[0].Contacts) != 2 || merges[0].Contacts[1].UUID != secondary.UUID {
+		t.Errorf("Expected one recorded merge with both contacts, got %+v", merges)
+	}
+}
+
+func TestPatchContact(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	handler := handlers.NewContactHandler(services.NewContactService(repo))
+	family := models.List{UUID: uuid.New(), Name: "Family"}
+	work := models.List{UUID: uuid.New(), Name: "Work"}
+	for _, list := range []*models.List{&family, &work} {
+		if err := db.Create(list).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	contact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test@example.com", CountryCode: "USA", ListIDs: []uint{family.ID}}
+	if err := repo.Create(contact); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		uuid               uuid.UUID
+		contentType        string
+		ifMatch            string
+		body               string
+		expectedStatusCode int
+		check              func(t *testing.T, patched models.Contact)
+	}{
+		{
+			name:               "ChangesOnlyGivenFields",
+			contentType:        "application/merge-patch+json",
+			body:               `{"last_name": "Patched", "uuid": "` + uuid.New().String() + `"}`,
+			expectedStatusCode: http.StatusOK,
+			check: func(t *testing.T, patched models.Contact) {
+				if patched.UUID != contact.UUID || patched.LastName != "Patched" || patched.FirstName != "Test" || patched.Email != "test@example.com" || patched.Version != 2 {
+					t.Errorf("Expected only the last name to change, got %+v", patched)
+				}
+				if len(patched.ListUUIDs) != 1 || patched.ListUUIDs[0] != family.UUID {
+					t.Errorf("Expected the lists to be kept, got %v", patched.ListUUIDs)
+				}
+			},
+		},
+		{
+			name:               "ReplacesArrays",
+			ifMatch:            `"2"`,
+			body:               `{"list_uuids": ["` + work.UUID.String() + `"]}`,
+			expectedStatusCode: http.StatusOK,
+			check: func(t *testing.T, patched models.Contact) {
+				if len(patched.ListUUIDs) != 1 || patched.ListUUIDs[0] != work.UUID {
+					t.Errorf("Expected the contact to be on %v only, got %v", work.UUID, patched.ListUUIDs)
+				}
+			},
+		},
+		{
+			name:               "NullClearsRequiredField",
+			body:               `{"email": null}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "StaleETag",
+			ifMatch:            `"1"`,
+			body:               `{"first_name": "Stale"}`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "PlainJSON",
+			contentType:        "application/json",
+			body:               `{"first_name": "Plain"}`,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:               "UnsupportedContentType",
+			contentType:        "text/plain",
+			body:               `{"first_name": "Text"}`,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:               "InvalidJSON",
+			body:               `{"first_name":`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "WrongType",
+			body:               `{"first_name": 42}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "NonExistentContact",
+			uuid:               uuid.New(),
+			body:               `{"first_name": "Missing"}`,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			target := contact.UUID
+			if tt.uuid != uuid.Nil {
+				target = tt.uuid
+			}
+			req := httptest.NewRequest("PATCH", "/contacts/"+target.String(), strings.NewReader(tt.body))
+			req.SetPathValue("uuid", target.String())
+			contentType := "application/merge-patch+json"
+			if tt.contentType != "" {
+				contentType = tt.contentType
+			}
+			req.Header.Set("Content-Type", contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			handler.PatchContact(rr, req)
+			if rr.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatusCode, rr.Code, rr.Body.String())
+			}
+			if tt.check == nil {
+				return
+			}
+			var patched models.Contact
+			if err := json.NewDecoder(rr.Body).Decode(&patched); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if rr.Header().Get("ETag") != fmt.Sprintf(`"%d"`, patched.Version) {
+				t.Errorf("Expected ETag of version %d, got %s", patched.Version, rr.Header().Get("ETag"))
+			}
+			tt.check(t, patched)
+		})
+	}
+}
+
+func TestBatchContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	list := models.List{UUID: uuid.New(), Name: "Family"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1555000111", Email: "john@example.com", CountryCode: "USA"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	newUUID := uuid.New()
+	create := fmt.Sprintf(`{"op":"create","uuid":%q,"first_name":"Jane","last_name":"Doe","mobile":"+1555000222","email":"jane@example.com","country_code":"USA","list_uuids":[%q]}`, newUUID, list.UUID)
+	invalid := fmt.Sprintf(`{"op":"create","first_name":"Jim","last_name":"Doe","mobile":"555","email":"john@example.com","country_code":"USA","list_uuids":[%q]}`, list.UUID)
+	update := fmt.Sprintf(`{"op":"update","uuid":%q,"version":1,"first_name":"Johnny","last_name":"Doe","mobile":"+1555000111","email":"john@example.com","country_code":"USA","list_uuids":[%q]}`, existing.UUID, list.UUID)
+	missing := fmt.Sprintf(`{"op":"delete","uuid":%q}`, uuid.New())
+
+	testCases := []struct {
+		name             string
+		query            string
+		body             string
+		expectedStatus   int
+		expectedStatuses []int
+	}{
+		{name: "InvalidPayload", body: `{`, expectedStatus: http.StatusBadRequest},
+		{name: "InvalidAtomic", query: "?atomic=maybe", body: `{"operations":[]}`, expectedStatus: http.StatusBadRequest},
+		{name: "NoOperations", body: `{"operations":[]}`, expectedStatus: http.StatusBadRequest},
+		{name: "UnknownOperation", body: `{"operations":[{"op":"upsert"}]}`, expectedStatus: http.StatusOK, expectedStatuses: []int{http.StatusBadRequest}},
+		{
+			name:             "AtomicWithFailures",
+			query:            "?atomic=true",
+			body:             `{"operations":[` + create + `,` + invalid + `,` + update + `]}`,
+			expectedStatus:   http.StatusBadRequest,
+			expectedStatuses: []int{http.StatusFailedDependency, http.StatusBadRequest, http.StatusFailedDependency},
+		},
+		{
+			name:             "PerItem",
+			body:             `{"operations":[` + create + `,` + invalid + `,` + update + `,` + missing + `]}`,
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusNoContent, http.StatusNotFound},
+		},
+		{
+			name:             "AtomicSuccess",
+			query:            "?atomic=true",
+			body:             fmt.Sprintf(`{"operations":[{"op":"delete","uuid":%q,"version":2},{"op":"delete","uuid":%q,"version":1}]}`, existing.UUID, newUUID),
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{http.StatusNoContent, http.StatusNoContent},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.BatchContacts(rr, httptest.NewRequest("POST", "/contacts:batch"+tt.query, strings.NewReader(tt.body)))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatuses == nil {
+				return
+			}
+			var response struct {
+				Results []struct {
+					UUID   uuid.UUID                  `json:"uuid"`
+					Status int                        `json:"status"`
+					Errors []services.ValidationError `json:"errors"`
+				} `json:"results"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			var statuses []int
+			for _, result := range response.Results {
+				statuses = append(statuses, result.Status)
+			}
+			if !reflect.DeepEqual(statuses, tt.expectedStatuses) {
+				t.Errorf("Expected statuses %v, got %v: %s", tt.expectedStatuses, statuses, rr.Body.String())
+			}
+			if tt.expectedStatuses[0] != http.StatusBadRequest && response.Results[0].UUID == uuid.Nil {
+				t.Errorf("Expected the UUID of the first operation, got %+v", response.Results[0])
+			}
+		})
+	}
+
+	var count int64
+	db.Unscoped().Model(&models.Contact{}).Where("first_name = ?", "Johnny").Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the update to be applied once, found %d contacts", count)
+	}
+}
+
+func TestExportContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	list := models.List{UUID: uuid.New(), Name: "Everyone"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	// More contacts than the repository exports per batch.
+	contacts := make([]models.Contact, 501)
+	for i := range contacts {
+		contacts[i] = models.Contact{UUID: uuid.New(), FirstName: fmt.Sprintf("First%03d", i), LastName: "Doe", Mobile: fmt.Sprintf("+1555%06d", i), Email: fmt.Sprintf("c%03d@example.com", i), CountryCode: "USA", Version: 1}
+	}
+	if err := db.CreateInBatches(contacts, 100).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	memberships := make([]models.ListMembership, len(contacts))
+	for i, contact := range contacts {
+		memberships[i] = models.ListMembership{ListID: list.ID, ContactID: contact.ID, AddedAt: time.Now(), Source: models.MembershipSourceAPI}
+	}
+	if err := db.CreateInBatches(memberships, 100).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	export := func(path string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if strings.HasPrefix(path, "/lists/") {
+			req.SetPathValue("uuid", strings.Split(path, "/")[2])
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		if strings.HasPrefix(path, "/lists/") {
+			handler.ExportListContacts(rr, req)
+		} else {
+			handler.ExportContacts(rr, req)
+		}
+		return rr
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		rr := export("/contacts/export?sort=-first_name", "")
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/csv" {
+			t.Fatalf("Expected a CSV export, got %d %s: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+		}
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Could not read the export: %v", err)
+		}
+		if len(records) != len(contacts)+1 || records[0][0] != "first_name" || records[1][0] != "First500" {
+			t.Fatalf("Expected a header and every contact, last first, got %d records starting %q", len(records), records[:min(2, len(records))])
+		}
+		if last := records[len(records)-1]; last[0] != "First000" || last[6] != list.UUID.String() {
+			t.Errorf("Expected the last contact with its list, got %q", last)
+		}
+	})
+	t.Run("NDJSON", func(t *testing.T) {
+		rr := export("/lists/"+list.UUID.String()+"/export?filter="+url.QueryEscape(`first_name eq "First000" or first_name eq "First001"`), "application/json;q=0.9, application/x-ndjson")
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+			t.Fatalf("Expected an NDJSON export, got %d %s: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+		}
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		var contact models.Contact
+		if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &contact) != nil || contact.FirstName != "First001" {
+			t.Errorf("Expected the two filtered contacts, got %q", lines)
+		}
+	})
+	t.Run("FormatParameter", func(t *testing.T) {
+		rr := export("/contacts/export?format=ndjson&name=First000", "text/csv")
+		if rr.Code != http.StatusOK || strings.Count(rr.Body.String(), "\n") != 1 {
+			t.Errorf("Expected the format parameter to win over Accept, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("VCard", func(t *testing.T) {
+		rr := export("/lists/"+list.UUID.String()+"/export?filter="+url.QueryEscape(`first_name eq "First000" or first_name eq "First001"`), "text/x-vcard")
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/vcard; charset=utf-8" || !strings.Contains(rr.Header().Get("Content-Disposition"), `contacts.vcf"`) {
+			t.Fatalf("Expected a vCard export, got %d %s %s: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Header().Get("Content-Disposition"), rr.Body.String())
+		}
+		body := rr.Body.String()
+		if strings.Count(body, "BEGIN:VCARD\r\n") != 2 || strings.Count(body, "VERSION:3.0\r\n") != 2 || !strings.Contains(body, "N:Doe;First001;;;\r\n") {
+			t.Errorf("Expected the two filtered contacts as vCard 3.0, got %q", body)
+		}
+	})
+
+	testCases := []struct {
+		name           string
+		path           string
+		accept         string
+		expectedStatus int
+	}{
+		{name: "UnknownFormat", path: "/contacts/export?format=xml", expectedStatus: http.StatusBadRequest},
+		{name: "UnsupportedVCardVersion", path: "/contacts/export?format=vcard&version=2.1", expectedStatus: http.StatusBadRequest},
+		{name: "NotAcceptable", path: "/contacts/export", accept: "application/xml", expectedStatus: http.StatusNotAcceptable},
+		{name: "InvalidFilter", path: "/contacts/export?filter=" + url.QueryEscape(`first_name eq`), expectedStatus: http.StatusBadRequest},
+		{name: "InvalidSort", path: "/contacts/export?sort=password", expectedStatus: http.StatusBadRequest},
+		{name: "ListNotFound", path: "/lists/" + uuid.New().String() + "/export", expectedStatus: http.StatusNotFound},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := export(tt.path, tt.accept); rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetContactByUUID_VCard(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewContactHandler(services.NewContactService(repositories.NewContactRepository(db)))
+	contact := models.Contact{UUID: uuid.New(), FirstName: "Jane", LastName: "Doe", Mobile: "+1555000222", Email: "jane@example.com", CountryCode: "USA", Version: 3}
+	if err := db.Create(&contact).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	testCases := []struct {
+		name            string
+		accept          string
+		expectedStatus  int
+		expectedType    string
+		expectedETag    string
+		expectedVersion string
+	}{
+		{name: "JSON", accept: "", expectedStatus: http.StatusOK, expectedType: "application/json", expectedETag: `"3"`},
+		{name: "JSONPreferred", accept: "application/json, text/vcard", expectedStatus: http.StatusOK, expectedType: "application/json", expectedETag: `"3"`},
+		{name: "Version4", accept: "text/vcard", expectedStatus: http.StatusOK, expectedType: "text/vcard; charset=utf-8", expectedETag: `"3-vcard-4.0"`, expectedVersion: "VERSION:4.0"},
+		{name: "VersionParameter", accept: "text/html, text/vcard;version=3.0", expectedStatus: http.StatusOK, expectedType: "text/vcard; charset=utf-8", expectedETag: `"3-vcard-3.0"`, expectedVersion: "VERSION:3.0"},
+		{name: "LegacyType", accept: "text/x-vcard", expectedStatus: http.StatusOK, expectedType: "text/vcard; charset=utf-8", expectedETag: `"3-vcard-3.0"`, expectedVersion: "VERSION:3.0"},
+		{name: "UnsupportedVersion", accept: "text/vcard;version=2.1", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/contacts/"+contact.UUID.String(), nil)
+			req.SetPathValue("uuid", contact.UUID.String())
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.GetContactByUUID(rr, req)
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if rr.Header().Get("Content-Type") != tt.expectedType || rr.Header().Get("Vary") != "Accept" || rr.Header().Get("ETag") != tt.expectedETag {
+				t.Errorf("Unexpected headers %v", rr.Header())
+			}
+			if tt.expectedVersion != "" && (!strings.Contains(rr.Body.String(), tt.expectedVersion+"\r\n") || !strings.Contains(rr.Body.String(), "UID:urn:uuid:"+contact.UUID.String())) {
+				t.Errorf("Expected a vCard with %s, got %q", tt.expectedVersion, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("IfNoneMatchOtherRepresentation", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/contacts/"+contact.UUID.String(), nil)
+		req.SetPathValue("uuid", contact.UUID.String())
+		req.Header.Set("Accept", "text/vcard")
+		req.Header.Set("If-None-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		handler.GetContactByUUID(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected the JSON ETag not to validate the vCard, got status code %d", rr.Code)
+		}
+		req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+		rr = httptest.NewRecorder()
+		handler.GetContactByUUID(rr, req)
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("Expected status code %d for the vCard ETag, got %d", http.StatusNotModified, rr.Code)
+		}
+	})
+}
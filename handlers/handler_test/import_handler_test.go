@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"contact-list-api-1/handlers"
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImportContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	contactRepo := repositories.NewContactRepository(db)
+	handler := handlers.NewImportHandler(services.NewImportService(repositories.NewImportRepository(db), contactRepo, services.NewContactService(contactRepo)))
+	list := models.List{UUID: uuid.New(), Name: "Newsletter"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1555000111", Email: "john@example.com", CountryCode: "USA"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	body := "Given Name,last_name,Mobile,E-mail,country_code,Notes\n" +
+		"Jane,Doe,+1555000222,jane@example.com,USA,new\n" +
+		"Johnny,Doe,+1555000999,john@example.com,USA,same email\n" +
+		"Jim,,555,jim@example,US,invalid\n" +
+		"Jack,Doe\n" +
+		"\"Jill, Jr.\",Doe,+1555000333,jill@example.com,USA,quoted\n" +
+		"Janet,Doe,+1555000222,JANE@example.com,USA,on the list\n" +
+		"Jo,Doe,+1555000222,john@example.com,USA,two contacts\n"
+	importRequest := func(listUUID uuid.UUID, query, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/lists/"+listUUID.String()+"/import"+query, strings.NewReader(body))
+		req.SetPathValue("uuid", listUUID.String())
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		handler.ImportContacts(rr, req)
+		return rr
+	}
+	mapping := "?map=Given%20Name:first_name&map=E-mail:email"
+
+	testCases := []struct {
+		name           string
+		listUUID       uuid.UUID
+		query          string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "NotCSV", listUUID: list.UUID, query: mapping, contentType: "application/json", body: body, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "ListNotFound", listUUID: uuid.New(), query: mapping, contentType: "text/csv", body: body, expectedStatus: http.StatusNotFound},
+		{name: "InvalidMap", listUUID: list.UUID, query: "?map=first_name", contentType: "text/csv", body: body, expectedStatus: http.StatusBadRequest},
+		{name: "UnknownField", listUUID: list.UUID, query: mapping + "&map=Notes:notes", contentType: "text/csv", body: body, expectedStatus: http.StatusBadRequest},
+		{name: "UnmappedField", listUUID: list.UUID, contentType: "text/csv", body: body, expectedStatus: http.StatusBadRequest},
+		{name: "Empty", listUUID: list.UUID, contentType: "text/csv", body: "", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := importRequest(tt.listUUID, tt.query, tt.contentType, tt.body); rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	rr := importRequest(list.UUID, mapping, "text/csv; charset=utf-8", body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		UUID        uuid.UUID `json:"uuid"`
+		Created     int       `json:"created"`
+		Linked      int       `json:"linked"`
+		Skipped     int       `json:"skipped"`
+		Failed      int       `json:"failed"`
+		ErrorReport string    `json:"error_report"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.Created != 2 || response.Linked != 1 || response.Skipped != 1 || response.Failed != 3 {
+		t.Errorf("Expected 2 created, 1 linked, 1 skipped and 3 failed, got %+v", response)
+	}
+	importPath := "/lists/" + list.UUID.String() + "/imports/" + response.UUID.String()
+	if response.ErrorReport != importPath+"/errors" || rr.Header().Get("Location") != importPath {
+		t.Errorf("Expected the import and its error report under %s, got %+v (Location %s)", importPath, response, rr.Header().Get("Location"))
+	}
+	var jill models.Contact
+	if err := db.Where("email = ?", "jill@example.com").First(&jill).Error; err != nil || jill.FirstName != "Jill, Jr." {
+		t.Errorf("Expected the quoted row to be imported, got %+v, %v", jill, err)
+	}
+	var members int64
+	db.Model(&models.ListMembership{}).Where("contact_id = ?", jill.ID).Count(&members)
+	if members != 1 {
+		t.Errorf("Expected the imported contact on the list, got %d memberships", members)
+	}
+	var linked models.ListMembership
+	if err := db.Where("contact_id = ? AND list_id = ?", existing.ID, list.ID).First(&linked).Error; err != nil || linked.Source != models.MembershipSourceImport {
+		t.Errorf("Expected the existing contact added to the list by the import, got %+v, %v", linked, err)
+	}
+	var john models.Contact
+	if err := db.First(&john, existing.ID).Error; err != nil || john.FirstName != "John" {
+		t.Errorf("Expected the existing contact to keep its fields, got %+v, %v", john, err)
+	}
+
+	req := httptest.NewRequest("GET", response.ErrorReport, nil)
+	req.SetPathValue("uuid", list.UUID.String())
+	req.SetPathValue("importUUID", response.UUID.String())
+	rr = httptest.NewRecorder()
+	handler.GetImportErrors(rr, req)
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("Expected a CSV report, got %d %s: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	reader := csv.NewReader(rr.Body)
+	reader.FieldsPerRecord = -1
+	report, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Could not read the report: %v", err)
+	}
+	if len(report) != 4 || !reflect.DeepEqual(report[0], []string{"line", "errors", "Given Name", "last_name", "Mobile", "E-mail", "country_code", "Notes"}) {
+		t.Fatalf("Expected a header and three rejected rows, got %q", report)
+	}
+	if report[1][0] != "4" || !strings.Contains(report[1][1], "LastName: last name cannot be empty") || report[1][2] != "Jim" {
+		t.Errorf("Expected line 4 with its validation errors, got %q", report[1])
+	}
+	if report[2][0] != "5" || report[2][1] != "wrong number of fields" {
+		t.Errorf("Expected line 5 with the wrong number of fields, got %q", report[2])
+	}
+	if report[3][0] != "8" || !strings.HasPrefix(report[3][1], "mobile: Belongs to the contact ") {
+		t.Errorf("Expected line 8 with its email and mobile on different contacts, got %q", report[3])
+	}
+
+	req = httptest.NewRequest("GET", "/lists/"+uuid.New().String()+"/imports/"+response.UUID.String(), nil)
+	req.SetPathValue("uuid", uuid.New().String())
+	req.SetPathValue("importUUID", response.UUID.String())
+	rr = httptest.NewRecorder()
+	handler.GetImport(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for the import of another list, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestImportVCards(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	contactRepo := repositories.NewContactRepository(db)
+	handler := handlers.NewImportHandler(services.NewImportService(repositories.NewImportRepository(db), contactRepo, services.NewContactService(contactRepo)))
+	list := models.List{UUID: uuid.New(), Name: "Address book"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1555000111", Email: "john@example.com", CountryCode: "USA"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	janeUUID := uuid.New()
+	body := "BEGIN:VCARD\r\n" + // 1
+		"VERSION:4.0\r\n" +
+		"UID:urn:uuid:" + janeUUID.String() + "\r\n" +
+		"FN:Jane Doe\r\n" +
+		"N:Doe;Jane;;;\r\n" +
+		"TEL;VALUE=uri;TYPE=cell:tel:+1-555-000-222\r\n" +
+		"EMAIL:Jane@Example.com\r\n" +
+		"ADR:;;;;;;USA\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" + // 10
+		"VERSION:3.0\r\n" +
+		"FN:Johnny Doe\r\n" +
+		"EMAIL;TYPE=INTERNET:john@example.com\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" + // 15
+		"VERSION:3.0\r\n" +
+		"FN:Jim\r\n" +
+		"TEL;TYPE=CELL:555\r\n" +
+		"EMAIL:jim@example\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" + // 21
+		"FN:Never Closed\r\n"
+
+	req := httptest.NewRequest("POST", "/lists/"+list.UUID.String()+"/import", strings.NewReader(body))
+	req.SetPathValue("uuid", list.UUID.String())
+	req.Header.Set("Content-Type", "text/vcard")
+	rr := httptest.NewRecorder()
+	handler.ImportContacts(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		UUID        uuid.UUID `json:"uuid"`
+		Created     int       `json:"created"`
+		Linked      int       `json:"linked"`
+		Skipped     int       `json:"skipped"`
+		Failed      int       `json:"failed"`
+		ErrorReport string    `json:"error_report"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.Created != 1 || response.Linked != 1 || response.Skipped != 0 || response.Failed != 2 {
+		t.Errorf("Expected 1 created, 1 linked and 2 failed, got %+v", response)
+	}
+	var jane models.Contact
+	if err := db.Where("uuid = ?", janeUUID).First(&jane).Error; err != nil || jane.Mobile != "+1555000222" || jane.Email != "jane@example.com" || jane.CountryCode != "USA" {
+		t.Errorf("Expected the card imported under its UID, got %+v, %v", jane, err)
+	}
+
+	req = httptest.NewRequest("GET", response.ErrorReport, nil)
+	req.SetPathValue("uuid", list.UUID.String())
+	req.SetPathValue("importUUID", response.UUID.String())
+	rr = httptest.NewRecorder()
+	handler.GetImportErrors(rr, req)
+	reader := csv.NewReader(rr.Body)
+	reader.FieldsPerRecord = -1
+	report, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Could not read the report: %v", err)
+	}
+	if len(report) != 3 || !reflect.DeepEqual(report[0], append([]string{"line", "errors"}, services.ImportFields...)) {
+		t.Fatalf("Expected a header and two rejected cards, got %q", report)
+	}
+	if report[1][0] != "15" || !strings.Contains(report[1][1], "LastName: last name cannot be empty") || report[1][2] != "Jim" {
+		t.Errorf("Expected the card on line 15 with its validation errors, got %q", report[1])
+	}
+	if report[2][0] != "21" || report[2][1] != "card has no END:VCARD" {
+		t.Errorf("Expected the card on line 21 without an end, got %q", report[2])
+	}
+}
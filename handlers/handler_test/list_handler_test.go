@@ -7,6 +7,7 @@ import (
 	"contact-list-api-1/services"
 	"contact-list-api-1/tests"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -121,6 +122,11 @@ func TestGetListByUUID(t *testing.T) {
 	if err := db.Create(&testList).Error; err != nil {
 		t.Fatalf("Could not create test data: %v", err)
 	}
+	var expectedList models.List
+	if err := db.First(&expectedList, testList.ID).Error; err != nil {
+		t.Fatalf("Could not reload test data: %v", err)
+	}
+	expectedList.ID = 0
 
 	testCases := []struct {
 		name               string
@@ -132,7 +138,7 @@ func TestGetListByUUID(t *testing.T) {
 			name:               "ValidUUID",
 			uuid:               testUUID.String(),
 			expectedStatusCode: http.StatusOK,
-			expectedList:       &testList,
+			expectedList:       &expectedList,
 		},
 		{
 			name:               "InvalidUUID",
@@ -374,3 +380,372 @@ func TestDeleteList(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteList_Cascade(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	service := services.NewListService(repo)
+	handler := handlers.NewListHandler(service)
+
+	testList := models.List{UUID: uuid.New(), Name: "Test List with Contacts"}
+	if err := db.Create(&testList).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	testContact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+123456789", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{testList.ID}}
+	if err := repositories.NewContactRepository(db).Create(testContact); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+	}{
+		{
+			name:               "InvalidCascadeValue",
+			query:              "?cascade=sometimes",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "NonEmptyListWithoutCascade",
+			query:              "?cascade=false",
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "NonEmptyListWithCascade",
+			query:              "?cascade=true",
+			expectedStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/lists/"+testList.UUID.String()+tt.query, nil)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			req.SetPathValue("uuid", testList.UUID.String())
+			rr := httptest.NewRecorder()
+			handler.DeleteList(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+		})
+	}
+}
+
+func TestRestoreList(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	service := services.NewListService(repo)
+	handler := handlers.NewListHandler(service)
+
+	testList := models.List{UUID: uuid.New(), Name: "Test List to Restore"}
+	if err := db.Create(&testList).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	if err := repo.Delete(testList.UUID, true, 0); err != nil {
+		t.Fatalf("Could not delete test data: %v", err)
+	}
+
+	for query, expectedCount := range map[string]int{"": 0, "?include_deleted=true": 1} {
+		req := httptest.NewRequest("GET", "/lists"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.GetAllLists(rr, req)
+		var gotLists []models.List
+		if err := json.NewDecoder(rr.Body).Decode(&gotLists); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+		if len(gotLists) != expectedCount {
+			t.Errorf("Expected %d lists for %q, got %d", expectedCount, query, len(gotLists))
+		}
+	}
+	rr := httptest.NewRecorder()
+	handler.GetAllLists(rr, httptest.NewRequest("GET", "/lists?include_deleted=maybe", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for invalid include_deleted, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	testCases := []struct {
+		name               string
+		uuid               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "ValidRestore",
+			uuid:               testList.UUID.String(),
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "InvalidUUID",
+			uuid:               "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "NonExistentUUID",
+			uuid:               uuid.New().String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/", nil)
+			req.SetPathValue("uuid", tt.uuid)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.RestoreList(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+			if tt.expectedStatusCode == http.StatusOK {
+				var gotList models.List
+				if err := json.NewDecoder(rr.Body).Decode(&gotList); err != nil {
+					t.Fatalf("Could not decode response body: %v", err)
+				}
+				if gotList.UUID != testList.UUID || gotList.DeletedAt.Valid {
+					t.Errorf("Expected restored list %v, got %+v", testList.UUID, gotList)
+				}
+			}
+		})
+	}
+}
+
+func TestListETags(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	service := services.NewListService(repo)
+	handler := handlers.NewListHandler(service)
+
+	testList := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := repo.Create(testList); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		method             string
+		ifMatch            string
+		ifNoneMatch        string
+		body               string
+		handle             http.HandlerFunc
+		expectedStatusCode int
+	}{
+		{
+			name:               "GetWithMatchingIfNoneMatch",
+			method:             "GET",
+			ifNoneMatch:        `"1"`,
+			handle:             handler.GetListByUUID,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "UpdateWithWeakETag",
+			method:             "PUT",
+			ifMatch:            `W/"1"`,
+			body:               `{"name": "Weak"}`,
+			handle:             handler.UpdateList,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "UpdateWithAnyETag",
+			method:             "PUT",
+			ifMatch:            "*",
+			body:               `{"name": "Renamed"}`,
+			handle:             handler.UpdateList,
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "DeleteWithStaleETag",
+			method:             "DELETE",
+			ifMatch:            `"1"`,
+			handle:             handler.DeleteList,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "DeleteWithCurrentETag",
+			method:             "DELETE",
+			ifMatch:            `"2"`,
+			handle:             handler.DeleteList,
+			expectedStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/lists/"+testList.UUID.String(), strings.NewReader(tt.body))
+			req.SetPathValue("uuid", testList.UUID.String())
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+			tt.handle(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedStatusCode, status, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetAllLists_PaginationHeaders(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewListHandler(services.NewListService(repositories.NewListRepository(db)))
+	for i := 0; i < 25; i++ {
+		if err := db.Create(&models.List{UUID: uuid.New(), Name: fmt.Sprintf("List %02d", i)}).Error; err != nil {
+			t.Fatalf("Could not create test data: %v", err)
+		}
+	}
+	if err := db.Create(&models.List{UUID: uuid.New(), Name: "Other"}).Error; err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+
+	testCases := []struct {
+		name            string
+		query           string
+		expectedHeaders map[string]string
+		expectedLinks   []string
+		unexpectedLinks []string
+	}{
+		{
+			name:  "MiddlePage",
+			query: "?name=List&page=2&pageSize=10",
+			expectedHeaders: map[string]string{
+				"X-Total-Count": "25",
+				"X-Page":        "2",
+				"X-Page-Size":   "10",
+				"X-Total-Pages": "3",
+			},
+			expectedLinks: []string{
+				`</lists?name=List&page=1&pageSize=10>; rel="first"`,
+				`</lists?name=List&page=1&pageSize=10>; rel="prev"`,
+				`</lists?name=List&page=3&pageSize=10>; rel="next"`,
+				`</lists?name=List&page=3&pageSize=10>; rel="last"`,
+			},
+		},
+		{
+			name:  "DefaultsOnFirstPage",
+			query: "",
+			expectedHeaders: map[string]string{
+				"X-Total-Count": "26",
+				"X-Page":        "1",
+				"X-Page-Size":   "10",
+				"X-Total-Pages": "3",
+			},
+			expectedLinks:   []string{`</lists?page=2&pageSize=10>; rel="next"`},
+			unexpectedLinks: []string{`rel="prev"`},
+		},
+		{
+			name:  "NoMatches",
+			query: "?name=missing",
+			expectedHeaders: map[string]string{
+				"X-Total-Count": "0",
+				"X-Total-Pages": "0",
+			},
+			unexpectedLinks: []string{`rel="prev"`, `rel="next"`},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.GetAllLists(rr, httptest.NewRequest("GET", "/lists"+tt.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+			}
+			for header, expected := range tt.expectedHeaders {
+				if got := rr.Header().Get(header); got != expected {
+					t.Errorf("Expected %s %q, got %q", header, expected, got)
+				}
+			}
+			link := rr.Header().Get("Link")
+			for _, expected := range tt.expectedLinks {
+				if !strings.Contains(link, expected) {
+					t.Errorf("Expected Link header to contain %s, got %s", expected, link)
+				}
+			}
+			for _, unexpected := range tt.unexpectedLinks {
+				if strings.Contains(link, unexpected) {
+					t.Errorf("Expected Link header not to contain %s, got %s", unexpected, link)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchList(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	handler := handlers.NewListHandler(services.NewListService(repositories.NewListRepository(db)))
+	list := models.List{UUID: uuid.New(), Name: "Friends", Version: 1}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+
+	send := func(method, body string, handle http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/lists/"+list.UUID.String(), strings.NewReader(body))
+		req.SetPathValue("uuid", list.UUID.String())
+		if method == "PATCH" {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, req)
+		return rr
+	}
+
+	rr := send("PATCH", `{"name": "Close friends"}`, handler.PatchList)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var patched models.List
+	if err := json.NewDecoder(rr.Body).Decode(&patched); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if patched.Name != "Close friends" || patched.Version != 2 || rr.Header().Get("ETag") != `"2"` {
+		t.Errorf("Expected the renamed list at version 2, got %+v (ETag %s)", patched, rr.Header().Get("ETag"))
+	}
+
+	for name, tt := range map[string]struct {
+		method string
+		body   string
+		handle http.HandlerFunc
+	}{
+		"PatchNullName": {"PATCH", `{"name": null}`, handler.PatchList},
+		"PutEmptyName":  {"PUT", `{}`, handler.UpdateList},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := send(tt.method, tt.body, tt.handle)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+			}
+		})
+	}
+	t.Run("MissingContentType", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/lists/"+list.UUID.String(), strings.NewReader(`{"name": "Untyped"}`))
+		req.SetPathValue("uuid", list.UUID.String())
+		rr := httptest.NewRecorder()
+		handler.PatchList(rr, req)
+		if rr.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status code %d, got %d: %s", http.StatusUnsupportedMediaType, rr.Code, rr.Body.String())
+		}
+	})
+	var stored models.List
+	if err := db.Where("uuid = ?", list.UUID).First(&stored).Error; err != nil || stored.Name != "Close friends" {
+		t.Errorf("Expected rejected changes to leave the list alone, got %+v (%v)", stored, err)
+	}
+}
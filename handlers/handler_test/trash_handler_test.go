@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"contact-list-api-1/handlers"
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTrashHandler(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	listRepo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+	service := services.NewTrashService(repositories.NewTrashRepository(db), listRepo, contactRepo)
+	handler := handlers.NewTrashHandler(service)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	if err := listRepo.Delete(list.UUID, true, 0); err != nil {
+		t.Fatalf("Could not delete test list: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.GetTrash(rr, httptest.NewRequest("GET", "/trash", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var items []models.TrashItem
+	if err := json.NewDecoder(rr.Body).Decode(&items); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(items) != 1 || items[0].UUID != list.UUID || items[0].Type != models.TrashItemList {
+		t.Errorf("Expected deleted list in the trash, got %+v", items)
+	}
+
+	testCases := []struct {
+		name               string
+		uuid               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "ValidRestore",
+			uuid:               list.UUID.String(),
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "AlreadyRestored",
+			uuid:               list.UUID.String(),
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "InvalidUUIDFormat",
+			uuid:               "invalid-uuid",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/", nil)
+			if err != nil {
+				t.Fatalf("Could not create HTTP request: %v", err)
+			}
+			req.SetPathValue("uuid", tt.uuid)
+			rr := httptest.NewRecorder()
+			handler.RestoreTrashItem(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportHandler struct {
+	service services.ImportService
+}
+
+func NewImportHandler(service services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+type importResponse struct {
+	models.ContactImport
+	ErrorReport string `json:"error_report,omitempty"`
+}
+
+// ImportContacts imports a text/csv or text/vcard body into the list. CSV
+// columns are matched to contact fields by header, and "map" parameters such
+// as map=Given%20Name:first_name map other headers.
+func (h *ImportHandler) ImportContacts(w http.ResponseWriter, r *http.Request) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	var contactImport *models.ContactImport
+	switch mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType {
+	case "text/csv":
+		var mapping map[string]string
+		if mapping, err = parseImportMapping(r.URL.Query()); err != nil {
+			http.Error(w, "Invalid map: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		contactImport, err = h.service.ImportCSV(listUUID, r.Body, mapping)
+	case "text/vcard", "text/x-vcard":
+		contactImport, err = h.service.ImportVCards(listUUID, r.Body)
+	default:
+		http.Error(w, "Content-Type must be text/csv or text/vcard", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		var validationErrors *services.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrors)
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Location", importPath(*contactImport))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newImportResponse(*contactImport))
+}
+
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	listUUID, importUUID, ok := parseImportPath(w, r)
+	if !ok {
+		return
+	}
+	contactImport, err := h.service.GetImport(listUUID, importUUID)
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newImportResponse(*contactImport))
+}
+
+// GetImportErrors downloads the rows of an import that failed, as CSV.
+func (h *ImportHandler) GetImportErrors(w http.ResponseWriter, r *http.Request) {
+	listUUID, importUUID, ok := parseImportPath(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.service.GetImport(listUUID, importUUID); err != nil {
+		writeImportError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-`+importUUID.String()+`-errors.csv"`)
+	// The status is sent with the first row, so a later failure can only cut
+	// the report short.
+	h.service.WriteErrorReport(listUUID, importUUID, w)
+}
+
+func parseImportPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	listUUID, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	importUUID, err := uuid.Parse(r.PathValue("importUUID"))
+	if err != nil {
+		http.Error(w, "Invalid import UUID format", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return listUUID, importUUID, true
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Import not found", http.StatusNotFound)
+	} else {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func importPath(contactImport models.ContactImport) string {
+	return "/lists/" + contactImport.ListUUID.String() + "/imports/" + contactImport.UUID.String()
+}
+
+func newImportResponse(contactImport models.ContactImport) importResponse {
+	response := importResponse{ContactImport: contactImport}
+	if contactImport.Failed > 0 {
+		response.ErrorReport = importPath(contactImport) + "/errors"
+	}
+	return response
+}
@@ -3,14 +3,14 @@ package handlers
 import (
 	"contact-list-api-1/models"
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/google/uuid"
 
-	//"contact-list-api-1/repositories"
+	"contact-list-api-1/repositories"
 	"contact-list-api-1/services"
 	"errors"
-	"strconv"
 
 	"gorm.io/gorm"
 )
@@ -26,22 +26,42 @@ func NewListHandler(service services.ListService) *ListHandler {
 func (h *ListHandler) GetAllLists(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 	name := queryParams.Get("name")
-	pageStr := queryParams.Get("page")
-	pageSizeStr := queryParams.Get("pageSize")
-	pageNum, err := strconv.Atoi(pageStr)
-
-	if err != nil || pageNum <= 0 {
-		pageNum = 1
+	includeDeleted, err := parseBool(queryParams, "include_deleted", false)
+	if err != nil {
+		http.Error(w, "Invalid include_deleted value", http.StatusBadRequest)
+		return
+	}
+	sort, err := parseSort(queryParams, listSortFields)
+	if err != nil {
+		http.Error(w, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
 	}
-	pageSizeNum, err := strconv.Atoi(pageSizeStr)
-	if err != nil || pageSizeNum <= 0 {
-		pageSizeNum = 10
+	pageNum, pageSizeNum := parsePagination(queryParams)
+	if queryParams.Has("cursor") {
+		if queryParams.Has("page") {
+			http.Error(w, "cursor and page cannot be combined", http.StatusBadRequest)
+			return
+		}
+		lists, nextCursor, err := h.service.GetListsAfter(name, includeDeleted, sort, queryParams.Get("cursor"), pageSizeNum)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCursor) {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		setCursorHeaders(w, r, pageSizeNum, nextCursor)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lists)
+		return
 	}
-	lists, err := h.service.GetAllLists(name, pageNum, pageSizeNum)
+	lists, total, err := h.service.GetAllLists(name, includeDeleted, sort, pageNum, pageSizeNum)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	setPaginationHeaders(w, r, pageNum, pageSizeNum, total)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(lists)
 
@@ -67,6 +87,11 @@ func (h *ListHandler) GetListByUUID(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	w.Header().Set("ETag", formatETag(list.Version))
+	if etagMatches(r.Header.Get("If-None-Match"), list.Version, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(list)
 
@@ -100,6 +125,7 @@ func (h *ListHandler) CreateList(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to retrieve created list", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("ETag", formatETag(createdList.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdList)
 }
@@ -119,8 +145,40 @@ func (h *ListHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	list.UUID = uuid
+	version, ok := checkIfMatch(w, r, "List", h.listVersion(uuid))
+	if !ok {
+		return
+	}
+	list.Version = version
 
 	if err = h.service.UpdateList(list); err != nil {
+		writeUpdateListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	json.NewEncoder(w).Encode(list)
+}
+
+// PatchList applies a JSON merge patch to the list and stores the result as a
+// full replacement, so the patched list is validated as a whole.
+func (h *ListHandler) PatchList(w http.ResponseWriter, r *http.Request) {
+	uuid, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if !isMergePatch(r) {
+		http.Error(w, "Content-Type must be "+mergePatchContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.service.GetListByUUID(uuid)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "List not found", http.StatusNotFound)
 		} else {
@@ -128,9 +186,53 @@ func (h *ListHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if _, ok := checkIfMatch(w, r, "List", func() (uint, error) { return existing.Version, nil }); !ok {
+		return
+	}
+	document, err := json.Marshal(existing)
+	if err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	patched, err := applyMergePatch(document, patch)
+	if err != nil {
+		http.Error(w, "Invalid merge patch", http.StatusBadRequest)
+		return
+	}
+	var list models.List
+	if err := json.Unmarshal(patched, &list); err != nil {
+		http.Error(w, "Invalid merge patch", http.StatusBadRequest)
+		return
+	}
+	list.UUID = uuid
+	list.Version = existing.Version
+	if err := h.service.UpdateList(list); err != nil {
+		writeUpdateListError(w, err)
+		return
+	}
 
-	w.WriteHeader(http.StatusNoContent)
-	json.NewEncoder(w).Encode(list)
+	updated, err := h.service.GetListByUUID(uuid)
+	if err != nil {
+		http.Error(w, "Failed to retrieve updated list", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", formatETag(updated.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func writeUpdateListError(w http.ResponseWriter, err error) {
+	var validationErrors *services.ValidationErrors
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "List not found", http.StatusNotFound)
+	} else if errors.Is(err, repositories.ErrVersionMismatch) {
+		http.Error(w, "List has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+	} else if errors.As(err, &validationErrors) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+	} else {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
 }
 
 func (h *ListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
@@ -141,9 +243,22 @@ func (h *ListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
 		return
 	}
-	if err := h.service.DeleteList(uuid); err != nil {
+	cascade, err := parseBool(r.URL.Query(), "cascade", true)
+	if err != nil {
+		http.Error(w, "Invalid cascade value", http.StatusBadRequest)
+		return
+	}
+	version, ok := checkIfMatch(w, r, "List", h.listVersion(uuid))
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteList(uuid, cascade, version); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "List not found", http.StatusNotFound)
+		} else if errors.Is(err, repositories.ErrVersionMismatch) {
+			http.Error(w, "List has been modified, fetch it again and retry", http.StatusPreconditionFailed)
+		} else if errors.Is(err, repositories.ErrListNotEmpty) {
+			http.Error(w, "List still has contacts, delete them first or use cascade=true", http.StatusConflict)
 		} else {
 			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
 		}
@@ -152,3 +267,39 @@ func (h *ListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *ListHandler) RestoreList(w http.ResponseWriter, r *http.Request) {
+
+	id := r.PathValue("uuid")
+	uuid, err := uuid.Parse(id)
+	if err != nil {
+		http.Error(w, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.RestoreList(uuid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	list, err := h.service.GetListByUUID(uuid)
+	if err != nil {
+		http.Error(w, "Failed to retrieve restored list", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", formatETag(list.Version))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *ListHandler) listVersion(uuid uuid.UUID) func() (uint, error) {
+	return func() (uint, error) {
+		list, err := h.service.GetListByUUID(uuid)
+		if err != nil {
+			return 0, err
+		}
+		return list.Version, nil
+	}
+}
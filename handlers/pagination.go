@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func parsePagination(queryParams url.Values) (int, int) {
+	pageNum, err := strconv.Atoi(queryParams.Get("page"))
+	if err != nil || pageNum <= 0 {
+		pageNum = 1
+	}
+	pageSizeNum, err := strconv.Atoi(queryParams.Get("pageSize"))
+	if err != nil || pageSizeNum <= 0 {
+		pageSizeNum = 10
+	}
+	return pageNum, pageSizeNum
+}
+
+// setPaginationHeaders describes the page in headers so the body stays a plain
+// array. The Link header follows RFC 8288 and keeps the request's filters.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, page, pageSize int, total int64) {
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Page-Size", strconv.Itoa(pageSize))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+
+	var links []string
+	link := func(page int, rel string) {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("pageSize", strconv.Itoa(pageSize))
+		target := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel))
+	}
+	link(1, "first")
+	if page > 1 {
+		link(min(page-1, max(totalPages, 1)), "prev")
+	}
+	if page < totalPages {
+		link(page+1, "next")
+	}
+	link(max(totalPages, 1), "last")
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// setCursorHeaders is the keyset counterpart of setPaginationHeaders. Counting
+// would defeat the point of a cursor, so there is no total, and the Link
+// header only has a next page while X-Next-Cursor is set.
+func setCursorHeaders(w http.ResponseWriter, r *http.Request, pageSize int, nextCursor string) {
+	w.Header().Set("X-Page-Size", strconv.Itoa(pageSize))
+	if nextCursor == "" {
+		return
+	}
+	w.Header().Set("X-Next-Cursor", nextCursor)
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+	query.Set("pageSize", strconv.Itoa(pageSize))
+	target := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", target.String()))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"contact-list-api-1/repositories"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Fields the collection endpoints can be sorted by. They are also the column
+// names, so nothing outside these lists ever reaches ORDER BY.
+var (
+	contactSortFields = []string{"first_name", "last_name", "mobile", "email", "country_code", "created_at", "updated_at"}
+	listSortFields    = []string{"name", "created_at", "updated_at"}
+)
+
+func parseBool(queryParams url.Values, name string, defaultValue bool) (bool, error) {
+	value := queryParams.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// parseSort reads a sort parameter such as "last_name,-created_at", where a
+// leading "-" sorts that field in descending order.
+func parseSort(queryParams url.Values, allowed []string) ([]repositories.SortField, error) {
+	value := queryParams.Get("sort")
+	if value == "" {
+		return nil, nil
+	}
+	var sort []repositories.SortField
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		field := repositories.SortField{Column: strings.TrimPrefix(name, "-"), Desc: strings.HasPrefix(name, "-")}
+		if !slices.Contains(allowed, field.Column) {
+			return nil, fmt.Errorf("unknown sort field %q, expected one of %s", field.Column, strings.Join(allowed, ", "))
+		}
+		for _, previous := range sort {
+			if previous.Column == field.Column {
+				return nil, fmt.Errorf("sort field %q given more than once", field.Column)
+			}
+		}
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// parseImportMapping reads "map" parameters such as "Given Name:first_name".
+// The header is everything up to the last colon, so it may contain colons.
+func parseImportMapping(queryParams url.Values) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, value := range queryParams["map"] {
+		i := strings.LastIndex(value, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not of the form header:field", value)
+		}
+		mapping[value[:i]] = value[i+1:]
+	}
+	return mapping, nil
+}
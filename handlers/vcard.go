@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"contact-list-api-1/services/vcard"
+	"errors"
+	"mime"
+	"strings"
+)
+
+const vcardContentType = "text/vcard; charset=utf-8"
+
+// vcardVersion returns the vCard version for a media type, or false if it is
+// not a vCard type. text/vcard is version 4.0 unless a version parameter says
+// otherwise, and the older text/x-vcard is version 3.0.
+func vcardVersion(mediaType string, params map[string]string) (string, bool, error) {
+	var version string
+	switch mediaType {
+	case "text/vcard":
+		version = vcard.Version4
+	case "text/x-vcard":
+		version = vcard.Version3
+	default:
+		return "", false, nil
+	}
+	if v, ok := params["version"]; ok {
+		if v != vcard.Version3 && v != vcard.Version4 {
+			return "", true, errors.New("unsupported vCard version " + v + ", expected 3.0 or 4.0")
+		}
+		version = v
+	}
+	return version, true, nil
+}
+
+// acceptsVCard reports whether Accept asks for a vCard before it asks for JSON,
+// and in which version.
+func acceptsVCard(accept string) (string, bool, error) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if mediaType == "application/json" || mediaType == "*/*" {
+			return "", false, nil
+		}
+		if version, ok, err := vcardVersion(mediaType, params); ok {
+			return version, true, err
+		}
+	}
+	return "", false, nil
+}
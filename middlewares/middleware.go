@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 )
 
 func AuthMiddleware(token string, next http.Handler) http.Handler {
@@ -18,3 +20,30 @@ func AuthMiddleware(token string, next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// DAVAuthMiddleware checks the token of AuthMiddleware for CardDAV clients.
+// Most of them can only send basic authentication, so the token is also taken
+// as its password, with any user name. Failures ask for basic authentication,
+// which makes clients prompt for the password again. Without a token nothing
+// is let through.
+func DAVAuthMiddleware(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !davAuthorized(r, token) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="contacts", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func davAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, credential, ok = r.BasicAuth()
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(credential), []byte(token)) == 1
+}
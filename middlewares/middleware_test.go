@@ -59,3 +59,70 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 
 }
+
+func TestDAVAuthMiddleware(t *testing.T) {
+	token := "Axf2FVAusahoXmKMLZih7LrhBwmYLVmyLDiMoYizPGReJTKEaseAb12oGYvbLleS"
+	testCases := []struct {
+		name         string
+		setAuth      func(req *http.Request)
+		unsetToken   bool
+		expectedCode int
+	}{
+		{
+			name:         "Bearer token",
+			setAuth:      func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Token as basic password",
+			setAuth:      func(req *http.Request) { req.SetBasicAuth("phone", token) },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Wrong basic password",
+			setAuth:      func(req *http.Request) { req.SetBasicAuth(token, "wrong") },
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Missing Authorization header",
+			setAuth:      func(req *http.Request) {},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Empty basic password without a token",
+			setAuth:      func(req *http.Request) { req.SetBasicAuth("phone", "") },
+			unsetToken:   true,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Empty bearer token without a token",
+			setAuth:      func(req *http.Request) { req.Header.Set("Authorization", "Bearer ") },
+			unsetToken:   true,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req, err := http.NewRequest("PROPFIND", "/carddav/", nil)
+			if err != nil {
+				t.Fatalf("Could not create request:%v", err)
+			}
+			tt.setAuth(req)
+			serverToken := token
+			if tt.unsetToken {
+				serverToken = ""
+			}
+			rr := httptest.NewRecorder()
+			DAVAuthMiddleware(serverToken, handler).ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, rr.Code)
+			}
+			if challenge := rr.Header().Get("WWW-Authenticate"); (challenge != "") != (tt.expectedCode == http.StatusUnauthorized) {
+				t.Errorf("Unexpected WWW-Authenticate header %q", challenge)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type list0001 struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement"`
+	UUID string `gorm:"type:char(36);not null;uniqueIndex"`
+	Name string `gorm:"type:varchar(255);not null"`
+}
+
+func (list0001) TableName() string {
+	return "lists"
+}
+
+type contact0001 struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	UUID        string `gorm:"type:char(36);not null;uniqueIndex"`
+	FirstName   string `gorm:"type:varchar(255);not null"`
+	LastName    string `gorm:"type:varchar(255);not null"`
+	Mobile      string `gorm:"type:varchar(20);not null"`
+	Email       string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	CountryCode string `gorm:"type:varchar(3);not null"`
+	ListID      uint   `gorm:"not null"`
+}
+
+func (contact0001) TableName() string {
+	return "contacts"
+}
+
+// Databases created before versioned migrations already have these tables,
+// so both are only created when missing.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "create_lists_and_contacts",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&list0001{}) {
+				if err := tx.Migrator().CreateTable(&list0001{}); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasTable(&contact0001{}) {
+				return tx.Migrator().CreateTable(&contact0001{})
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&contact0001{}, &list0001{})
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type listMembership0002 struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ListID    uint      `gorm:"not null;uniqueIndex:idx_list_memberships_list_contact"`
+	ContactID uint      `gorm:"not null;uniqueIndex:idx_list_memberships_list_contact;index"`
+	AddedAt   time.Time `gorm:"not null"`
+	Source    string    `gorm:"type:varchar(50);not null"`
+}
+
+func (listMembership0002) TableName() string {
+	return "list_memberships"
+}
+
+type contactListID0002 struct {
+	ListID uint
+}
+
+func (contactListID0002) TableName() string {
+	return "contacts"
+}
+
+type contactIndexes0002 struct {
+	UUID  string `gorm:"type:char(36);not null;uniqueIndex"`
+	Email string `gorm:"type:varchar(255);not null;uniqueIndex"`
+}
+
+func (contactIndexes0002) TableName() string {
+	return "contacts"
+}
+
+// Contacts used to carry a single list_id column. Move those values into
+// list_memberships and drop the column once the data has been copied.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "create_list_memberships",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&listMembership0002{}) {
+				if err := tx.Migrator().CreateTable(&listMembership0002{}); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasColumn(&contactListID0002{}, "list_id") {
+				return nil
+			}
+			err := tx.Exec(`INSERT INTO list_memberships (list_id, contact_id, added_at, source)
+				SELECT c.list_id, c.id, ?, 'migration' FROM contacts c
+				WHERE c.list_id IS NOT NULL AND c.list_id <> 0
+				AND NOT EXISTS (SELECT 1 FROM list_memberships m WHERE m.list_id = c.list_id AND m.contact_id = c.id)`,
+				time.Now()).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&contactListID0002{}, "list_id"); err != nil {
+				return err
+			}
+			// SQLite drops a column by rebuilding the table, which loses its indexes.
+			for _, field := range []string{"UUID", "Email"} {
+				if !tx.Migrator().HasIndex(&contactIndexes0002{}, field) {
+					if err := tx.Migrator().CreateIndex(&contactIndexes0002{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&contactListID0002{}, "ListID"); err != nil {
+				return err
+			}
+			err := tx.Exec(`UPDATE contacts SET list_id = (SELECT MIN(m.list_id) FROM list_memberships m WHERE m.contact_id = contacts.id)`).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&listMembership0002{})
+		},
+	})
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type listTimestamps0003 struct {
+	UUID      string `gorm:"type:char(36);not null;uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (listTimestamps0003) TableName() string {
+	return "lists"
+}
+
+type contactTimestamps0003 struct {
+	UUID      string `gorm:"type:char(36);not null;uniqueIndex"`
+	Email     string `gorm:"type:varchar(255);not null;uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (contactTimestamps0003) TableName() string {
+	return "contacts"
+}
+
+var timestampFields0003 = []string{"CreatedAt", "UpdatedAt", "DeletedAt"}
+
+// Existing rows get the time of the migration as their created and updated
+// timestamps since the real values were never recorded.
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "add_timestamps_and_soft_delete",
+		Up: func(tx *gorm.DB) error {
+			now := time.Now()
+			for _, model := range []interface{}{&listTimestamps0003{}, &contactTimestamps0003{}} {
+				for _, field := range timestampFields0003 {
+					if tx.Migrator().HasColumn(model, field) {
+						continue
+					}
+					if err := tx.Migrator().AddColumn(model, field); err != nil {
+						return err
+					}
+				}
+				if err := ensureIndexes(tx, model, "DeletedAt"); err != nil {
+					return err
+				}
+				err := tx.Model(model).Where("created_at IS NULL").
+					Updates(map[string]interface{}{"created_at": now, "updated_at": now}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&listTimestamps0003{}, &contactTimestamps0003{}} {
+				if err := tx.Migrator().DropIndex(model, "DeletedAt"); err != nil {
+					return err
+				}
+				for _, field := range timestampFields0003 {
+					if err := tx.Migrator().DropColumn(model, field); err != nil {
+						return err
+					}
+				}
+			}
+			if err := ensureIndexes(tx, &listTimestamps0003{}, "UUID"); err != nil {
+				return err
+			}
+			return ensureIndexes(tx, &contactTimestamps0003{}, "UUID", "Email")
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type listVersion0004 struct {
+	UUID      string         `gorm:"type:char(36);not null;uniqueIndex"`
+	Version   uint           `gorm:"not null;default:1"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (listVersion0004) TableName() string {
+	return "lists"
+}
+
+type contactVersion0004 struct {
+	UUID      string         `gorm:"type:char(36);not null;uniqueIndex"`
+	Email     string         `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Version   uint           `gorm:"not null;default:1"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (contactVersion0004) TableName() string {
+	return "contacts"
+}
+
+// Every existing row starts at version 1 through the column default.
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "add_versions",
+		Up: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&listVersion0004{}, &contactVersion0004{}} {
+				if tx.Migrator().HasColumn(model, "Version") {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(model, "Version"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&listVersion0004{}, &contactVersion0004{}} {
+				if err := tx.Migrator().DropColumn(model, "Version"); err != nil {
+					return err
+				}
+			}
+			if err := ensureIndexes(tx, &listVersion0004{}, "UUID", "DeletedAt"); err != nil {
+				return err
+			}
+			return ensureIndexes(tx, &contactVersion0004{}, "UUID", "Email", "DeletedAt")
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type contactMobile0005 struct {
+	Mobile string `gorm:"type:varchar(20);not null;uniqueIndex"`
+}
+
+func (contactMobile0005) TableName() string {
+	return "contacts"
+}
+
+// Mobile numbers were only checked for uniqueness by the service, so existing
+// duplicates have to be resolved by hand before the index can be created.
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "add_contact_mobile_unique_index",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&contactMobile0005{}, "Mobile") {
+				return nil
+			}
+			var duplicates []string
+			err := tx.Raw("SELECT mobile FROM contacts GROUP BY mobile HAVING COUNT(*) > 1").Scan(&duplicates).Error
+			if err != nil {
+				return err
+			}
+			if len(duplicates) > 0 {
+				return fmt.Errorf("mobile numbers shared by several contacts: %v", duplicates)
+			}
+			return tx.Migrator().CreateIndex(&contactMobile0005{}, "Mobile")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropIndex(&contactMobile0005{}, "Mobile")
+		},
+	})
+}
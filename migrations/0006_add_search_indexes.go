@@ -0,0 +1,35 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Only MySQL gets FULLTEXT indexes. The other databases search with LIKE, see
+// repositories.contactRepository.Search.
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "add_search_indexes",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if !tx.Migrator().HasIndex("contacts", "idx_contacts_search") {
+				if err := tx.Exec("CREATE FULLTEXT INDEX idx_contacts_search ON contacts (first_name, last_name, email, mobile)").Error; err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex("lists", "idx_lists_search") {
+				return tx.Exec("CREATE FULLTEXT INDEX idx_lists_search ON lists (name)").Error
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Migrator().DropIndex("contacts", "idx_contacts_search"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropIndex("lists", "idx_lists_search")
+		},
+	})
+}
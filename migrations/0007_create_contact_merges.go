@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type contactMerge0007 struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	UUID           string    `gorm:"type:char(36);not null;uniqueIndex"`
+	PrimaryUUID    string    `gorm:"type:char(36);not null;index"`
+	SecondaryUUIDs string    `gorm:"type:text;not null"`
+	FieldSources   string    `gorm:"type:text;not null"`
+	Contacts       string    `gorm:"type:text;not null"`
+	MergedAt       time.Time `gorm:"not null"`
+}
+
+func (contactMerge0007) TableName() string {
+	return "contact_merges"
+}
+
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "create_contact_merges",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&contactMerge0007{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&contactMerge0007{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&contactMerge0007{})
+		},
+	})
+}
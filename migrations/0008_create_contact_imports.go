@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type contactImport0008 struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	UUID       string    `gorm:"type:char(36);not null;uniqueIndex"`
+	ListUUID   string    `gorm:"type:char(36);not null;index"`
+	Header     string    `gorm:"type:text;not null"`
+	Created    int       `gorm:"not null"`
+	Skipped    int       `gorm:"not null"`
+	Failed     int       `gorm:"not null"`
+	ImportedAt time.Time `gorm:"not null"`
+}
+
+func (contactImport0008) TableName() string {
+	return "contact_imports"
+}
+
+type contactImportRow0008 struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	ImportUUID string `gorm:"type:char(36);not null;index"`
+	Line       int    `gorm:"not null"`
+	Values     string `gorm:"type:text;not null"`
+	Errors     string `gorm:"type:text;not null"`
+}
+
+func (contactImportRow0008) TableName() string {
+	return "contact_import_rows"
+}
+
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "create_contact_imports",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range []interface{}{&contactImport0008{}, &contactImportRow0008{}} {
+				if tx.Migrator().HasTable(table) {
+					continue
+				}
+				if err := tx.Migrator().CreateTable(table); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&contactImportRow0008{}, &contactImport0008{})
+		},
+	})
+}
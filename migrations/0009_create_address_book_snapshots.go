@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type addressBookSnapshot0009 struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement"`
+	ListUUID string    `gorm:"type:char(36);not null;uniqueIndex:idx_address_book_snapshots_list_token"`
+	Token    string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_address_book_snapshots_list_token"`
+	Members  string    `gorm:"type:text;not null"`
+	IssuedAt time.Time `gorm:"not null"`
+}
+
+func (addressBookSnapshot0009) TableName() string {
+	return "address_book_snapshots"
+}
+
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "create_address_book_snapshots",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&addressBookSnapshot0009{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&addressBookSnapshot0009{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&addressBookSnapshot0009{})
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type contactImportLinked0010 struct {
+	UUID     string `gorm:"type:char(36);not null;uniqueIndex"`
+	ListUUID string `gorm:"type:char(36);not null;index"`
+	Linked   int    `gorm:"not null;default:0"`
+}
+
+func (contactImportLinked0010) TableName() string {
+	return "contact_imports"
+}
+
+// Imports made before existing contacts were added to the list never linked
+// any, so the column default is right for them.
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "add_contact_import_linked",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&contactImportLinked0010{}, "Linked") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&contactImportLinked0010{}, "Linked")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&contactImportLinked0010{}, "Linked"); err != nil {
+				return err
+			}
+			return ensureIndexes(tx, &contactImportLinked0010{}, "UUID", "ListUUID")
+		},
+	})
+}
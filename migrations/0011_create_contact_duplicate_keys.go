@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+type contactDuplicateKey0011 struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	ContactID uint   `gorm:"not null;index;index:idx_contact_duplicate_keys_block,priority:3"`
+	Kind      string `gorm:"type:varchar(10);not null;index:idx_contact_duplicate_keys_block,priority:1"`
+	Value     string `gorm:"type:varchar(255);not null;index:idx_contact_duplicate_keys_block,priority:2"`
+}
+
+func (contactDuplicateKey0011) TableName() string {
+	return "contact_duplicate_keys"
+}
+
+type contactFields0011 struct {
+	ID        uint
+	FirstName string
+	LastName  string
+	Mobile    string
+	Email     string
+}
+
+func (contactFields0011) TableName() string {
+	return "contacts"
+}
+
+// Deleted contacts get their keys too, they are found again once restored. The
+// blocks are computed by a copy of services/duplicate as it was when the table
+// was created, so later changes there do not change what this migration does.
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "create_contact_duplicate_keys",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasTable(&contactDuplicateKey0011{}) {
+				return nil
+			}
+			if err := tx.Migrator().CreateTable(&contactDuplicateKey0011{}); err != nil {
+				return err
+			}
+			var contacts []contactFields0011
+			return tx.Order("id").FindInBatches(&contacts, 500, func(batch *gorm.DB, _ int) error {
+				var keys []contactDuplicateKey0011
+				for _, contact := range contacts {
+					keys = append(keys, duplicateKeys0011(contact)...)
+				}
+				if len(keys) == 0 {
+					return nil
+				}
+				return batch.CreateInBatches(&keys, 500).Error
+			}).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&contactDuplicateKey0011{})
+		},
+	})
+}
+
+func duplicateKeys0011(contact contactFields0011) []contactDuplicateKey0011 {
+	var keys []contactDuplicateKey0011
+	add := func(kind, value string) {
+		key := contactDuplicateKey0011{ContactID: contact.ID, Kind: kind, Value: value}
+		if value != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(contact.Email))
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		local, domain := email[:at], email[at:]
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
+		email = local + domain
+	}
+	add("email", email)
+
+	mobile := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, contact.Mobile)
+	add("mobile", mobile[max(0, len(mobile)-9):])
+
+	var name strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(contact.FirstName + " " + contact.LastName)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			name.WriteRune(r)
+		default:
+			name.WriteRune(' ')
+		}
+	}
+	tokens := strings.Fields(name.String())
+	slices.Sort(tokens)
+	for _, token := range tokens {
+		runes := []rune(token)
+		add("name", string(runes[:min(2, len(runes))]))
+	}
+	return keys
+}
@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+type addressBookSnapshotMember0012 struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	SnapshotID  uint   `gorm:"not null;uniqueIndex:idx_address_book_snapshot_members_snapshot_contact"`
+	ContactUUID string `gorm:"type:char(36);not null;uniqueIndex:idx_address_book_snapshot_members_snapshot_contact"`
+	Version     uint   `gorm:"not null"`
+}
+
+func (addressBookSnapshotMember0012) TableName() string {
+	return "address_book_snapshot_members"
+}
+
+type addressBookSnapshot0012 struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	ListUUID string `gorm:"type:char(36);not null;uniqueIndex:idx_address_book_snapshots_list_token"`
+	Token    string `gorm:"type:varchar(64);not null;uniqueIndex:idx_address_book_snapshots_list_token"`
+	Members  string `gorm:"type:text"`
+}
+
+func (addressBookSnapshot0012) TableName() string {
+	return "address_book_snapshots"
+}
+
+// Snapshots kept every member as JSON in one TEXT column, which MySQL caps at
+// 64 KB, about 1,500 members. Move them into a table with a row per member.
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "create_address_book_snapshot_members",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasTable(&addressBookSnapshotMember0012{}) {
+				if err := tx.Migrator().CreateTable(&addressBookSnapshotMember0012{}); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasColumn(&addressBookSnapshot0012{}, "members") {
+				return nil
+			}
+			var snapshots []addressBookSnapshot0012
+			err := tx.Order("id").FindInBatches(&snapshots, 100, func(batch *gorm.DB, _ int) error {
+				for _, snapshot := range snapshots {
+					var members map[string]uint
+					if err := json.Unmarshal([]byte(snapshot.Members), &members); err != nil {
+						return err
+					}
+					rows := make([]addressBookSnapshotMember0012, 0, len(members))
+					for contactUUID, version := range members {
+						rows = append(rows, addressBookSnapshotMember0012{SnapshotID: snapshot.ID, ContactUUID: contactUUID, Version: version})
+					}
+					if len(rows) == 0 {
+						continue
+					}
+					if err := batch.CreateInBatches(&rows, 500).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&addressBookSnapshot0012{}, "Members"); err != nil {
+				return err
+			}
+			return ensureIndexes(tx, &addressBookSnapshot0012{}, "idx_address_book_snapshots_list_token")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&addressBookSnapshot0012{}, "Members"); err != nil {
+				return err
+			}
+			var snapshots []addressBookSnapshot0012
+			err := tx.Order("id").FindInBatches(&snapshots, 100, func(batch *gorm.DB, _ int) error {
+				for _, snapshot := range snapshots {
+					var rows []addressBookSnapshotMember0012
+					if err := batch.Where("snapshot_id = ?", snapshot.ID).Find(&rows).Error; err != nil {
+						return err
+					}
+					members := make(map[string]uint, len(rows))
+					for _, row := range rows {
+						members[row.ContactUUID] = row.Version
+					}
+					encoded, err := json.Marshal(members)
+					if err != nil {
+						return err
+					}
+					if err := batch.Model(&addressBookSnapshot0012{}).Where("id = ?", snapshot.ID).UpdateColumn("members", string(encoded)).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&addressBookSnapshotMember0012{})
+		},
+	})
+}
@@ -0,0 +1,157 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+var registered []Migration
+
+// register is called from the init function of every numbered migration file.
+func register(migration Migration) {
+	for _, existing := range registered {
+		if existing.Version == migration.Version {
+			panic(fmt.Sprintf("migration %d registered twice", migration.Version))
+		}
+	}
+	registered = append(registered, migration)
+	sort.Slice(registered, func(i, j int) bool { return registered[i].Version < registered[j].Version })
+}
+
+func All() []Migration {
+	return append([]Migration(nil), registered...)
+}
+
+func Up(db *gorm.DB) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("number of migrations to roll back must be positive, got %d", steps)
+	}
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	var rolledBack []Migration
+	for i := len(registered) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := registered[i]
+		if _, ok := versions[migration.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rolling back migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+func Pending(db *gorm.DB) ([]Migration, error) {
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range registered {
+		if _, ok := versions[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(registered))
+	for _, migration := range registered {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := versions[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ensureIndexes recreates indexes that SQLite loses when it rebuilds a table to
+// drop a column.
+func ensureIndexes(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasIndex(model, field) {
+			continue
+		}
+		if err := tx.Migrator().CreateIndex(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appliedVersions(db *gorm.DB) (map[int]time.Time, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var applied []SchemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	versions := make(map[int]time.Time, len(applied))
+	for _, migration := range applied {
+		versions[migration.Version] = migration.AppliedAt
+	}
+	return versions, nil
+}
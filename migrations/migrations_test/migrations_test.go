@@ -0,0 +1,280 @@
+package migrations
+
+import (
+	"contact-list-api-1/config"
+	"contact-list-api-1/database"
+	"contact-list-api-1/migrations"
+	"contact-list-api-1/models"
+	"contact-list-api-1/tests"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type legacyContact struct {
+	ID     uint
+	ListID uint
+}
+
+func (legacyContact) TableName() string {
+	return "contacts"
+}
+
+type legacyList struct {
+	ID   uint
+	UUID string
+	Name string
+}
+
+func (legacyList) TableName() string {
+	return "lists"
+}
+
+func TestUpDownAndStatus(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	pending, err := migrations.Pending(db)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("Expected no pending migrations after setup, got %d", len(pending))
+	}
+
+	rolledBack, err := migrations.Down(db, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	last := migrations.All()[len(migrations.All())-1]
+	if len(rolledBack) != 1 || rolledBack[0].Version != last.Version {
+		t.Fatalf("Expected migration %d to be rolled back, got %+v", last.Version, rolledBack)
+	}
+
+	statuses, err := migrations.GetStatus(db)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version != last.Version) {
+			t.Errorf("Unexpected status for migration %d: applied=%v", status.Version, applied)
+		}
+	}
+
+	applied, err := migrations.Up(db)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != last.Version {
+		t.Errorf("Expected only migration %d to be applied, got %+v", last.Version, applied)
+	}
+	if _, err := migrations.Down(db, 0); err == nil {
+		t.Errorf("Expected rolling back zero migrations to fail")
+	}
+}
+
+func TestUp_MovesContactListIDsToMemberships(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	rollBackTo(t, db, 1)
+	if !db.Migrator().HasColumn(&legacyContact{}, "list_id") {
+		t.Fatalf("Expected legacy list_id column after rolling back")
+	}
+
+	list := createLegacyList(t, db, "Legacy List")
+	err := db.Exec("INSERT INTO contacts (uuid, first_name, last_name, mobile, email, country_code, list_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		uuid.New().String(), "Legacy", "Contact", "+1234567890", "legacy@example.com", "USA", list.ID).Error
+	if err != nil {
+		t.Fatalf("Could not create legacy contact: %v", err)
+	}
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if db.Migrator().HasColumn(&legacyContact{}, "list_id") {
+		t.Errorf("Expected list_id column to be dropped")
+	}
+	for _, index := range []string{"idx_contacts_uuid", "idx_contacts_email"} {
+		if !db.Migrator().HasIndex(&models.Contact{}, index) {
+			t.Errorf("Expected index %s to survive dropping list_id", index)
+		}
+	}
+	var contact models.Contact
+	if err := db.Where("email = ?", "legacy@example.com").First(&contact).Error; err != nil {
+		t.Fatalf("Expected legacy contact to survive, got %v", err)
+	}
+	var membership models.ListMembership
+	if err := db.Where("contact_id = ? AND list_id = ?", contact.ID, list.ID).First(&membership).Error; err != nil {
+		t.Fatalf("Expected membership to be created, got %v", err)
+	}
+	if membership.Source != models.MembershipSourceMigration {
+		t.Errorf("Expected membership source '%s', got '%s'", models.MembershipSourceMigration, membership.Source)
+	}
+}
+
+func TestUp_BackfillsTimestamps(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	rollBackTo(t, db, 2)
+	if db.Migrator().HasColumn(&models.List{}, "deleted_at") {
+		t.Fatalf("Expected deleted_at column to be dropped")
+	}
+	if !db.Migrator().HasIndex(&models.List{}, "idx_lists_uuid") {
+		t.Errorf("Expected index idx_lists_uuid to survive the rollback")
+	}
+	legacy := createLegacyList(t, db, "Legacy List")
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var list models.List
+	if err := db.First(&list, legacy.ID).Error; err != nil {
+		t.Fatalf("Expected legacy list to be visible after migrating, got %v", err)
+	}
+	if list.CreatedAt.IsZero() || list.UpdatedAt.IsZero() {
+		t.Errorf("Expected timestamps to be backfilled, got %+v", list)
+	}
+}
+
+func TestUp_RejectsDuplicateMobiles(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	rollBackTo(t, db, 4)
+	if db.Migrator().HasIndex(&models.Contact{}, "idx_contacts_mobile") {
+		t.Fatalf("Expected idx_contacts_mobile to be dropped")
+	}
+	for _, email := range []string{"first@example.com", "second@example.com"} {
+		err := db.Exec("INSERT INTO contacts (uuid, first_name, last_name, mobile, email, country_code) VALUES (?, ?, ?, ?, ?, ?)",
+			uuid.New().String(), "Legacy", "Contact", "+1234567890", email, "USA").Error
+		if err != nil {
+			t.Fatalf("Could not create legacy contact: %v", err)
+		}
+	}
+
+	if _, err := migrations.Up(db); err == nil || !strings.Contains(err.Error(), "+1234567890") {
+		t.Fatalf("Expected migration to name the duplicate mobile, got %v", err)
+	}
+	if err := db.Exec("DELETE FROM contacts WHERE email = ?", "second@example.com").Error; err != nil {
+		t.Fatalf("Could not remove duplicate: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !db.Migrator().HasIndex(&models.Contact{}, "idx_contacts_mobile") {
+		t.Errorf("Expected idx_contacts_mobile to be created")
+	}
+}
+
+func TestUp_BackfillsDuplicateKeys(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	rollBackTo(t, db, 10)
+	if db.Migrator().HasTable(&models.ContactDuplicateKey{}) {
+		t.Fatalf("Expected contact_duplicate_keys to be dropped")
+	}
+	contact := models.Contact{UUID: uuid.New(), FirstName: "Sara", LastName: "Savić", Mobile: "+381 60 111 1111", Email: "Sara+home@example.com", CountryCode: "SRB"}
+	if err := db.Create(&contact).Error; err != nil {
+		t.Fatalf("Could not create legacy contact: %v", err)
+	}
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var keys []models.ContactDuplicateKey
+	if err := db.Where("contact_id = ?", contact.ID).Order("id").Find(&keys).Error; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var found []string
+	for _, key := range keys {
+		found = append(found, key.Kind+":"+key.Value)
+	}
+	if expected := "email:sara@example.com mobile:601111111 name:sa"; strings.Join(found, " ") != expected {
+		t.Errorf("Expected keys %q, got %q", expected, strings.Join(found, " "))
+	}
+}
+
+func TestUp_MovesSnapshotMembersToRows(t *testing.T) {
+	db := tests.SetupTestDB(t)
+	defer tests.TearDownTestDB(t, db)
+
+	rollBackTo(t, db, 11)
+	listUUID, contactUUID := uuid.New(), uuid.New()
+	err := db.Exec("INSERT INTO address_book_snapshots (list_uuid, token, members, issued_at) VALUES (?, ?, ?, ?)",
+		listUUID.String(), "legacy", `{"`+contactUUID.String()+`":3}`, time.Now()).Error
+	if err != nil {
+		t.Fatalf("Could not create legacy snapshot: %v", err)
+	}
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var snapshot models.AddressBookSnapshot
+	if err := db.Where("list_uuid = ? AND token = ?", listUUID, "legacy").First(&snapshot).Error; err != nil {
+		t.Fatalf("Expected the legacy snapshot to survive, got %v", err)
+	}
+	var members []models.AddressBookSnapshotMember
+	if err := db.Where("snapshot_id = ?", snapshot.ID).Find(&members).Error; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(members) != 1 || members[0].ContactUUID != contactUUID || members[0].Version != 3 {
+		t.Errorf("Expected the member to be moved to its own row, got %+v", members)
+	}
+	if !db.Migrator().HasIndex(&models.AddressBookSnapshot{}, "idx_address_book_snapshots_list_token") {
+		t.Errorf("Expected idx_address_book_snapshots_list_token to survive dropping the members column")
+	}
+
+	rollBackTo(t, db, 11)
+	var encoded string
+	if err := db.Raw("SELECT members FROM address_book_snapshots WHERE id = ?", snapshot.ID).Scan(&encoded).Error; err != nil || encoded != `{"`+contactUUID.String()+`":3}` {
+		t.Errorf("Expected rolling back to restore the JSON members, got %q (%v)", encoded, err)
+	}
+}
+
+func TestUp_AdoptsSchemaCreatedByAutoMigrate(t *testing.T) {
+	db, err := database.Open(config.DBConfig{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "legacy.db")})
+	if err != nil {
+		t.Fatalf("Could not open database: %v", err)
+	}
+	defer tests.TearDownTestDB(t, db)
+	if err := db.AutoMigrate(&models.List{}, &models.Contact{}, &models.ListMembership{}); err != nil {
+		t.Fatalf("Could not create legacy schema: %v", err)
+	}
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	pending, err := migrations.Pending(db)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %d (%v)", len(pending), err)
+	}
+}
+
+func createLegacyList(t *testing.T, db *gorm.DB, name string) legacyList {
+	list := legacyList{UUID: uuid.New().String(), Name: name}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	return list
+}
+
+func rollBackTo(t *testing.T, db *gorm.DB, version int) {
+	for _, migration := range migrations.All() {
+		if migration.Version > version {
+			if _, err := migrations.Down(db, 1); err != nil {
+				t.Fatalf("Could not roll back migration %d: %v", migration.Version, err)
+			}
+		}
+	}
+}
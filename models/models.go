@@ -1,23 +1,129 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
-	//"gorm.io/gorm"
+	"gorm.io/gorm"
+)
+
+const (
+	MembershipSourceAPI       = "api"
+	MembershipSourceMigration = "migration"
+	MembershipSourceMerge     = "merge"
+	MembershipSourceImport    = "import"
+)
+
+const (
+	TrashItemList    = "list"
+	TrashItemContact = "contact"
 )
 
 type List struct {
-	ID   uint      `gorm:"primaryKey;autoIncrement"`
-	UUID uuid.UUID `gorm:"type:char(36); not null;uniqueIndex" json:"uuid":`
-	Name string    `gorm:"type:varchar(255);not null" json:"name"`
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"-"`
+	UUID      uuid.UUID      `gorm:"type:char(36); not null;uniqueIndex" json:"uuid"`
+	Name      string         `gorm:"type:varchar(255);not null" json:"name"`
+	Version   uint           `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 type Contact struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement"`
-	UUID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex" json:"uuid:`
-	FirstName   string    `gorm:"type:varchar(255);not null" json:"first_name"`
-	LastName    string    `gorm:"type:varchar(255);not null" json:"last_name"`
-	Mobile      string    `gorm:"type:varchar(20);not null" json:"mobile"`
-	Email       string    `gorm:"type:varchar(255); not null ; uniqueIndex" json:"email"`
-	CountryCode string    `gorm:"type:varchar(3);not null" json:"country_code"`
-	ListID      uint      `gorm:"not null" json:"list_id"`
+	ID          uint           `gorm:"primaryKey;autoIncrement" json:"-"`
+	UUID        uuid.UUID      `gorm:"type:char(36);not null;uniqueIndex" json:"uuid"`
+	FirstName   string         `gorm:"type:varchar(255);not null" json:"first_name"`
+	LastName    string         `gorm:"type:varchar(255);not null" json:"last_name"`
+	Mobile      string         `gorm:"type:varchar(20);not null;uniqueIndex" json:"mobile"`
+	Email       string         `gorm:"type:varchar(255); not null ; uniqueIndex" json:"email"`
+	CountryCode string         `gorm:"type:varchar(3);not null" json:"country_code"`
+	ListIDs     []uint         `gorm:"-" json:"-"`
+	ListUUIDs   []uuid.UUID    `gorm:"-" json:"list_uuids"`
+	Version     uint           `gorm:"not null;default:1" json:"version"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+type ListMembership struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	ListID    uint      `gorm:"not null;uniqueIndex:idx_list_memberships_list_contact" json:"-"`
+	ContactID uint      `gorm:"not null;uniqueIndex:idx_list_memberships_list_contact;index" json:"-"`
+	AddedAt   time.Time `gorm:"not null" json:"added_at"`
+	Source    string    `gorm:"type:varchar(50);not null" json:"source"`
+}
+
+// ContactMerge records secondary contacts merged into a primary one. The
+// secondaries are deleted by the merge, so Contacts keeps every contact as it
+// was before, primary first.
+type ContactMerge struct {
+	ID             uint                 `gorm:"primaryKey;autoIncrement" json:"-"`
+	UUID           uuid.UUID            `gorm:"type:char(36);not null;uniqueIndex" json:"uuid"`
+	PrimaryUUID    uuid.UUID            `gorm:"type:char(36);not null;index" json:"primary_uuid"`
+	SecondaryUUIDs []uuid.UUID          `gorm:"type:text;not null;serializer:json" json:"secondary_uuids"`
+	FieldSources   map[string]uuid.UUID `gorm:"type:text;not null;serializer:json" json:"field_sources"`
+	Contacts       []Contact            `gorm:"type:text;not null;serializer:json" json:"contacts"`
+	MergedAt       time.Time            `gorm:"not null" json:"merged_at"`
+}
+
+// ContactImport is the outcome of a CSV import into a list. Linked counts the
+// existing contacts added to the list and Skipped those that were on it
+// already. The rows that failed are kept as ContactImportRows for the error
+// report.
+type ContactImport struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	UUID       uuid.UUID `gorm:"type:char(36);not null;uniqueIndex" json:"uuid"`
+	ListUUID   uuid.UUID `gorm:"type:char(36);not null;index" json:"list_uuid"`
+	Header     []string  `gorm:"type:text;not null;serializer:json" json:"-"`
+	Created    int       `gorm:"not null" json:"created"`
+	Linked     int       `gorm:"not null;default:0" json:"linked"`
+	Skipped    int       `gorm:"not null" json:"skipped"`
+	Failed     int       `gorm:"not null" json:"failed"`
+	ImportedAt time.Time `gorm:"not null" json:"imported_at"`
+}
+
+// ContactImportRow is a row of an import that could not be imported, with the
+// values as they were in the file.
+type ContactImportRow struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	ImportUUID uuid.UUID `gorm:"type:char(36);not null;index" json:"-"`
+	Line       int       `gorm:"not null" json:"line"`
+	Values     []string  `gorm:"type:text;not null;serializer:json" json:"values"`
+	Errors     []string  `gorm:"type:text;not null;serializer:json" json:"errors"`
+}
+
+// AddressBookSnapshot is a list as a CardDAV client saw it when it was handed
+// Token: the version of every member. Token is derived from Members, so a list
+// that has not changed keeps its token. Members are stored as
+// AddressBookSnapshotMembers, one row per contact, since lists can be large.
+type AddressBookSnapshot struct {
+	ID       uint               `gorm:"primaryKey;autoIncrement" json:"-"`
+	ListUUID uuid.UUID          `gorm:"type:char(36);not null;uniqueIndex:idx_address_book_snapshots_list_token" json:"list_uuid"`
+	Token    string             `gorm:"type:varchar(64);not null;uniqueIndex:idx_address_book_snapshots_list_token" json:"token"`
+	Members  map[uuid.UUID]uint `gorm:"-" json:"members"`
+	IssuedAt time.Time          `gorm:"not null" json:"issued_at"`
+}
+
+type AddressBookSnapshotMember struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	SnapshotID  uint      `gorm:"not null;uniqueIndex:idx_address_book_snapshot_members_snapshot_contact" json:"-"`
+	ContactUUID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_address_book_snapshot_members_snapshot_contact" json:"contact_uuid"`
+	Version     uint      `gorm:"not null" json:"version"`
+}
+
+// ContactDuplicateKey files a contact under one of its duplicate blocks, see
+// services/duplicate. The rows are kept in step with the contact by the
+// repository, so candidates for a duplicate check come from an index lookup.
+type ContactDuplicateKey struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement" json:"-"`
+	ContactID uint   `gorm:"not null;index;index:idx_contact_duplicate_keys_block,priority:3" json:"-"`
+	Kind      string `gorm:"type:varchar(10);not null;index:idx_contact_duplicate_keys_block,priority:1" json:"-"`
+	Value     string `gorm:"type:varchar(255);not null;index:idx_contact_duplicate_keys_block,priority:2" json:"-"`
+}
+
+type TrashItem struct {
+	Type      string    `json:"type"`
+	UUID      uuid.UUID `json:"uuid"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
 }
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AddressBookRepository interface {
+	GetMembers(listID uint, contactUUIDs []uuid.UUID) ([]models.Contact, error)
+	SaveSnapshot(snapshot models.AddressBookSnapshot) error
+	GetSnapshot(listUUID uuid.UUID, token string) (*models.AddressBookSnapshot, error)
+	PruneSnapshots(listUUID uuid.UUID, keep int) error
+}
+
+type addressBookRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressBookRepository(db *gorm.DB) AddressBookRepository {
+	return &addressBookRepository{db: db}
+}
+
+// GetMembers returns the live contacts of the list in the order they were
+// created, or only those among contactUUIDs when it is not nil. Their lists
+// are not loaded.
+func (a *addressBookRepository) GetMembers(listID uint, contactUUIDs []uuid.UUID) ([]models.Contact, error) {
+	contacts := make([]models.Contact, 0)
+	if contactUUIDs != nil && len(contactUUIDs) == 0 {
+		return contacts, nil
+	}
+	query := a.db.Where("id IN (?)", a.db.Model(&models.ListMembership{}).Select("contact_id").Where("list_id = ?", listID))
+	if contactUUIDs != nil {
+		query = query.Where("uuid IN ?", contactUUIDs)
+	}
+	if err := query.Order("id").Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// SaveSnapshot stores the snapshot, or marks the stored one with the same
+// token as issued again.
+func (a *addressBookRepository) SaveSnapshot(snapshot models.AddressBookSnapshot) error {
+	result := a.db.Model(&models.AddressBookSnapshot{}).Where("list_uuid = ? AND token = ?", snapshot.ListUUID, snapshot.Token).
+		UpdateColumn("issued_at", snapshot.IssuedAt)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return err
+		}
+		members := make([]models.AddressBookSnapshotMember, 0, len(snapshot.Members))
+		for contactUUID, version := range snapshot.Members {
+			members = append(members, models.AddressBookSnapshotMember{SnapshotID: snapshot.ID, ContactUUID: contactUUID, Version: version})
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&members, 500).Error
+	})
+	if err != nil {
+		// Another request may have stored the same state in the meantime.
+		if _, getErr := a.GetSnapshot(snapshot.ListUUID, snapshot.Token); getErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+func (a *addressBookRepository) GetSnapshot(listUUID uuid.UUID, token string) (*models.AddressBookSnapshot, error) {
+	var snapshot models.AddressBookSnapshot
+	if err := a.db.Where("list_uuid = ? AND token = ?", listUUID, token).First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	var members []models.AddressBookSnapshotMember
+	if err := a.db.Where("snapshot_id = ?", snapshot.ID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	snapshot.Members = make(map[uuid.UUID]uint, len(members))
+	for _, member := range members {
+		snapshot.Members[member.ContactUUID] = member.Version
+	}
+	return &snapshot, nil
+}
+
+// PruneSnapshots deletes all but the keep most recently issued snapshots of
+// the list, along with their members.
+func (a *addressBookRepository) PruneSnapshots(listUUID uuid.UUID, keep int) error {
+	var oldest []models.AddressBookSnapshot
+	err := a.db.Select("id", "issued_at").Where("list_uuid = ?", listUUID).
+		Order("issued_at DESC").Order("id DESC").Offset(keep - 1).Limit(1).Find(&oldest).Error
+	if err != nil || len(oldest) == 0 {
+		return err
+	}
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		var pruned []uint
+		err := tx.Model(&models.AddressBookSnapshot{}).
+			Where("list_uuid = ? AND (issued_at < ? OR (issued_at = ? AND id < ?))", listUUID, oldest[0].IssuedAt, oldest[0].IssuedAt, oldest[0].ID).
+			Pluck("id", &pruned).Error
+		if err != nil || len(pruned) == 0 {
+			return err
+		}
+		if err := tx.Where("snapshot_id IN ?", pruned).Delete(&models.AddressBookSnapshotMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", pruned).Delete(&models.AddressBookSnapshot{}).Error
+	})
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// ContactOperation is one step of a batch. Create and update take the whole
+// contact, delete only uses its UUID and Version.
+type ContactOperation struct {
+	Op      string
+	Contact models.Contact
+}
+
+// Batch applies the operations in order and returns the error of each one.
+// Without atomic every operation commits on its own. With atomic they share one
+// transaction, which is rolled back at the first failure; every other
+// operation then reports ErrRolledBack.
+func (c *contactRepository) Batch(operations []ContactOperation, atomic bool) ([]error, error) {
+	errs := make([]error, len(operations))
+	if !atomic {
+		for i, operation := range operations {
+			errs[i] = c.apply(operation)
+		}
+		return errs, nil
+	}
+
+	failed := -1
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		repo := &contactRepository{db: tx}
+		for i, operation := range operations {
+			if errs[i] = repo.apply(operation); errs[i] != nil {
+				failed = i
+				return errs[i]
+			}
+		}
+		return nil
+	})
+	if failed < 0 && err != nil {
+		return nil, err
+	}
+	if failed >= 0 {
+		rollBack(errs, failed)
+	}
+	return errs, nil
+}
+
+func (c *contactRepository) apply(operation ContactOperation) error {
+	switch operation.Op {
+	case BatchCreate:
+		return c.Create(operation.Contact)
+	case BatchUpdate:
+		return c.Update(operation.Contact)
+	case BatchDelete:
+		return c.Delete(operation.Contact.UUID, operation.Contact.Version)
+	}
+	return fmt.Errorf("unknown batch operation %q", operation.Op)
+}
+
+// GetByUUIDs returns the live contacts with the given UUIDs, without their
+// lists. UUIDs without a contact are left out.
+func (c *contactRepository) GetByUUIDs(uuids []uuid.UUID) (map[uuid.UUID]models.Contact, error) {
+	found := make(map[uuid.UUID]models.Contact)
+	if len(uuids) == 0 {
+		return found, nil
+	}
+	var contacts []models.Contact
+	if err := c.db.Where("uuid IN ?", uuids).Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	for _, contact := range contacts {
+		found[contact.UUID] = contact
+	}
+	return found, nil
+}
+
+// FindByEmails is FindByEmail for many emails in one query. The contacts are
+// keyed by the email as it was given.
+func (c *contactRepository) FindByEmails(emails []string) (map[string]models.Contact, error) {
+	return c.findByColumn("email", emails, normalizeEmail, func(contact models.Contact) string { return contact.Email })
+}
+
+// FindByMobiles is FindByMobile for many mobiles in one query. The contacts are
+// keyed by the mobile as it was given.
+func (c *contactRepository) FindByMobiles(mobiles []string) (map[string]models.Contact, error) {
+	return c.findByColumn("mobile", mobiles, normalizeMobile, func(contact models.Contact) string { return contact.Mobile })
+}
+
+func (c *contactRepository) findByColumn(column string, values []string, normalize func(string) string, value func(models.Contact) string) (map[string]models.Contact, error) {
+	found := make(map[string]models.Contact)
+	if len(values) == 0 {
+		return found, nil
+	}
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = normalize(v)
+	}
+	var contacts []models.Contact
+	if err := c.db.Unscoped().Where(column+" IN ?", normalized).Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	byValue := make(map[string]models.Contact, len(contacts))
+	for _, contact := range contacts {
+		byValue[normalize(value(contact))] = contact
+	}
+	for i, v := range values {
+		if contact, ok := byValue[normalized[i]]; ok {
+			found[v] = contact
+		}
+	}
+	return found, nil
+}
+
+// GetListIDs is GetListID for many lists in one query. UUIDs of lists that do
+// not exist are left out.
+func (c *contactRepository) GetListIDs(listUUIDs []uuid.UUID) (map[uuid.UUID]uint, error) {
+	ids := make(map[uuid.UUID]uint)
+	if len(listUUIDs) == 0 {
+		return ids, nil
+	}
+	var lists []models.List
+	if err := c.db.Select("id", "uuid").Where("uuid IN ?", listUUIDs).Find(&lists).Error; err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		ids[list.UUID] = list.ID
+	}
+	return ids, nil
+}
+
+// rollBack marks every operation but the failed one as rolled back.
+func rollBack(errs []error, failed int) {
+	for i := range errs {
+		if i != failed {
+			errs[i] = ErrRolledBack
+		}
+	}
+}
@@ -2,20 +2,41 @@ package repositories
 
 import (
 	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ContactRepository interface {
-	GetAll(name string, mobile string, email string, limit, offset int) ([]models.Contact, error)
+	GetAll(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, limit, offset int) ([]models.Contact, int64, error)
+	GetAllByList(listID uint, name string, mobile string, email string, where filter.Node, sort []SortField, limit, offset int) ([]models.Contact, int64, error)
+	GetAllAfter(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.Contact, error)
+	Search(terms []string, limit, offset int) ([]models.Contact, int64, error)
+	Export(listID uint, name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, fn func(models.Contact) error) error
 	GetByUUID(uuid uuid.UUID) (*models.Contact, error)
-	ListExists(listID uint) (bool, error)
+	FindByEmail(email string) (*models.Contact, error)
+	FindByMobile(mobile string) (*models.Contact, error)
+	GetListID(listUUID uuid.UUID) (uint, error)
+	AddToList(contactID, listID uint, source string) error
+	RemoveFromList(contactID, listID uint) error
 	Create(contact models.Contact) error
 	Update(contact models.Contact) error
-	Delete(uuid uuid.UUID) error
+	Delete(uuid uuid.UUID, version uint) error
+	Restore(uuid uuid.UUID) error
+	Merge(merge models.ContactMerge) error
+	GetMerges(contactUUID uuid.UUID) ([]models.ContactMerge, error)
+	Batch(operations []ContactOperation, atomic bool) ([]error, error)
+	GetByUUIDs(uuids []uuid.UUID) (map[uuid.UUID]models.Contact, error)
+	FindByEmails(emails []string) (map[string]models.Contact, error)
+	FindByMobiles(mobiles []string) (map[string]models.Contact, error)
+	GetListIDs(listUUIDs []uuid.UUID) (map[uuid.UUID]uint, error)
+	DuplicateBlocks(kinds []string, maxSize int) ([][]models.Contact, error)
+	DuplicateBlocksOf(contactID uint, kinds []string, maxSize int) ([][]models.Contact, error)
 }
 
 type contactRepository struct {
@@ -25,18 +46,58 @@ type contactRepository struct {
 func NewContactRepository(db *gorm.DB) ContactRepository {
 	return &contactRepository{db: db}
 }
-func (c *contactRepository) GetAll(name string, mobile string, email string, limit, offset int) ([]models.Contact, error) {
+func (c *contactRepository) GetAll(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, limit, offset int) ([]models.Contact, int64, error) {
+	query := c.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	return c.find(query, name, mobile, email, where, sort, limit, offset)
+}
+func (c *contactRepository) GetAllByList(listID uint, name string, mobile string, email string, where filter.Node, sort []SortField, limit, offset int) ([]models.Contact, int64, error) {
+	members := c.db.Model(&models.ListMembership{}).Select("contact_id").Where("list_id = ?", listID)
+	return c.find(c.db.Where("id IN (?)", members), name, mobile, email, where, sort, limit, offset)
+}
+
+// GetAllAfter pages from the position of a row instead of OFFSET, so it stays
+// fast deep into large tables and does not skip or repeat rows inserted during
+// a scan.
+func (c *contactRepository) GetAllAfter(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.Contact, error) {
+	if err := cursorRowExists(c.db, &models.Contact{}, after.ID); err != nil {
+		return nil, err
+	}
+	values, err := cursorValues(after, sort, contactSortValue)
+	if err != nil {
+		return nil, err
+	}
 	var contacts []models.Contact
 	query := c.db
-	if name != "" {
-		query = query.Where("first_name LIKE ? OR last_name LIKE ?", "%"+name+"%", "%"+name+"%")
+	if includeDeleted {
+		query = query.Unscoped()
 	}
-	if mobile != "" {
-		query = query.Where("mobile LIKE ?", "%"+mobile+"%")
+	query = orderBy(whereAfter(c.filter(query, name, mobile, email, where), sort, values), sort)
+	if limit > 0 {
+		query = query.Limit(limit)
 	}
-	if email != "" {
-		query = query.Where("email LIKE ?", "%"+email+"%")
+	if err := query.Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	if err := c.loadLists(contacts); err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// find returns one page of matching contacts together with the number of
+// contacts matching across all pages.
+func (c *contactRepository) find(query *gorm.DB, name string, mobile string, email string, where filter.Node, sort []SortField, limit, offset int) ([]models.Contact, int64, error) {
+	var contacts []models.Contact
+	query = c.filter(query, name, mobile, email, where).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Model(&models.Contact{}).Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
+	query = orderBy(query, sort)
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -46,10 +107,26 @@ func (c *contactRepository) GetAll(name string, mobile string, email string, lim
 	}
 
 	if err := query.Find(&contacts).Error; err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	if err := c.loadLists(contacts); err != nil {
+		return nil, 0, err
 	}
 
-	return contacts, nil
+	return contacts, total, nil
+}
+func (c *contactRepository) filter(query *gorm.DB, name string, mobile string, email string, where filter.Node) *gorm.DB {
+	like := likeOperator(c.db)
+	if name != "" {
+		query = query.Where("first_name "+like+" ? OR last_name "+like+" ?", "%"+name+"%", "%"+name+"%")
+	}
+	if mobile != "" {
+		query = query.Where("mobile "+like+" ?", "%"+mobile+"%")
+	}
+	if email != "" {
+		query = query.Where("email "+like+" ?", "%"+email+"%")
+	}
+	return whereContactFilter(query, where)
 }
 func (c *contactRepository) GetByUUID(uuid uuid.UUID) (*models.Contact, error) {
 
@@ -57,19 +134,81 @@ func (c *contactRepository) GetByUUID(uuid uuid.UUID) (*models.Contact, error) {
 	if err := c.db.Where("uuid =?", uuid).First(&contact).Error; err != nil {
 		return nil, err
 	}
+	contacts := []models.Contact{contact}
+	if err := c.loadLists(contacts); err != nil {
+		return nil, err
+	}
+	return &contacts[0], nil
+}
+
+// FindByEmail and FindByMobile also return deleted contacts, because those keep
+// their email and mobile until they are purged. Stored emails are lowercase, so
+// the email is compared as is and the lookup can use the unique index.
+func (c *contactRepository) FindByEmail(email string) (*models.Contact, error) {
+	var contact models.Contact
+	if err := c.db.Unscoped().Where("email = ?", normalizeEmail(email)).First(&contact).Error; err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+func (c *contactRepository) FindByMobile(mobile string) (*models.Contact, error) {
+	var contact models.Contact
+	if err := c.db.Unscoped().Where("mobile = ?", normalizeMobile(mobile)).First(&contact).Error; err != nil {
+		return nil, err
+	}
 	return &contact, nil
 }
-func (c *contactRepository) ListExists(listID uint) (bool, error) {
+func (c *contactRepository) GetListID(listUUID uuid.UUID) (uint, error) {
+	var list models.List
+	if err := c.db.Select("id").Where("uuid = ?", listUUID).First(&list).Error; err != nil {
+		return 0, err
+	}
+	return list.ID, nil
+}
+func (c *contactRepository) AddToList(contactID, listID uint, source string) error {
 	var count int64
-	if err := c.db.Model(&models.List{}).Where("id = ?", listID).Count(&count).Error; err != nil {
-		return false, err
+	if err := c.db.Model(&models.ListMembership{}).Where("contact_id = ? AND list_id = ?", contactID, listID).Count(&count).Error; err != nil {
+		return err
 	}
-	return count > 0, nil
+	if count > 0 {
+		return nil
+	}
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if err := addMemberships(tx, contactID, []uint{listID}, source); err != nil {
+			return err
+		}
+		return bumpVersion(tx.Model(&models.Contact{}).Where("id = ?", contactID), 0)
+	})
+}
+func (c *contactRepository) RemoveFromList(contactID, listID uint) error {
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("contact_id = ? AND list_id = ?", contactID, listID).Delete(&models.ListMembership{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return bumpVersion(tx.Model(&models.Contact{}).Where("id = ?", contactID), 0)
+	})
 }
 func (c *contactRepository) Create(contact models.Contact) error {
 
-	return c.db.Create(&contact).Error
+	contact.Version = 1
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("DeletedAt").Create(&contact).Error; err != nil {
+			return err
+		}
+		if err := saveDuplicateKeys(tx, contact); err != nil {
+			return err
+		}
+		return addMemberships(tx, contact.ID, contact.ListIDs, models.MembershipSourceAPI)
+	})
+	return translateContactConflict(err)
 }
+
+// Update replaces every field of the contact, so empty values clear fields.
+// Memberships are only replaced when ListIDs is not nil.
 func (c *contactRepository) Update(contact models.Contact) error {
 	var existingContact models.Contact
 	if err := c.db.Where("uuid = ?", contact.UUID).First(&existingContact).Error; err != nil {
@@ -79,24 +218,122 @@ func (c *contactRepository) Update(contact models.Contact) error {
 		return err
 	}
 
-	if err := c.db.Model(&models.Contact{}).Where("uuid = ?", contact.UUID).Updates(contact).Error; err != nil {
-		return err
-	}
-	return nil
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx.Model(&models.Contact{}).Where("uuid = ?", contact.UUID), contact.Version); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Contact{}).Where("uuid = ?", contact.UUID).Select("FirstName", "LastName", "Mobile", "Email", "CountryCode").Updates(contact).Error; err != nil {
+			return err
+		}
+		contact.ID = existingContact.ID
+		if err := saveDuplicateKeys(tx, contact); err != nil {
+			return err
+		}
+		if contact.ListIDs == nil {
+			return nil
+		}
+		if err := tx.Where("contact_id = ?", existingContact.ID).Delete(&models.ListMembership{}).Error; err != nil {
+			return err
+		}
+		return addMemberships(tx, existingContact.ID, contact.ListIDs, models.MembershipSourceAPI)
+	})
+	return translateContactConflict(err)
 }
-func (c *contactRepository) Delete(uuid uuid.UUID) error {
+func (c *contactRepository) Delete(uuid uuid.UUID, version uint) error {
 
 	var contact models.Contact
 	result := c.db.Where("uuid=?", uuid).First(&contact)
 	if result.Error != nil {
 		return result.Error
 	}
-	result = c.db.Delete(&contact)
+	if version > 0 && contact.Version != version {
+		return ErrVersionMismatch
+	}
+	// Memberships are kept so that a restored contact is back on its lists.
+	result = c.db.Where("version = ?", contact.Version).Delete(&contact)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return ErrNotFound
+		return ErrVersionMismatch
 	}
 	return nil
 }
+func (c *contactRepository) Restore(uuid uuid.UUID) error {
+	var contact models.Contact
+	if err := c.db.Unscoped().Where("uuid = ?", uuid).First(&contact).Error; err != nil {
+		return err
+	}
+	if !contact.DeletedAt.Valid {
+		return nil
+	}
+	return c.db.Unscoped().Model(&contact).UpdateColumn("deleted_at", nil).Error
+}
+
+func (c *contactRepository) loadLists(contacts []models.Contact) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+	contactIDs := make([]uint, len(contacts))
+	for i, contact := range contacts {
+		contactIDs[i] = contact.ID
+	}
+	var memberships []struct {
+		ContactID uint
+		ListID    uint
+		ListUUID  uuid.UUID
+	}
+	err := c.db.Table("list_memberships").
+		Select("list_memberships.contact_id, list_memberships.list_id, lists.uuid AS list_uuid").
+		Joins("JOIN lists ON lists.id = list_memberships.list_id").
+		Where("list_memberships.contact_id IN ? AND lists.deleted_at IS NULL", contactIDs).
+		Order("list_memberships.id").
+		Scan(&memberships).Error
+	if err != nil {
+		return err
+	}
+	listIDs := make(map[uint][]uint)
+	listUUIDs := make(map[uint][]uuid.UUID)
+	for _, membership := range memberships {
+		listIDs[membership.ContactID] = append(listIDs[membership.ContactID], membership.ListID)
+		listUUIDs[membership.ContactID] = append(listUUIDs[membership.ContactID], membership.ListUUID)
+	}
+	for i := range contacts {
+		contacts[i].ListIDs = listIDs[contacts[i].ID]
+		contacts[i].ListUUIDs = listUUIDs[contacts[i].ID]
+	}
+	return nil
+}
+
+func addMemberships(tx *gorm.DB, contactID uint, listIDs []uint, source string) error {
+	if len(listIDs) == 0 {
+		return nil
+	}
+	memberships := make([]models.ListMembership, 0, len(listIDs))
+	seen := make(map[uint]bool)
+	now := time.Now()
+	for _, listID := range listIDs {
+		if seen[listID] {
+			continue
+		}
+		seen[listID] = true
+		memberships = append(memberships, models.ListMembership{ListID: listID, ContactID: contactID, AddedAt: now, Source: source})
+	}
+	return tx.Create(&memberships).Error
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizeMobile drops the separators people type into phone numbers, so
+// "+1 234-567" and "+1234567" are the same number.
+func normalizeMobile(mobile string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, mobile)
+}
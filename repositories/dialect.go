@@ -0,0 +1,12 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// LIKE is case-sensitive on PostgreSQL, while MySQL and SQLite compare
+// case-insensitively by default.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/duplicate"
+
+	"gorm.io/gorm"
+)
+
+// DuplicateBlocks returns the live contacts that share a block of one of the
+// given kinds, one slice per block in the order of the block. Blocks with a
+// single contact or more than maxSize contacts are left out, a block that big
+// says little about any of its pairs.
+func (c *contactRepository) DuplicateBlocks(kinds []string, maxSize int) ([][]models.Contact, error) {
+	return c.duplicateBlocks(c.liveDuplicateKeys(kinds), maxSize)
+}
+
+// DuplicateBlocksOf is DuplicateBlocks for the blocks of one contact only.
+func (c *contactRepository) DuplicateBlocksOf(contactID uint, kinds []string, maxSize int) ([][]models.Contact, error) {
+	keys := c.liveDuplicateKeys(kinds).
+		Joins("JOIN contact_duplicate_keys AS own ON own.kind = contact_duplicate_keys.kind AND own.value = contact_duplicate_keys.value AND own.contact_id = ?", contactID)
+	return c.duplicateBlocks(keys, maxSize)
+}
+
+func (c *contactRepository) liveDuplicateKeys(kinds []string) *gorm.DB {
+	return c.db.Table("contact_duplicate_keys").
+		Joins("JOIN contacts ON contacts.id = contact_duplicate_keys.contact_id AND contacts.deleted_at IS NULL").
+		Where("contact_duplicate_keys.kind IN ?", kinds)
+}
+
+func (c *contactRepository) duplicateBlocks(keys *gorm.DB, maxSize int) ([][]models.Contact, error) {
+	blocks := keys.Session(&gorm.Session{}).
+		Select("contact_duplicate_keys.kind, contact_duplicate_keys.value").
+		Group("contact_duplicate_keys.kind, contact_duplicate_keys.value").
+		Having("COUNT(*) BETWEEN 2 AND ?", maxSize)
+	var members []struct {
+		Kind      string
+		Value     string
+		ContactID uint
+	}
+	err := keys.Session(&gorm.Session{}).
+		Select("contact_duplicate_keys.kind, contact_duplicate_keys.value, contact_duplicate_keys.contact_id").
+		Joins("JOIN (?) AS blocks ON blocks.kind = contact_duplicate_keys.kind AND blocks.value = contact_duplicate_keys.value", blocks).
+		Order("contact_duplicate_keys.kind, contact_duplicate_keys.value, contact_duplicate_keys.contact_id").
+		Scan(&members).Error
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	var contacts []models.Contact
+	ids := keys.Session(&gorm.Session{}).Select("contact_duplicate_keys.contact_id").
+		Joins("JOIN (?) AS blocks ON blocks.kind = contact_duplicate_keys.kind AND blocks.value = contact_duplicate_keys.value", blocks)
+	if err := c.db.Where("id IN (?)", ids).Order("id").Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	if err := c.loadLists(contacts); err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Contact, len(contacts))
+	for _, contact := range contacts {
+		byID[contact.ID] = contact
+	}
+
+	var result [][]models.Contact
+	for i, member := range members {
+		if i == 0 || member.Kind != members[i-1].Kind || member.Value != members[i-1].Value {
+			result = append(result, nil)
+		}
+		result[len(result)-1] = append(result[len(result)-1], byID[member.ContactID])
+	}
+	return result, nil
+}
+
+// saveDuplicateKeys replaces the duplicate keys of the contact with the blocks
+// of its current fields.
+func saveDuplicateKeys(tx *gorm.DB, contact models.Contact) error {
+	if err := tx.Where("contact_id = ?", contact.ID).Delete(&models.ContactDuplicateKey{}).Error; err != nil {
+		return err
+	}
+	blocks := duplicate.NewKey(contact).Blocks()
+	if len(blocks) == 0 {
+		return nil
+	}
+	keys := make([]models.ContactDuplicateKey, len(blocks))
+	for i, block := range blocks {
+		keys[i] = models.ContactDuplicateKey{ContactID: contact.ID, Kind: block.Kind, Value: block.Value}
+	}
+	return tx.Create(&keys).Error
+}
@@ -1,7 +1,58 @@
 package repositories
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-    ErrNotFound = errors.New("item not found")
-)
\ No newline at end of file
+	ErrNotFound        = errors.New("item not found")
+	ErrListNotEmpty    = errors.New("list is not empty")
+	ErrVersionMismatch = errors.New("item was modified by someone else")
+	ErrConflict        = errors.New("item already exists")
+	ErrCursorNotFound  = errors.New("the row of the cursor no longer exists")
+	ErrInvalidCursor   = errors.New("the cursor does not match the sort order")
+)
+
+// ConflictError names the field whose unique constraint rejected a write. It
+// matches ErrConflict with errors.Is.
+type ConflictError struct {
+	Field string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return ErrConflict.Error()
+	}
+	return fmt.Sprintf("%s already exists", e.Field)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+var uniqueContactColumns = []string{"uuid", "email", "mobile"}
+
+// translateContactConflict turns the duplicate key errors of the supported
+// drivers into a ConflictError. SQLite names the column ("contacts.mobile"),
+// MySQL and PostgreSQL name the index ("idx_contacts_mobile").
+func translateContactConflict(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := strings.ToLower(err.Error())
+	if !strings.Contains(message, "unique constraint") && !strings.Contains(message, "duplicate entry") && !strings.Contains(message, "duplicate key") {
+		return err
+	}
+	for _, column := range uniqueContactColumns {
+		if strings.Contains(message, "contacts."+column) || strings.Contains(message, "idx_contacts_"+column) {
+			return &ConflictError{Field: column}
+		}
+	}
+	return &ConflictError{}
+}
+
+// ErrRolledBack is reported for the operations of an atomic batch that were
+// undone, or never tried, because another operation failed.
+var ErrRolledBack = errors.New("rolled back because another operation of the batch failed")
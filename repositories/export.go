@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
+)
+
+// exportBatchSize is how many contacts Export holds at a time, to load their
+// lists with one query per batch.
+const exportBatchSize = 500
+
+// Export passes every matching contact to fn in sort order, reading them
+// through a database cursor so the result is never held in memory as a whole.
+// A listID other than 0 exports only the members of that list. An error from
+// fn stops the export and is returned.
+func (c *contactRepository) Export(listID uint, name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, fn func(models.Contact) error) error {
+	query := c.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	if listID != 0 {
+		query = query.Where("id IN (?)", c.db.Model(&models.ListMembership{}).Select("contact_id").Where("list_id = ?", listID))
+	}
+	rows, err := orderBy(c.filter(query, name, mobile, email, where), sort).Model(&models.Contact{}).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]models.Contact, 0, exportBatchSize)
+	flush := func() error {
+		// The lists are read on another connection while the cursor is open.
+		if err := c.loadLists(batch); err != nil {
+			return err
+		}
+		for _, contact := range batch {
+			if err := fn(contact); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for rows.Next() {
+		var contact models.Contact
+		if err := c.db.ScanRows(rows, &contact); err != nil {
+			return err
+		}
+		if batch = append(batch, contact); len(batch) == exportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"contact-list-api-1/services/filter"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// likeEscaper escapes the LIKE wildcards in user input. "!" is used because
+// backslash is not an escape character by default in SQLite.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func whereContactFilter(query *gorm.DB, node filter.Node) *gorm.DB {
+	if node == nil {
+		return query
+	}
+	sql, args := contactFilterSQL(query, node)
+	return query.Where(sql, args...)
+}
+
+func contactFilterSQL(db *gorm.DB, node filter.Node) (string, []interface{}) {
+	switch node := node.(type) {
+	case filter.And:
+		left, leftArgs := contactFilterSQL(db, node.Left)
+		right, rightArgs := contactFilterSQL(db, node.Right)
+		return "(" + left + " AND " + right + ")", append(leftArgs, rightArgs...)
+	case filter.Or:
+		left, leftArgs := contactFilterSQL(db, node.Left)
+		right, rightArgs := contactFilterSQL(db, node.Right)
+		return "(" + left + " OR " + right + ")", append(leftArgs, rightArgs...)
+	case filter.Not:
+		operand, args := contactFilterSQL(db, node.Operand)
+		return "NOT " + operand, args
+	case filter.Comparison:
+		return comparisonSQL(db, node)
+	}
+	return "1 = 0", nil
+}
+
+func comparisonSQL(db *gorm.DB, comparison filter.Comparison) (string, []interface{}) {
+	if comparison.Kind == filter.KindList {
+		members := "id IN (SELECT m.contact_id FROM list_memberships m JOIN lists l ON l.id = m.list_id WHERE l.uuid = ? AND l.deleted_at IS NULL)"
+		if comparison.Op == filter.Ne {
+			return "NOT " + members, []interface{}{comparison.Value}
+		}
+		return members, []interface{}{comparison.Value}
+	}
+
+	column := clause.Column{Name: comparison.Field}
+	if value, ok := comparison.Value.(time.Time); ok {
+		// Match the zone gorm writes timestamps in, SQLite compares them as text.
+		comparison.Value = value.Local()
+	}
+	like := "? " + likeOperator(db) + " ? ESCAPE '!'"
+	switch comparison.Op {
+	case filter.Eq:
+		return "? = ?", []interface{}{column, comparison.Value}
+	case filter.Ne:
+		return "? <> ?", []interface{}{column, comparison.Value}
+	case filter.Contains:
+		return like, []interface{}{column, "%" + likeEscaper.Replace(comparison.Value.(string)) + "%"}
+	case filter.StartsWith:
+		return like, []interface{}{column, likeEscaper.Replace(comparison.Value.(string)) + "%"}
+	case filter.EndsWith:
+		return like, []interface{}{column, "%" + likeEscaper.Replace(comparison.Value.(string))}
+	case filter.Gt:
+		return "? > ?", []interface{}{column, comparison.Value}
+	case filter.Ge:
+		return "? >= ?", []interface{}{column, comparison.Value}
+	case filter.Lt:
+		return "? < ?", []interface{}{column, comparison.Value}
+	case filter.Le:
+		return "? <= ?", []interface{}{column, comparison.Value}
+	}
+	return "1 = 0", nil
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportRepository interface {
+	Create(contactImport models.ContactImport) error
+	UpdateCounts(contactImport models.ContactImport) error
+	AddRow(row models.ContactImportRow) error
+	GetByUUID(uuid uuid.UUID) (*models.ContactImport, error)
+	GetRows(importUUID uuid.UUID, afterID uint, limit int) ([]models.ContactImportRow, error)
+}
+
+type importRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+func (i *importRepository) Create(contactImport models.ContactImport) error {
+	return i.db.Create(&contactImport).Error
+}
+func (i *importRepository) UpdateCounts(contactImport models.ContactImport) error {
+	return i.db.Model(&models.ContactImport{}).Where("uuid = ?", contactImport.UUID).
+		Select("Created", "Linked", "Skipped", "Failed").Updates(contactImport).Error
+}
+func (i *importRepository) AddRow(row models.ContactImportRow) error {
+	return i.db.Create(&row).Error
+}
+func (i *importRepository) GetByUUID(uuid uuid.UUID) (*models.ContactImport, error) {
+	var contactImport models.ContactImport
+	if err := i.db.Where("uuid = ?", uuid).First(&contactImport).Error; err != nil {
+		return nil, err
+	}
+	return &contactImport, nil
+}
+
+// GetRows pages through the rows of an import by ID, so a large error report
+// can be read without loading it at once.
+func (i *importRepository) GetRows(importUUID uuid.UUID, afterID uint, limit int) ([]models.ContactImportRow, error) {
+	rows := make([]models.ContactImportRow, 0)
+	query := i.db.Where("import_uuid = ? AND id > ?", importUUID, afterID).Order("id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
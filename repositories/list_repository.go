@@ -4,17 +4,20 @@ import (
 	"contact-list-api-1/models"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ListRepository interface {
-	GetAll(name string, limit, offset int) ([]models.List, error)
+	GetAll(name string, includeDeleted bool, sort []SortField, limit, offset int) ([]models.List, int64, error)
+	GetAllAfter(name string, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.List, error)
 	GetByUUID(uuid uuid.UUID) (*models.List, error)
 	Create(list models.List) error
 	Update(list models.List) error
-	Delete(uuid uuid.UUID) error
+	Delete(uuid uuid.UUID, cascade bool, version uint) error
+	Restore(uuid uuid.UUID) error
 }
 
 type listRepository struct {
@@ -25,14 +28,23 @@ func NewListRepository(db *gorm.DB) ListRepository {
 	return &listRepository{db: db}
 }
 
-func (l *listRepository) GetAll(name string, limit, offset int) ([]models.List, error) {
+func (l *listRepository) GetAll(name string, includeDeleted bool, sort []SortField, limit, offset int) ([]models.List, int64, error) {
 	var lists []models.List
 	query := l.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
 
 	if name != "" {
-		query = query.Where("name LIKE ?", "%"+name+"%")
+		query = query.Where("name "+likeOperator(l.db)+" ?", "%"+name+"%")
 	}
+	query = query.Session(&gorm.Session{})
 
+	var total int64
+	if err := query.Model(&models.List{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = orderBy(query, sort)
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -42,9 +54,37 @@ func (l *listRepository) GetAll(name string, limit, offset int) ([]models.List,
 	}
 
 	if err := query.Find(&lists).Error; err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	return lists, total, nil
+}
+
+// GetAllAfter pages from the position of a row instead of OFFSET, see
+// contactRepository.GetAllAfter.
+func (l *listRepository) GetAllAfter(name string, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.List, error) {
+	if err := cursorRowExists(l.db, &models.List{}, after.ID); err != nil {
+		return nil, err
+	}
+	values, err := cursorValues(after, sort, listSortValue)
+	if err != nil {
+		return nil, err
+	}
+	var lists []models.List
+	query := l.db
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	if name != "" {
+		query = query.Where("name "+likeOperator(l.db)+" ?", "%"+name+"%")
+	}
+	query = orderBy(whereAfter(query, sort, values), sort)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&lists).Error; err != nil {
+		return nil, err
+	}
 	return lists, nil
 }
 func (l *listRepository) GetByUUID(uuid uuid.UUID) (*models.List, error) {
@@ -58,8 +98,11 @@ func (l *listRepository) GetByUUID(uuid uuid.UUID) (*models.List, error) {
 
 func (l *listRepository) Create(list models.List) error {
 
-	return l.db.Create(&list).Error
+	list.Version = 1
+	return l.db.Omit("DeletedAt").Create(&list).Error
 }
+
+// Update replaces every field of the list, so an empty name is stored as is.
 func (l *listRepository) Update(list models.List) error {
 	var existingList models.List
 	if err := l.db.Where("uuid = ?", list.UUID).First(&existingList).Error; err != nil {
@@ -69,26 +112,70 @@ func (l *listRepository) Update(list models.List) error {
 		return err
 	}
 
-	if err := l.db.Model(&models.List{}).Where("uuid = ?", list.UUID).Updates(list).Error; err != nil {
-		return err
-	}
-	return nil
-
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx.Model(&models.List{}).Where("uuid = ?", list.UUID), list.Version); err != nil {
+			return err
+		}
+		return tx.Model(&models.List{}).Where("uuid = ?", list.UUID).Select("Name").Updates(list).Error
+	})
 }
-func (l *listRepository) Delete(uuid uuid.UUID) error {
 
+// Delete soft-deletes the list together with the contacts that belong to no
+// other list, all in one transaction. Without cascade a list that still has
+// contacts is left alone and ErrListNotEmpty is returned.
+func (l *listRepository) Delete(uuid uuid.UUID, cascade bool, version uint) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		var list models.List
+		if err := tx.Where("uuid = ?", uuid).First(&list).Error; err != nil {
+			return err
+		}
+		if version > 0 && list.Version != version {
+			return ErrVersionMismatch
+		}
+		members := tx.Model(&models.ListMembership{}).Select("contact_id").Where("list_id = ?", list.ID)
+		var contactIDs []uint
+		if err := tx.Model(&models.Contact{}).Where("id IN (?)", members).Pluck("id", &contactIDs).Error; err != nil {
+			return fmt.Errorf("finding contacts of list %v: %w", uuid, err)
+		}
+		if len(contactIDs) > 0 && !cascade {
+			return ErrListNotEmpty
+		}
+		// Memberships are kept and contacts share the list's deletion time so
+		// that Restore can bring back exactly the contacts removed here.
+		now := time.Now()
+		if len(contactIDs) > 0 {
+			remainingMembers := tx.Model(&models.ListMembership{}).Select("list_memberships.contact_id").
+				Joins("JOIN lists ON lists.id = list_memberships.list_id").
+				Where("lists.deleted_at IS NULL AND lists.id <> ?", list.ID)
+			if err := tx.Model(&models.Contact{}).Where("id IN ? AND id NOT IN (?)", contactIDs, remainingMembers).UpdateColumn("deleted_at", now).Error; err != nil {
+				return fmt.Errorf("deleting contacts of list %v: %w", uuid, err)
+			}
+		}
+		result := tx.Model(&list).Where("version = ?", list.Version).UpdateColumn("deleted_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("deleting list %v: %w", uuid, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionMismatch
+		}
+		return nil
+	})
+}
+func (l *listRepository) Restore(uuid uuid.UUID) error {
 	var list models.List
-	result := l.db.Where("uuid = ?", uuid).First(&list)
-	if result.Error != nil {
-		return result.Error
-	}
-	l.db.Where("list_id = ?", list.ID).Delete(&models.Contact{})
-	result = l.db.Delete(&list)
-	if result.Error != nil {
-		return result.Error
+	if err := l.db.Unscoped().Where("uuid = ?", uuid).First(&list).Error; err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	if !list.DeletedAt.Valid {
+		return nil
 	}
-	return nil
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		members := tx.Model(&models.ListMembership{}).Select("contact_id").Where("list_id = ?", list.ID)
+		deletedAt := tx.Unscoped().Model(&models.List{}).Select("deleted_at").Where("id = ?", list.ID)
+		err := tx.Unscoped().Model(&models.Contact{}).Where("id IN (?) AND deleted_at = (?)", members, deletedAt).UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&list).UpdateColumn("deleted_at", nil).Error
+	})
 }
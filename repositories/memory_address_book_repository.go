@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memoryAddressBookRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAddressBookRepository(store *MemoryStore) AddressBookRepository {
+	return &memoryAddressBookRepository{store: store}
+}
+func (a *memoryAddressBookRepository) GetMembers(listID uint, contactUUIDs []uuid.UUID) ([]models.Contact, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	members := make(map[uint]bool)
+	for _, membership := range a.store.memberships {
+		if membership.ListID == listID {
+			members[membership.ContactID] = true
+		}
+	}
+	contacts := make([]models.Contact, 0)
+	for _, contact := range a.store.contacts {
+		if !members[contact.ID] || contact.DeletedAt.Valid {
+			continue
+		}
+		if contactUUIDs != nil && !slices.Contains(contactUUIDs, contact.UUID) {
+			continue
+		}
+		contact.ListIDs = nil
+		contact.ListUUIDs = nil
+		contacts = append(contacts, contact)
+	}
+	return contacts, nil
+}
+func (a *memoryAddressBookRepository) SaveSnapshot(snapshot models.AddressBookSnapshot) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	for i := range a.store.snapshots {
+		if existing := &a.store.snapshots[i]; existing.ListUUID == snapshot.ListUUID && existing.Token == snapshot.Token {
+			existing.IssuedAt = snapshot.IssuedAt
+			return nil
+		}
+	}
+	a.store.nextSnapshotID++
+	snapshot.ID = a.store.nextSnapshotID
+	snapshot.Members = maps.Clone(snapshot.Members)
+	a.store.snapshots = append(a.store.snapshots, snapshot)
+	return nil
+}
+func (a *memoryAddressBookRepository) GetSnapshot(listUUID uuid.UUID, token string) (*models.AddressBookSnapshot, error) {
+	a.store.mu.RLock()
+	defer a.store.mu.RUnlock()
+
+	for _, snapshot := range a.store.snapshots {
+		if snapshot.ListUUID == listUUID && snapshot.Token == token {
+			snapshot.Members = maps.Clone(snapshot.Members)
+			return &snapshot, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (a *memoryAddressBookRepository) PruneSnapshots(listUUID uuid.UUID, keep int) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	var issued []models.AddressBookSnapshot
+	for _, snapshot := range a.store.snapshots {
+		if snapshot.ListUUID == listUUID {
+			issued = append(issued, snapshot)
+		}
+	}
+	if len(issued) <= keep {
+		return nil
+	}
+	slices.SortFunc(issued, func(x, y models.AddressBookSnapshot) int {
+		if c := y.IssuedAt.Compare(x.IssuedAt); c != 0 {
+			return c
+		}
+		return int(y.ID) - int(x.ID)
+	})
+	kept := make(map[uint]bool, keep)
+	for _, snapshot := range issued[:keep] {
+		kept[snapshot.ID] = true
+	}
+	a.store.snapshots = slices.DeleteFunc(a.store.snapshots, func(snapshot models.AddressBookSnapshot) bool {
+		return snapshot.ListUUID == listUUID && !kept[snapshot.ID]
+	})
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Batch holds the store lock for the whole batch. An atomic batch works on a
+// copy of the contacts and memberships that is dropped at the first failure.
+func (c *memoryContactRepository) Batch(operations []ContactOperation, atomic bool) ([]error, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	contacts, memberships := slices.Clone(c.store.contacts), slices.Clone(c.store.memberships)
+	nextContactID, nextMembershipID := c.store.nextContactID, c.store.nextMembershipID
+	errs := make([]error, len(operations))
+	for i, operation := range operations {
+		errs[i] = c.apply(operation)
+		if errs[i] != nil && atomic {
+			c.store.contacts, c.store.memberships = contacts, memberships
+			c.store.nextContactID, c.store.nextMembershipID = nextContactID, nextMembershipID
+			rollBack(errs, i)
+			break
+		}
+	}
+	return errs, nil
+}
+
+func (c *memoryContactRepository) apply(operation ContactOperation) error {
+	switch operation.Op {
+	case BatchCreate:
+		return c.create(operation.Contact)
+	case BatchUpdate:
+		return c.update(operation.Contact)
+	case BatchDelete:
+		return c.delete(operation.Contact.UUID, operation.Contact.Version)
+	}
+	return fmt.Errorf("unknown batch operation %q", operation.Op)
+}
+
+func (c *memoryContactRepository) GetByUUIDs(uuids []uuid.UUID) (map[uuid.UUID]models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	found := make(map[uuid.UUID]models.Contact)
+	for _, contact := range c.store.contacts {
+		if !contact.DeletedAt.Valid && slices.Contains(uuids, contact.UUID) {
+			found[contact.UUID] = contact
+		}
+	}
+	return found, nil
+}
+func (c *memoryContactRepository) FindByEmails(emails []string) (map[string]models.Contact, error) {
+	found := make(map[string]models.Contact)
+	for _, email := range emails {
+		if contact, err := c.FindByEmail(email); err == nil {
+			found[email] = *contact
+		}
+	}
+	return found, nil
+}
+func (c *memoryContactRepository) FindByMobiles(mobiles []string) (map[string]models.Contact, error) {
+	found := make(map[string]models.Contact)
+	for _, mobile := range mobiles {
+		if contact, err := c.FindByMobile(mobile); err == nil {
+			found[mobile] = *contact
+		}
+	}
+	return found, nil
+}
+func (c *memoryContactRepository) GetListIDs(listUUIDs []uuid.UUID) (map[uuid.UUID]uint, error) {
+	ids := make(map[uuid.UUID]uint)
+	for _, listUUID := range listUUIDs {
+		if id, err := c.GetListID(listUUID); err == nil {
+			ids[listUUID] = id
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memoryContactRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryContactRepository(store *MemoryStore) ContactRepository {
+	return &memoryContactRepository{store: store}
+}
+
+func (c *memoryContactRepository) GetAll(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, limit, offset int) ([]models.Contact, int64, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	visible := func(contact models.Contact) bool { return includeDeleted || !contact.DeletedAt.Valid }
+	contacts, total := c.find(visible, name, mobile, email, where, sort, limit, offset)
+	return contacts, total, nil
+}
+func (c *memoryContactRepository) GetAllByList(listID uint, name string, mobile string, email string, where filter.Node, sort []SortField, limit, offset int) ([]models.Contact, int64, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	isMember := func(contact models.Contact) bool {
+		return !contact.DeletedAt.Valid && c.store.isMember(contact.ID, listID)
+	}
+	contacts, total := c.find(isMember, name, mobile, email, where, sort, limit, offset)
+	return contacts, total, nil
+}
+func (c *memoryContactRepository) GetAllAfter(name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	if after.ID > 0 && c.store.contactIndex(func(contact models.Contact) bool { return contact.ID == after.ID }) < 0 {
+		return nil, ErrCursorNotFound
+	}
+	values, err := cursorValues(after, sort, contactSortValue)
+	if err != nil {
+		return nil, err
+	}
+	match := func(contact models.Contact) bool {
+		if !includeDeleted && contact.DeletedAt.Valid {
+			return false
+		}
+		return values == nil || compareToCursor(contact, sort, values, contactSortValue) > 0
+	}
+	contacts, _ := c.find(match, name, mobile, email, where, sort, limit, 0)
+	return contacts, nil
+}
+func (c *memoryContactRepository) find(match func(models.Contact) bool, name string, mobile string, email string, where filter.Node, sort []SortField, limit, offset int) ([]models.Contact, int64) {
+	contacts := make([]models.Contact, 0)
+	for _, contact := range c.store.contacts {
+		if !match(contact) {
+			continue
+		}
+		if name != "" && !containsFold(contact.FirstName, name) && !containsFold(contact.LastName, name) {
+			continue
+		}
+		if mobile != "" && !containsFold(contact.Mobile, mobile) {
+			continue
+		}
+		if email != "" && !containsFold(contact.Email, email) {
+			continue
+		}
+		if !c.store.matchesFilter(contact, where) {
+			continue
+		}
+		contacts = append(contacts, c.store.withLists(contact))
+	}
+	slices.SortStableFunc(contacts, func(a, b models.Contact) int { return compareRows(a, b, sort, contactSortValue) })
+	start, end := paginate(len(contacts), limit, offset)
+	return contacts[start:end], int64(len(contacts))
+}
+func (c *memoryContactRepository) GetByUUID(uuid uuid.UUID) (*models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	i := c.store.contactIndex(func(contact models.Contact) bool { return contact.UUID == uuid && !contact.DeletedAt.Valid })
+	if i < 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	contact := c.store.withLists(c.store.contacts[i])
+	return &contact, nil
+}
+func (c *memoryContactRepository) FindByEmail(email string) (*models.Contact, error) {
+	return c.findOne(func(contact models.Contact) bool { return contact.Email == normalizeEmail(email) })
+}
+func (c *memoryContactRepository) FindByMobile(mobile string) (*models.Contact, error) {
+	return c.findOne(func(contact models.Contact) bool { return contact.Mobile == normalizeMobile(mobile) })
+}
+func (c *memoryContactRepository) findOne(match func(models.Contact) bool) (*models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	i := c.store.contactIndex(match)
+	if i < 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	contact := c.store.contacts[i]
+	return &contact, nil
+}
+func (c *memoryContactRepository) GetListID(listUUID uuid.UUID) (uint, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	i := c.store.listIndex(func(list models.List) bool { return list.UUID == listUUID && !list.DeletedAt.Valid })
+	if i < 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return c.store.lists[i].ID, nil
+}
+func (c *memoryContactRepository) AddToList(contactID, listID uint, source string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	if c.store.isMember(contactID, listID) {
+		return nil
+	}
+	c.addMemberships(contactID, []uint{listID}, source)
+	c.bumpVersion(contactID)
+	return nil
+}
+func (c *memoryContactRepository) RemoveFromList(contactID, listID uint) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	if !c.store.isMember(contactID, listID) {
+		return ErrNotFound
+	}
+	c.store.removeMemberships(func(m models.ListMembership) bool { return m.ContactID == contactID && m.ListID == listID })
+	c.bumpVersion(contactID)
+	return nil
+}
+func (c *memoryContactRepository) Create(contact models.Contact) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.create(contact)
+}
+func (c *memoryContactRepository) create(contact models.Contact) error {
+	if err := c.checkUnique(contact, 0); err != nil {
+		return err
+	}
+	c.store.nextContactID++
+	contact.ID = c.store.nextContactID
+	now := time.Now()
+	if contact.CreatedAt.IsZero() {
+		contact.CreatedAt = now
+	}
+	contact.UpdatedAt = now
+	contact.DeletedAt = gorm.DeletedAt{}
+	contact.Version = 1
+	listIDs := contact.ListIDs
+	contact.ListIDs = nil
+	contact.ListUUIDs = nil
+	c.store.contacts = append(c.store.contacts, contact)
+	c.addMemberships(contact.ID, listIDs, models.MembershipSourceAPI)
+	return nil
+}
+func (c *memoryContactRepository) Update(contact models.Contact) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.update(contact)
+}
+func (c *memoryContactRepository) update(contact models.Contact) error {
+	i := c.store.contactIndex(func(existing models.Contact) bool { return existing.UUID == contact.UUID && !existing.DeletedAt.Valid })
+	if i < 0 {
+		return fmt.Errorf("contact with UUID %v does not exist", contact.UUID)
+	}
+	existing := &c.store.contacts[i]
+	if contact.Version > 0 && contact.Version != existing.Version {
+		return ErrVersionMismatch
+	}
+	if err := c.checkUnique(contact, existing.ID); err != nil {
+		return err
+	}
+	existing.FirstName = contact.FirstName
+	existing.LastName = contact.LastName
+	existing.Mobile = contact.Mobile
+	existing.Email = contact.Email
+	existing.CountryCode = contact.CountryCode
+	existing.UpdatedAt = time.Now()
+	existing.Version++
+	if contact.ListIDs != nil {
+		contactID := existing.ID
+		c.store.removeMemberships(func(m models.ListMembership) bool { return m.ContactID == contactID })
+		c.addMemberships(contactID, contact.ListIDs, models.MembershipSourceAPI)
+	}
+	return nil
+}
+func (c *memoryContactRepository) Delete(uuid uuid.UUID, version uint) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	return c.delete(uuid, version)
+}
+func (c *memoryContactRepository) delete(uuid uuid.UUID, version uint) error {
+	i := c.store.contactIndex(func(contact models.Contact) bool { return contact.UUID == uuid && !contact.DeletedAt.Valid })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if version > 0 && c.store.contacts[i].Version != version {
+		return ErrVersionMismatch
+	}
+	c.store.contacts[i].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+func (c *memoryContactRepository) Restore(uuid uuid.UUID) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	i := c.store.contactIndex(func(contact models.Contact) bool { return contact.UUID == uuid })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	c.store.contacts[i].DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (c *memoryContactRepository) bumpVersion(contactID uint) {
+	if i := c.store.contactIndex(func(contact models.Contact) bool { return contact.ID == contactID }); i >= 0 {
+		c.store.contacts[i].Version++
+	}
+}
+
+func (c *memoryContactRepository) checkUnique(contact models.Contact, ownID uint) error {
+	for _, existing := range c.store.contacts {
+		if existing.ID == ownID {
+			continue
+		}
+		if existing.UUID == contact.UUID {
+			return &ConflictError{Field: "uuid"}
+		}
+		if contact.Email != "" && existing.Email == contact.Email {
+			return &ConflictError{Field: "email"}
+		}
+		if contact.Mobile != "" && existing.Mobile == contact.Mobile {
+			return &ConflictError{Field: "mobile"}
+		}
+	}
+	return nil
+}
+
+func (c *memoryContactRepository) addMemberships(contactID uint, listIDs []uint, source string) {
+	now := time.Now()
+	for _, listID := range listIDs {
+		if c.store.isMember(contactID, listID) {
+			continue
+		}
+		c.store.nextMembershipID++
+		c.store.memberships = append(c.store.memberships, models.ListMembership{
+			ID:        c.store.nextMembershipID,
+			ListID:    listID,
+			ContactID: contactID,
+			AddedAt:   now,
+			Source:    source,
+		})
+	}
+}
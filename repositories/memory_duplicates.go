@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"cmp"
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/duplicate"
+	"slices"
+)
+
+// The memory store keeps no duplicate keys, the blocks are computed from the
+// contacts on every call.
+func (c *memoryContactRepository) DuplicateBlocks(kinds []string, maxSize int) ([][]models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	return c.duplicateBlocks(kinds, maxSize, func(duplicate.Block) bool { return true }), nil
+}
+
+func (c *memoryContactRepository) DuplicateBlocksOf(contactID uint, kinds []string, maxSize int) ([][]models.Contact, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	i := c.store.contactIndex(func(contact models.Contact) bool { return contact.ID == contactID })
+	if i < 0 {
+		return nil, nil
+	}
+	own := duplicate.NewKey(c.store.contacts[i]).Blocks()
+	return c.duplicateBlocks(kinds, maxSize, func(block duplicate.Block) bool { return slices.Contains(own, block) }), nil
+}
+
+func (c *memoryContactRepository) duplicateBlocks(kinds []string, maxSize int, match func(duplicate.Block) bool) [][]models.Contact {
+	members := make(map[duplicate.Block][]models.Contact)
+	for _, contact := range c.store.contacts {
+		if contact.DeletedAt.Valid {
+			continue
+		}
+		for _, block := range duplicate.NewKey(contact).Blocks() {
+			if slices.Contains(kinds, block.Kind) && match(block) {
+				members[block] = append(members[block], contact)
+			}
+		}
+	}
+	var blocks []duplicate.Block
+	for block, contacts := range members {
+		if len(contacts) >= 2 && len(contacts) <= maxSize {
+			blocks = append(blocks, block)
+		}
+	}
+	slices.SortFunc(blocks, func(a, b duplicate.Block) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Value, b.Value))
+	})
+
+	var result [][]models.Contact
+	for _, block := range blocks {
+		contacts := make([]models.Contact, len(members[block]))
+		for i, contact := range members[block] {
+			contacts[i] = c.store.withLists(contact)
+		}
+		slices.SortFunc(contacts, func(a, b models.Contact) int { return cmp.Compare(a.ID, b.ID) })
+		result = append(result, contacts)
+	}
+	return result
+}
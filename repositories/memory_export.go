@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
+)
+
+// Export copies the matching contacts before calling fn, so a slow reader does
+// not hold the store lock.
+func (c *memoryContactRepository) Export(listID uint, name string, mobile string, email string, where filter.Node, includeDeleted bool, sort []SortField, fn func(models.Contact) error) error {
+	c.store.mu.RLock()
+	match := func(contact models.Contact) bool {
+		return (includeDeleted || !contact.DeletedAt.Valid) && (listID == 0 || c.store.isMember(contact.ID, listID))
+	}
+	contacts, _ := c.find(match, name, mobile, email, where, sort, 0, 0)
+	c.store.mu.RUnlock()
+
+	for _, contact := range contacts {
+		if err := fn(contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
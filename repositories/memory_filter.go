@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// matchesFilter evaluates a filter the way contactFilterSQL does in SQL.
+func (s *MemoryStore) matchesFilter(contact models.Contact, node filter.Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case filter.And:
+		return s.matchesFilter(contact, node.Left) && s.matchesFilter(contact, node.Right)
+	case filter.Or:
+		return s.matchesFilter(contact, node.Left) || s.matchesFilter(contact, node.Right)
+	case filter.Not:
+		return !s.matchesFilter(contact, node.Operand)
+	case filter.Comparison:
+		return s.matchesComparison(contact, node)
+	}
+	return false
+}
+
+func (s *MemoryStore) matchesComparison(contact models.Contact, comparison filter.Comparison) bool {
+	switch value := comparison.Value.(type) {
+	case uuid.UUID:
+		i := s.listIndex(func(list models.List) bool { return list.UUID == value && !list.DeletedAt.Valid })
+		isMember := i >= 0 && s.isMember(contact.ID, s.lists[i].ID)
+		return isMember == (comparison.Op == filter.Eq)
+	case time.Time:
+		result := contactSortValue(contact, comparison.Field).(time.Time).Compare(value)
+		switch comparison.Op {
+		case filter.Gt:
+			return result > 0
+		case filter.Ge:
+			return result >= 0
+		case filter.Lt:
+			return result < 0
+		case filter.Le:
+			return result <= 0
+		}
+	case string:
+		field := contactSortValue(contact, comparison.Field).(string)
+		switch comparison.Op {
+		case filter.Eq:
+			return field == value
+		case filter.Ne:
+			return field != value
+		case filter.Contains:
+			return containsFold(field, value)
+		case filter.StartsWith:
+			return strings.HasPrefix(strings.ToLower(field), strings.ToLower(value))
+		case filter.EndsWith:
+			return strings.HasSuffix(strings.ToLower(field), strings.ToLower(value))
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memoryImportRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryImportRepository(store *MemoryStore) ImportRepository {
+	return &memoryImportRepository{store: store}
+}
+func (i *memoryImportRepository) Create(contactImport models.ContactImport) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	i.store.nextImportID++
+	contactImport.ID = i.store.nextImportID
+	i.store.imports = append(i.store.imports, contactImport)
+	return nil
+}
+func (i *memoryImportRepository) UpdateCounts(contactImport models.ContactImport) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	for j := range i.store.imports {
+		if existing := &i.store.imports[j]; existing.UUID == contactImport.UUID {
+			existing.Created, existing.Linked, existing.Skipped, existing.Failed = contactImport.Created, contactImport.Linked, contactImport.Skipped, contactImport.Failed
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (i *memoryImportRepository) AddRow(row models.ContactImportRow) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	i.store.nextImportRowID++
+	row.ID = i.store.nextImportRowID
+	i.store.importRows = append(i.store.importRows, row)
+	return nil
+}
+func (i *memoryImportRepository) GetByUUID(uuid uuid.UUID) (*models.ContactImport, error) {
+	i.store.mu.RLock()
+	defer i.store.mu.RUnlock()
+
+	for _, contactImport := range i.store.imports {
+		if contactImport.UUID == uuid {
+			return &contactImport, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (i *memoryImportRepository) GetRows(importUUID uuid.UUID, afterID uint, limit int) ([]models.ContactImportRow, error) {
+	i.store.mu.RLock()
+	defer i.store.mu.RUnlock()
+
+	rows := make([]models.ContactImportRow, 0)
+	for _, row := range i.store.importRows {
+		if limit > 0 && len(rows) == limit {
+			break
+		}
+		if row.ImportUUID == importUUID && row.ID > afterID {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memoryListRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryListRepository(store *MemoryStore) ListRepository {
+	return &memoryListRepository{store: store}
+}
+
+func (l *memoryListRepository) GetAll(name string, includeDeleted bool, sort []SortField, limit, offset int) ([]models.List, int64, error) {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	visible := func(list models.List) bool { return includeDeleted || !list.DeletedAt.Valid }
+	lists := l.find(visible, name, sort)
+	start, end := paginate(len(lists), limit, offset)
+	return lists[start:end], int64(len(lists)), nil
+}
+func (l *memoryListRepository) GetAllAfter(name string, includeDeleted bool, sort []SortField, after Cursor, limit int) ([]models.List, error) {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	if after.ID > 0 && l.store.listIndex(func(list models.List) bool { return list.ID == after.ID }) < 0 {
+		return nil, ErrCursorNotFound
+	}
+	values, err := cursorValues(after, sort, listSortValue)
+	if err != nil {
+		return nil, err
+	}
+	match := func(list models.List) bool {
+		if !includeDeleted && list.DeletedAt.Valid {
+			return false
+		}
+		return values == nil || compareToCursor(list, sort, values, listSortValue) > 0
+	}
+	lists := l.find(match, name, sort)
+	start, end := paginate(len(lists), limit, 0)
+	return lists[start:end], nil
+}
+func (l *memoryListRepository) find(match func(models.List) bool, name string, sort []SortField) []models.List {
+	lists := make([]models.List, 0)
+	for _, list := range l.store.lists {
+		if !match(list) {
+			continue
+		}
+		if name != "" && !containsFold(list.Name, name) {
+			continue
+		}
+		lists = append(lists, list)
+	}
+	slices.SortStableFunc(lists, func(a, b models.List) int { return compareRows(a, b, sort, listSortValue) })
+	return lists
+}
+func (l *memoryListRepository) GetByUUID(uuid uuid.UUID) (*models.List, error) {
+	l.store.mu.RLock()
+	defer l.store.mu.RUnlock()
+
+	i := l.store.listIndex(func(list models.List) bool { return list.UUID == uuid && !list.DeletedAt.Valid })
+	if i < 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	list := l.store.lists[i]
+	return &list, nil
+}
+func (l *memoryListRepository) Create(list models.List) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	if l.store.listIndex(func(existing models.List) bool { return existing.UUID == list.UUID }) >= 0 {
+		return fmt.Errorf("list with UUID %v already exists", list.UUID)
+	}
+	l.store.nextListID++
+	list.ID = l.store.nextListID
+	now := time.Now()
+	if list.CreatedAt.IsZero() {
+		list.CreatedAt = now
+	}
+	list.UpdatedAt = now
+	list.DeletedAt = gorm.DeletedAt{}
+	list.Version = 1
+	l.store.lists = append(l.store.lists, list)
+	return nil
+}
+func (l *memoryListRepository) Update(list models.List) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	i := l.store.listIndex(func(existing models.List) bool { return existing.UUID == list.UUID && !existing.DeletedAt.Valid })
+	if i < 0 {
+		return fmt.Errorf("list with UUID %v does not exist", list.UUID)
+	}
+	if list.Version > 0 && list.Version != l.store.lists[i].Version {
+		return ErrVersionMismatch
+	}
+	l.store.lists[i].Name = list.Name
+	l.store.lists[i].UpdatedAt = time.Now()
+	l.store.lists[i].Version++
+	return nil
+}
+func (l *memoryListRepository) Delete(uuid uuid.UUID, cascade bool, version uint) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	i := l.store.listIndex(func(list models.List) bool { return list.UUID == uuid && !list.DeletedAt.Valid })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if version > 0 && l.store.lists[i].Version != version {
+		return ErrVersionMismatch
+	}
+	listID := l.store.lists[i].ID
+	isLiveMember := func(contact models.Contact) bool {
+		return !contact.DeletedAt.Valid && l.store.isMember(contact.ID, listID)
+	}
+	if !cascade && l.store.contactIndex(isLiveMember) >= 0 {
+		return ErrListNotEmpty
+	}
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	for j, contact := range l.store.contacts {
+		if !isLiveMember(contact) || l.store.isMemberOfOtherList(contact.ID, listID) {
+			continue
+		}
+		l.store.contacts[j].DeletedAt = deletedAt
+	}
+	l.store.lists[i].DeletedAt = deletedAt
+	return nil
+}
+func (l *memoryListRepository) Restore(uuid uuid.UUID) error {
+	l.store.mu.Lock()
+	defer l.store.mu.Unlock()
+
+	i := l.store.listIndex(func(list models.List) bool { return list.UUID == uuid })
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	list := &l.store.lists[i]
+	if !list.DeletedAt.Valid {
+		return nil
+	}
+	for j, contact := range l.store.contacts {
+		if contact.DeletedAt.Valid && contact.DeletedAt.Time.Equal(list.DeletedAt.Time) && l.store.isMember(contact.ID, list.ID) {
+			l.store.contacts[j].DeletedAt = gorm.DeletedAt{}
+		}
+	}
+	list.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
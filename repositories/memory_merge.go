@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (c *memoryContactRepository) Merge(merge models.ContactMerge) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	var found []models.Contact
+	for _, contactUUID := range append([]uuid.UUID{merge.PrimaryUUID}, merge.SecondaryUUIDs...) {
+		i := c.store.contactIndex(func(contact models.Contact) bool { return contact.UUID == contactUUID && !contact.DeletedAt.Valid })
+		if i < 0 || slices.ContainsFunc(found, func(contact models.Contact) bool { return contact.UUID == contactUUID }) {
+			return gorm.ErrRecordNotFound
+		}
+		found = append(found, c.store.withLists(c.store.contacts[i]))
+	}
+	contacts, merged, err := mergeContacts(found, merge)
+	if err != nil {
+		return err
+	}
+	primary, secondaries := contacts[0], contacts[1:]
+
+	var listIDs []uint
+	for _, membership := range c.store.memberships {
+		if slices.ContainsFunc(secondaries, func(secondary models.Contact) bool { return secondary.ID == membership.ContactID }) {
+			listIDs = append(listIDs, membership.ListID)
+		}
+	}
+	c.store.removeMemberships(func(m models.ListMembership) bool {
+		return slices.ContainsFunc(secondaries, func(secondary models.Contact) bool { return secondary.ID == m.ContactID })
+	})
+	c.addMemberships(primary.ID, listIDs, models.MembershipSourceMerge)
+	c.store.contacts = slices.DeleteFunc(c.store.contacts, func(contact models.Contact) bool {
+		return slices.ContainsFunc(secondaries, func(secondary models.Contact) bool { return secondary.ID == contact.ID })
+	})
+
+	i := c.store.contactIndex(func(contact models.Contact) bool { return contact.ID == primary.ID })
+	existing := &c.store.contacts[i]
+	existing.FirstName = merged.FirstName
+	existing.LastName = merged.LastName
+	existing.Mobile = merged.Mobile
+	existing.Email = merged.Email
+	existing.CountryCode = merged.CountryCode
+	existing.UpdatedAt = time.Now()
+	existing.Version++
+
+	c.store.nextMergeID++
+	merge.ID = c.store.nextMergeID
+	merge.Contacts = contacts
+	merge.MergedAt = time.Now()
+	c.store.merges = append(c.store.merges, merge)
+	return nil
+}
+func (c *memoryContactRepository) GetMerges(contactUUID uuid.UUID) ([]models.ContactMerge, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	merges := make([]models.ContactMerge, 0)
+	for _, merge := range c.store.merges {
+		if merge.PrimaryUUID == contactUUID {
+			merges = append(merges, merge)
+		}
+	}
+	return merges, nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"slices"
+	"strings"
+)
+
+func (c *memoryContactRepository) Search(terms []string, limit, offset int) ([]models.Contact, int64, error) {
+	c.store.mu.RLock()
+	defer c.store.mu.RUnlock()
+
+	type result struct {
+		contact models.Contact
+		score   int
+	}
+	var results []result
+	for _, contact := range c.store.contacts {
+		if contact.DeletedAt.Valid {
+			continue
+		}
+		contact = c.store.withLists(contact)
+		if score := c.store.searchScore(contact, terms); score > 0 {
+			results = append(results, result{contact, score})
+		}
+	}
+	slices.SortStableFunc(results, func(a, b result) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return int(a.contact.ID) - int(b.contact.ID)
+	})
+
+	start, end := paginate(len(results), limit, offset)
+	contacts := make([]models.Contact, 0, end-start)
+	for _, result := range results[start:end] {
+		contacts = append(contacts, result.contact)
+	}
+	return contacts, int64(len(results)), nil
+}
+
+// searchScore scores a contact the way likeSearch does in SQL, or returns 0
+// when a term matches none of its fields.
+func (s *MemoryStore) searchScore(contact models.Contact, terms []string) int {
+	fields := []string{contact.FirstName, contact.LastName, contact.Email, contact.Mobile}
+	for _, listID := range contact.ListIDs {
+		if i := s.listIndex(func(list models.List) bool { return list.ID == listID }); i >= 0 {
+			fields = append(fields, s.lists[i].Name)
+		}
+	}
+	total := 0
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		score := 0
+		for _, field := range fields {
+			field = strings.ToLower(field)
+			switch {
+			case field == term:
+				score = max(score, 3)
+			case strings.HasPrefix(field, term):
+				score = max(score, 2)
+			case strings.Contains(field, term):
+				score = max(score, 1)
+			}
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total
+}
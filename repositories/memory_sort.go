@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"contact-list-api-1/models"
+)
+
+func contactSortValue(contact models.Contact, column string) interface{} {
+	switch column {
+	case "first_name":
+		return contact.FirstName
+	case "last_name":
+		return contact.LastName
+	case "mobile":
+		return contact.Mobile
+	case "email":
+		return contact.Email
+	case "country_code":
+		return contact.CountryCode
+	case "created_at":
+		return contact.CreatedAt
+	case "updated_at":
+		return contact.UpdatedAt
+	}
+	return contact.ID
+}
+
+func listSortValue(list models.List, column string) interface{} {
+	switch column {
+	case "name":
+		return list.Name
+	case "created_at":
+		return list.CreatedAt
+	case "updated_at":
+		return list.UpdatedAt
+	}
+	return list.ID
+}
+
+// compareRows orders two rows the way orderBy does in SQL, including the id
+// tiebreaker.
+func compareRows[T any](a, b T, sort []SortField, value func(T, string) interface{}) int {
+	for _, field := range append(append([]SortField(nil), sort...), SortField{Column: "id"}) {
+		result := compareSortValues(value(a, field.Column), value(b, field.Column))
+		if field.Desc {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// compareToCursor is compareRows against the values of a cursor, as returned
+// by cursorValues.
+func compareToCursor[T any](row T, sort []SortField, after []interface{}, value func(T, string) interface{}) int {
+	for i, field := range append(append([]SortField(nil), sort...), SortField{Column: "id"}) {
+		result := compareSortValues(value(row, field.Column), after[i])
+		if field.Desc {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+func compareSortValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case uint:
+		return cmp.Compare(a, b.(uint))
+	}
+	return 0
+}
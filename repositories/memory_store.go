@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"strings"
+	"sync"
+)
+
+type MemoryStore struct {
+	mu               sync.RWMutex
+	lists            []models.List
+	contacts         []models.Contact
+	memberships      []models.ListMembership
+	merges           []models.ContactMerge
+	imports          []models.ContactImport
+	importRows       []models.ContactImportRow
+	snapshots        []models.AddressBookSnapshot
+	nextListID       uint
+	nextContactID    uint
+	nextMembershipID uint
+	nextMergeID      uint
+	nextImportID     uint
+	nextImportRowID  uint
+	nextSnapshotID   uint
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) listIndex(match func(models.List) bool) int {
+	for i, list := range s.lists {
+		if match(list) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) contactIndex(match func(models.Contact) bool) int {
+	for i, contact := range s.contacts {
+		if match(contact) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) withLists(contact models.Contact) models.Contact {
+	contact.ListIDs = nil
+	contact.ListUUIDs = nil
+	for _, membership := range s.memberships {
+		if membership.ContactID != contact.ID {
+			continue
+		}
+		if i := s.listIndex(func(l models.List) bool { return l.ID == membership.ListID && !l.DeletedAt.Valid }); i >= 0 {
+			contact.ListIDs = append(contact.ListIDs, membership.ListID)
+			contact.ListUUIDs = append(contact.ListUUIDs, s.lists[i].UUID)
+		}
+	}
+	return contact
+}
+
+func (s *MemoryStore) isMember(contactID, listID uint) bool {
+	for _, membership := range s.memberships {
+		if membership.ContactID == contactID && membership.ListID == listID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) isMemberOfOtherList(contactID, listID uint) bool {
+	for _, membership := range s.memberships {
+		if membership.ContactID != contactID || membership.ListID == listID {
+			continue
+		}
+		if s.listIndex(func(l models.List) bool { return l.ID == membership.ListID && !l.DeletedAt.Valid }) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) removeMemberships(match func(models.ListMembership) bool) {
+	memberships := s.memberships[:0]
+	for _, membership := range s.memberships {
+		if !match(membership) {
+			memberships = append(memberships, membership)
+		}
+	}
+	s.memberships = memberships
+}
+
+func containsFold(value, substr string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(substr))
+}
+
+func paginate(total, limit, offset int) (int, int) {
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return offset, end
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memoryTrashRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryTrashRepository(store *MemoryStore) TrashRepository {
+	return &memoryTrashRepository{store: store}
+}
+
+func (t *memoryTrashRepository) GetAll(limit, offset int) ([]models.TrashItem, error) {
+	t.store.mu.RLock()
+	defer t.store.mu.RUnlock()
+
+	items := make([]models.TrashItem, 0)
+	for _, list := range t.store.lists {
+		if list.DeletedAt.Valid {
+			items = append(items, listTrashItem(list))
+		}
+	}
+	for _, contact := range t.store.contacts {
+		if contact.DeletedAt.Valid {
+			items = append(items, contactTrashItem(contact))
+		}
+	}
+	sortTrashItems(items)
+	start, end := paginate(len(items), limit, offset)
+	return items[start:end], nil
+}
+func (t *memoryTrashRepository) GetByUUID(uuid uuid.UUID) (*models.TrashItem, error) {
+	t.store.mu.RLock()
+	defer t.store.mu.RUnlock()
+
+	if i := t.store.listIndex(func(list models.List) bool { return list.UUID == uuid && list.DeletedAt.Valid }); i >= 0 {
+		item := listTrashItem(t.store.lists[i])
+		return &item, nil
+	}
+	if i := t.store.contactIndex(func(contact models.Contact) bool { return contact.UUID == uuid && contact.DeletedAt.Valid }); i >= 0 {
+		item := contactTrashItem(t.store.contacts[i])
+		return &item, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (t *memoryTrashRepository) Purge(before time.Time) (int64, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	expired := func(deletedAt gorm.DeletedAt) bool { return deletedAt.Valid && deletedAt.Time.Before(before) }
+	purgedContacts := make(map[uint]bool)
+	purgedLists := make(map[uint]bool)
+	contacts := t.store.contacts[:0]
+	for _, contact := range t.store.contacts {
+		if expired(contact.DeletedAt) {
+			purgedContacts[contact.ID] = true
+			continue
+		}
+		contacts = append(contacts, contact)
+	}
+	t.store.contacts = contacts
+	lists := t.store.lists[:0]
+	for _, list := range t.store.lists {
+		if expired(list.DeletedAt) {
+			purgedLists[list.ID] = true
+			continue
+		}
+		lists = append(lists, list)
+	}
+	t.store.lists = lists
+	t.store.removeMemberships(func(m models.ListMembership) bool { return purgedContacts[m.ContactID] || purgedLists[m.ListID] })
+	return int64(len(purgedContacts) + len(purgedLists)), nil
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MergeFields are the contact fields a merge can take from a secondary contact.
+var MergeFields = []string{"first_name", "last_name", "mobile", "email", "country_code"}
+
+// Merge folds the secondary contacts of merge into its primary in a single
+// transaction. The primary takes the fields named in FieldSources from the
+// chosen contacts and the list memberships of the secondaries, the secondaries
+// are deleted for good, and merge is stored as the record of what happened.
+func (c *contactRepository) Merge(merge models.ContactMerge) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		uuids := append([]uuid.UUID{merge.PrimaryUUID}, merge.SecondaryUUIDs...)
+		var found []models.Contact
+		if err := tx.Where("uuid IN ?", uuids).Find(&found).Error; err != nil {
+			return err
+		}
+		if len(found) != len(uuids) {
+			return gorm.ErrRecordNotFound
+		}
+		if err := (&contactRepository{db: tx}).loadLists(found); err != nil {
+			return err
+		}
+		contacts, merged, err := mergeContacts(found, merge)
+		if err != nil {
+			return err
+		}
+		primary, secondaries := contacts[0], contacts[1:]
+
+		var secondaryIDs, listIDs []uint
+		for _, secondary := range secondaries {
+			secondaryIDs = append(secondaryIDs, secondary.ID)
+		}
+		// Memberships of deleted lists move too, so they are back if the list
+		// is restored.
+		err = tx.Model(&models.ListMembership{}).
+			Where("contact_id IN ? AND list_id NOT IN (?)", secondaryIDs, tx.Model(&models.ListMembership{}).Select("list_id").Where("contact_id = ?", primary.ID)).
+			Order("id").Pluck("list_id", &listIDs).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("contact_id IN ?", secondaryIDs).Delete(&models.ListMembership{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contact_id IN ?", secondaryIDs).Delete(&models.ContactDuplicateKey{}).Error; err != nil {
+			return err
+		}
+		if err := addMemberships(tx, primary.ID, listIDs, models.MembershipSourceMerge); err != nil {
+			return err
+		}
+		for _, secondary := range secondaries {
+			result := tx.Unscoped().Where("version = ?", secondary.Version).Delete(&secondary)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrVersionMismatch
+			}
+		}
+
+		// The secondaries are gone, so the primary can take over their email
+		// and mobile without hitting the unique indexes.
+		if err := bumpVersion(tx.Model(&models.Contact{}).Where("id = ?", primary.ID), primary.Version); err != nil {
+			return err
+		}
+		err = tx.Model(&models.Contact{}).Where("id = ?", primary.ID).Updates(map[string]interface{}{
+			"first_name":   merged.FirstName,
+			"last_name":    merged.LastName,
+			"mobile":       merged.Mobile,
+			"email":        merged.Email,
+			"country_code": merged.CountryCode,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := saveDuplicateKeys(tx, merged); err != nil {
+			return err
+		}
+
+		merge.Contacts = contacts
+		merge.MergedAt = time.Now()
+		return tx.Create(&merge).Error
+	})
+	return translateContactConflict(err)
+}
+
+// GetMerges returns the merges into the contact, oldest first.
+func (c *contactRepository) GetMerges(contactUUID uuid.UUID) ([]models.ContactMerge, error) {
+	merges := make([]models.ContactMerge, 0)
+	if err := c.db.Where("primary_uuid = ?", contactUUID).Order("merged_at, id").Find(&merges).Error; err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
+
+// mergeContacts orders the contacts like the merge, primary first, and returns
+// them together with the primary as it looks after the merge.
+func mergeContacts(found []models.Contact, merge models.ContactMerge) ([]models.Contact, models.Contact, error) {
+	byUUID := make(map[uuid.UUID]models.Contact, len(found))
+	for _, contact := range found {
+		byUUID[contact.UUID] = contact
+	}
+	contacts := []models.Contact{byUUID[merge.PrimaryUUID]}
+	for _, secondaryUUID := range merge.SecondaryUUIDs {
+		contacts = append(contacts, byUUID[secondaryUUID])
+	}
+
+	merged := contacts[0]
+	for field, source := range merge.FieldSources {
+		from, ok := byUUID[source]
+		if !ok {
+			return nil, models.Contact{}, fmt.Errorf("contact %v is not part of the merge", source)
+		}
+		switch field {
+		case "first_name":
+			merged.FirstName = from.FirstName
+		case "last_name":
+			merged.LastName = from.LastName
+		case "mobile":
+			merged.Mobile = from.Mobile
+		case "email":
+			merged.Email = from.Email
+		case "country_code":
+			merged.CountryCode = from.CountryCode
+		default:
+			return nil, models.Contact{}, fmt.Errorf("unknown merge field %q", field)
+		}
+	}
+	return contacts, merged, nil
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestAddressBookRepository_GetMembers(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			list := models.List{UUID: uuid.New(), Name: "Friends"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			listID, err := repos.contacts.GetListID(list.UUID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			member := models.Contact{UUID: uuid.New(), FirstName: "Ana", LastName: "Doe", Mobile: "+1555000001", Email: "ana@example.com", CountryCode: "USA", ListIDs: []uint{listID}}
+			deleted := models.Contact{UUID: uuid.New(), FirstName: "Bob", LastName: "Doe", Mobile: "+1555000002", Email: "bob@example.com", CountryCode: "USA", ListIDs: []uint{listID}}
+			outsider := models.Contact{UUID: uuid.New(), FirstName: "Cid", LastName: "Doe", Mobile: "+1555000003", Email: "cid@example.com", CountryCode: "USA"}
+			for _, contact := range []models.Contact{member, deleted, outsider} {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			if err := repos.contacts.Delete(deleted.UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+
+			members, err := repos.addressBooks.GetMembers(listID, nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(members) != 1 || members[0].UUID != member.UUID || members[0].Version != 1 {
+				t.Errorf("Expected only the live member, got %+v", members)
+			}
+			members, err = repos.addressBooks.GetMembers(listID, []uuid.UUID{outsider.UUID, deleted.UUID})
+			if err != nil || len(members) != 0 {
+				t.Errorf("Expected no members among contacts off the list, got %+v, %v", members, err)
+			}
+			members, err = repos.addressBooks.GetMembers(listID, []uuid.UUID{})
+			if err != nil || len(members) != 0 {
+				t.Errorf("Expected no members for no UUIDs, got %+v, %v", members, err)
+			}
+		})
+	}
+}
+
+func TestAddressBookRepository_Snapshots(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			listUUID := uuid.New()
+			contactUUID := uuid.New()
+			start := time.Now().Add(-time.Hour).Truncate(time.Second)
+			for i, token := range []string{"a", "b", "c", "d"} {
+				snapshot := models.AddressBookSnapshot{ListUUID: listUUID, Token: token, Members: map[uuid.UUID]uint{contactUUID: uint(i + 1)}, IssuedAt: start.Add(time.Duration(i) * time.Minute)}
+				if err := repos.addressBooks.SaveSnapshot(snapshot); err != nil {
+					t.Fatalf("Could not save test snapshot: %v", err)
+				}
+			}
+			if err := repos.addressBooks.SaveSnapshot(models.AddressBookSnapshot{ListUUID: uuid.New(), Token: "a", Members: map[uuid.UUID]uint{}, IssuedAt: start}); err != nil {
+				t.Fatalf("Could not save test snapshot: %v", err)
+			}
+			// Issuing "a" again makes it the most recent.
+			if err := repos.addressBooks.SaveSnapshot(models.AddressBookSnapshot{ListUUID: listUUID, Token: "a", Members: map[uuid.UUID]uint{contactUUID: 1}, IssuedAt: start.Add(time.Hour)}); err != nil {
+				t.Fatalf("Could not save test snapshot: %v", err)
+			}
+
+			snapshot, err := repos.addressBooks.GetSnapshot(listUUID, "c")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if snapshot.Members[contactUUID] != 3 {
+				t.Errorf("Expected the members of the snapshot, got %+v", snapshot.Members)
+			}
+
+			if err := repos.addressBooks.PruneSnapshots(listUUID, 2); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for token, kept := range map[string]bool{"a": true, "b": false, "c": false, "d": true} {
+				if _, err := repos.addressBooks.GetSnapshot(listUUID, token); (err == nil) != kept {
+					t.Errorf("Expected snapshot %s kept=%v, got %v", token, kept, err)
+				} else if !kept && !errors.Is(err, gorm.ErrRecordNotFound) {
+					t.Errorf("Expected ErrRecordNotFound for snapshot %s, got %v", token, err)
+				}
+			}
+		})
+	}
+}
+
+// Members are stored a row each, so a snapshot is not bound by the size of a
+// TEXT column, 64 KB on MySQL.
+func TestAddressBookRepository_LargeSnapshot(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewAddressBookRepository(db)
+	listUUID := uuid.New()
+	members := make(map[uuid.UUID]uint)
+	for i := 0; i < 5000; i++ {
+		members[uuid.New()] = uint(i + 1)
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := repo.SaveSnapshot(models.AddressBookSnapshot{ListUUID: listUUID, Token: "large", Members: members, IssuedAt: start}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	snapshot, err := repo.GetSnapshot(listUUID, "large")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(snapshot.Members, members) {
+		t.Errorf("Expected all %d members back, got %d", len(members), len(snapshot.Members))
+	}
+
+	if err := repo.SaveSnapshot(models.AddressBookSnapshot{ListUUID: listUUID, Token: "small", Members: map[uuid.UUID]uint{uuid.New(): 1}, IssuedAt: start.Add(time.Minute)}); err != nil {
+		t.Fatalf("Could not save test snapshot: %v", err)
+	}
+	if err := repo.PruneSnapshots(listUUID, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var count int64
+	if err := db.Model(&models.AddressBookSnapshotMember{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("Expected only the member of the kept snapshot to remain, got %d (%v)", count, err)
+	}
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestContactRepository_Batch(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			list := models.List{UUID: uuid.New(), Name: "Family"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			listID, _ := repos.contacts.GetListID(list.UUID)
+			existing := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1555000111", Email: "john@example.com", CountryCode: "USA", ListIDs: []uint{listID}}
+			if err := repos.contacts.Create(existing); err != nil {
+				t.Fatalf("Could not create test contact: %v", err)
+			}
+
+			created := models.Contact{UUID: uuid.New(), FirstName: "Jane", LastName: "Doe", Mobile: "+1555000222", Email: "jane@example.com", CountryCode: "USA", ListIDs: []uint{listID}}
+			taken := models.Contact{UUID: uuid.New(), FirstName: "Jim", LastName: "Doe", Mobile: "+1555000333", Email: "john@example.com", CountryCode: "USA"}
+			updated := existing
+			updated.FirstName = "Johnny"
+			updated.Version = 1
+
+			errs, err := repos.contacts.Batch([]repositories.ContactOperation{
+				{Op: repositories.BatchCreate, Contact: created},
+				{Op: repositories.BatchCreate, Contact: taken},
+				{Op: repositories.BatchUpdate, Contact: updated},
+			}, true)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !errors.Is(errs[0], repositories.ErrRolledBack) || !errors.Is(errs[1], repositories.ErrConflict) || !errors.Is(errs[2], repositories.ErrRolledBack) {
+				t.Fatalf("Expected the conflict and two rolled back operations, got %v", errs)
+			}
+			if _, err := repos.contacts.GetByUUID(created.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Expected the atomic create to be rolled back, got %v", err)
+			}
+			if contact, _ := repos.contacts.GetByUUID(existing.UUID); contact.FirstName != "John" || contact.Version != 1 {
+				t.Errorf("Expected the atomic update to be rolled back, got %+v", contact)
+			}
+
+			errs, err = repos.contacts.Batch([]repositories.ContactOperation{
+				{Op: repositories.BatchCreate, Contact: created},
+				{Op: repositories.BatchCreate, Contact: taken},
+				{Op: repositories.BatchUpdate, Contact: updated},
+				{Op: repositories.BatchDelete, Contact: models.Contact{UUID: created.UUID, Version: 2}},
+			}, false)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if errs[0] != nil || !errors.Is(errs[1], repositories.ErrConflict) || errs[2] != nil || !errors.Is(errs[3], repositories.ErrVersionMismatch) {
+				t.Fatalf("Expected only the conflict and the stale delete to fail, got %v", errs)
+			}
+			if contact, err := repos.contacts.GetByUUID(created.UUID); err != nil || len(contact.ListUUIDs) != 1 {
+				t.Errorf("Expected the created contact on its list, got %+v, %v", contact, err)
+			}
+			if contact, _ := repos.contacts.GetByUUID(existing.UUID); contact.FirstName != "Johnny" || contact.Version != 2 {
+				t.Errorf("Expected the update to be applied, got %+v", contact)
+			}
+		})
+	}
+}
+
+func TestContactRepository_BulkLookups(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			list := models.List{UUID: uuid.New(), Name: "Family"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			contact := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1555000111", Email: "john@example.com", CountryCode: "USA"}
+			deleted := models.Contact{UUID: uuid.New(), FirstName: "Jane", LastName: "Doe", Mobile: "+1555000222", Email: "jane@example.com", CountryCode: "USA"}
+			for _, c := range []models.Contact{contact, deleted} {
+				if err := repos.contacts.Create(c); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			if err := repos.contacts.Delete(deleted.UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+
+			byUUID, err := repos.contacts.GetByUUIDs([]uuid.UUID{contact.UUID, deleted.UUID, uuid.New()})
+			if err != nil || len(byUUID) != 1 || byUUID[contact.UUID].Email != contact.Email {
+				t.Errorf("Expected only the live contact by UUID, got %v, %v", byUUID, err)
+			}
+			// Deleted contacts still hold their email and mobile.
+			byEmail, err := repos.contacts.FindByEmails([]string{"JOHN@example.com", "jane@example.com", "nobody@example.com"})
+			if err != nil || len(byEmail) != 2 || byEmail["JOHN@example.com"].UUID != contact.UUID || byEmail["jane@example.com"].UUID != deleted.UUID {
+				t.Errorf("Expected both contacts keyed by the given email, got %v, %v", byEmail, err)
+			}
+			byMobile, err := repos.contacts.FindByMobiles([]string{"+1 555-000-111", "+1555000999"})
+			if err != nil || len(byMobile) != 1 || byMobile["+1 555-000-111"].UUID != contact.UUID {
+				t.Errorf("Expected the contact keyed by the given mobile, got %v, %v", byMobile, err)
+			}
+			listIDs, err := repos.contacts.GetListIDs([]uuid.UUID{list.UUID, uuid.New()})
+			if err != nil || len(listIDs) != 1 || listIDs[list.UUID] == 0 {
+				t.Errorf("Expected the ID of the existing list only, got %v, %v", listIDs, err)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"contact-list-api-1/models"
 	"contact-list-api-1/repositories"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func TestContactRepository_GetAll(t *testing.T) {
@@ -24,8 +28,8 @@ func TestContactRepository_GetAll(t *testing.T) {
 	}
 
 	testContacts := []models.Contact{
-		{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListID: list.ID},
-		{UUID: uuid.New(), FirstName: "Test 1", LastName: "Contact 1", Mobile: "+1987654321", Email: "test.test@example.com", CountryCode: "USA", ListID: list.ID},
+		{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Test 1", LastName: "Contact 1", Mobile: "+1987654321", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
 	}
 	for _, contact := range testContacts {
 		if err := db.Create(&contact).Error; err != nil {
@@ -41,6 +45,7 @@ func TestContactRepository_GetAll(t *testing.T) {
 		limit         int
 		offset        int
 		expectedCount int
+		expectedTotal int64
 		expectedName  string
 	}{
 		{
@@ -51,6 +56,7 @@ func TestContactRepository_GetAll(t *testing.T) {
 			limit:         0,
 			offset:        0,
 			expectedCount: len(testContacts),
+			expectedTotal: 2,
 		},
 		{
 			name:          "WithFilter",
@@ -60,8 +66,19 @@ func TestContactRepository_GetAll(t *testing.T) {
 			limit:         0,
 			offset:        0,
 			expectedCount: 2,
+			expectedTotal: 2,
 			expectedName:  "Contact",
 		},
+		{
+			name:          "WithCaseInsensitiveFilter",
+			filterName:    "contact",
+			filterMobile:  "",
+			filterEmail:   "",
+			limit:         0,
+			offset:        0,
+			expectedCount: 2,
+			expectedTotal: 2,
+		},
 		{
 			name:          "WithPagination",
 			filterName:    "",
@@ -70,6 +87,7 @@ func TestContactRepository_GetAll(t *testing.T) {
 			limit:         0,
 			offset:        0,
 			expectedCount: 2,
+			expectedTotal: 2,
 		},
 		{
 			name:          "WithPagination",
@@ -79,11 +97,12 @@ func TestContactRepository_GetAll(t *testing.T) {
 			limit:         1,
 			offset:        1,
 			expectedCount: 1,
+			expectedTotal: 2,
 		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			contacts, err := repo.GetAll(tt.filterName, tt.filterMobile, tt.filterEmail, tt.limit, tt.offset)
+			contacts, total, err := repo.GetAll(tt.filterName, tt.filterMobile, tt.filterEmail, nil, false, nil, tt.limit, tt.offset)
 			if err != nil {
 				t.Fatalf("Expected no error, got %v", err)
 
@@ -91,6 +110,9 @@ func TestContactRepository_GetAll(t *testing.T) {
 			if len(contacts) != tt.expectedCount {
 				t.Errorf("Expected %d contacts, got %d", tt.expectedCount, len(contacts))
 			}
+			if total != tt.expectedTotal {
+				t.Errorf("Expected a total of %d contacts, got %d", tt.expectedTotal, total)
+			}
 			if tt.expectedName != "" && len(contacts) > 0 && contacts[0].LastName != tt.expectedName {
 				t.Errorf("Expected contact with first name '%s', got %s", tt.expectedName, contacts[0].LastName)
 			}
@@ -118,7 +140,7 @@ func TestContactRepository_Create(t *testing.T) {
 		Mobile:      "+1234567890",
 		Email:       "test.contact@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 
 	testCases := []struct {
@@ -149,6 +171,13 @@ func TestContactRepository_Create(t *testing.T) {
 				if err := db.Where("uuid=?", testUUID).First(&createdContact).Error; err != nil {
 					t.Fatalf("Test data not found in database:%v", err)
 				}
+				var membership models.ListMembership
+				if err := db.Where("contact_id = ? AND list_id = ?", createdContact.ID, list.ID).First(&membership).Error; err != nil {
+					t.Fatalf("List membership not found in database:%v", err)
+				}
+				if membership.Source != models.MembershipSourceAPI {
+					t.Errorf("Expected membership source '%s', got '%s'", models.MembershipSourceAPI, membership.Source)
+				}
 			}
 		})
 	}
@@ -173,7 +202,7 @@ func TestContactRepository_GetByUUID(t *testing.T) {
 		Mobile:      "+1987654321",
 		Email:       "test.contact@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 
 	if err := db.Create(&testContact).Error; err != nil {
@@ -232,7 +261,7 @@ func TestContactRepository_Update(t *testing.T) {
 		Mobile:      "+1111111111",
 		Email:       "test.test@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 
 	if err := db.Create(&testContact).Error; err != nil {
@@ -272,6 +301,9 @@ func TestContactRepository_Update(t *testing.T) {
 				if updatedContact.LastName != tt.expectedLastName {
 					t.Errorf("Expected last name to be '%s', got %s", tt.expectedLastName, updatedContact.LastName)
 				}
+				if updatedContact.FirstName != "" {
+					t.Errorf("Expected fields missing from the update to be cleared, got first name %s", updatedContact.FirstName)
+				}
 			}
 		})
 	}
@@ -297,7 +329,7 @@ func TestContactRepository_Delete(t *testing.T) {
 		Mobile:      "+2222222222",
 		Email:       "test.test@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListIDs:     []uint{list.ID},
 	}
 
 	if err := db.Create(&testContact).Error; err != nil {
@@ -323,7 +355,7 @@ func TestContactRepository_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.Delete(tt.uuid)
+			err := repo.Delete(tt.uuid, 0)
 			if (err != nil) != tt.expectedError {
 				t.Fatalf("Expected error: %v, got %v", tt.expectedError, err)
 			}
@@ -338,3 +370,303 @@ func TestContactRepository_Delete(t *testing.T) {
 	}
 
 }
+
+func TestContactRepository_Restore(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	testContact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+2222222222", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(testContact); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	if err := repo.Delete(testContact.UUID, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	contacts, _, err := repo.GetAll("", "", "", nil, false, nil, 0, 0)
+	if err != nil || len(contacts) != 0 {
+		t.Errorf("Expected deleted contact to be hidden, got %d (%v)", len(contacts), err)
+	}
+	contacts, _, err = repo.GetAll("", "", "", nil, true, nil, 0, 0)
+	if err != nil || len(contacts) != 1 || !contacts[0].DeletedAt.Valid {
+		t.Fatalf("Expected deleted contact with include_deleted, got %+v (%v)", contacts, err)
+	}
+
+	if err := repo.Restore(testContact.UUID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	restored, err := repo.GetByUUID(testContact.UUID)
+	if err != nil {
+		t.Fatalf("Expected contact to be restored, got %v", err)
+	}
+	if len(restored.ListUUIDs) != 1 || restored.ListUUIDs[0] != list.UUID {
+		t.Errorf("Expected restored contact to keep list %v, got %v", list.UUID, restored.ListUUIDs)
+	}
+	if restored.CreatedAt.IsZero() || restored.UpdatedAt.IsZero() {
+		t.Errorf("Expected timestamps to be set, got %+v", restored)
+	}
+	if err := repo.Restore(uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestContactRepository_Versioning(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	other := models.List{UUID: uuid.New(), Name: "Other List"}
+	for _, l := range []*models.List{&list, &other} {
+		if err := db.Create(l).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	testContact := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+2222222222", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}, Version: 7}
+	if err := repo.Create(testContact); err != nil {
+		t.Fatalf("Could not create test data: %v", err)
+	}
+	version := func() uint {
+		contact, err := repo.GetByUUID(testContact.UUID)
+		if err != nil {
+			t.Fatalf("Could not fetch test contact: %v", err)
+		}
+		return contact.Version
+	}
+	if got := version(); got != 1 {
+		t.Fatalf("Expected new contact to start at version 1, got %d", got)
+	}
+
+	if err := repo.Update(models.Contact{UUID: testContact.UUID, FirstName: "Updated", Version: 1}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := version(); got != 2 {
+		t.Errorf("Expected version 2 after update, got %d", got)
+	}
+	if err := repo.Update(models.Contact{UUID: testContact.UUID, FirstName: "Stale", Version: 1}); !errors.Is(err, repositories.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch for stale update, got %v", err)
+	}
+	var stored models.Contact
+	if err := db.Where("uuid = ?", testContact.UUID).First(&stored).Error; err != nil {
+		t.Fatalf("Could not fetch test contact: %v", err)
+	}
+	if err := repo.AddToList(stored.ID, other.ID, models.MembershipSourceAPI); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := version(); got != 3 {
+		t.Errorf("Expected membership change to bump version to 3, got %d", got)
+	}
+	if err := repo.Delete(testContact.UUID, 2); !errors.Is(err, repositories.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch for stale delete, got %v", err)
+	}
+	if err := repo.Delete(testContact.UUID, 3); err != nil {
+		t.Errorf("Expected delete with current version to succeed, got %v", err)
+	}
+}
+
+func TestContactRepository_Conflict(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	existing := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	other := models.Contact{UUID: uuid.New(), FirstName: "Other", LastName: "Contact", Mobile: "+1987654321", Email: "other@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	for _, contact := range []models.Contact{existing, other} {
+		if err := repo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		write         func() error
+		expectedField string
+	}{
+		{
+			name: "CreateWithDuplicateMobile",
+			write: func() error {
+				return repo.Create(models.Contact{UUID: uuid.New(), FirstName: "New", LastName: "Contact", Mobile: existing.Mobile, Email: "new@example.com", CountryCode: "USA"})
+			},
+			expectedField: "mobile",
+		},
+		{
+			name: "CreateWithDuplicateEmail",
+			write: func() error {
+				return repo.Create(models.Contact{UUID: uuid.New(), FirstName: "New", LastName: "Contact", Mobile: "+1555000111", Email: existing.Email, CountryCode: "USA"})
+			},
+			expectedField: "email",
+		},
+		{
+			name: "UpdateToDuplicateMobile",
+			write: func() error {
+				return repo.Update(models.Contact{UUID: other.UUID, Mobile: existing.Mobile})
+			},
+			expectedField: "mobile",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.write()
+			if !errors.Is(err, repositories.ErrConflict) {
+				t.Fatalf("Expected ErrConflict, got %v", err)
+			}
+			var conflictErr *repositories.ConflictError
+			if !errors.As(err, &conflictErr) || conflictErr.Field != tt.expectedField {
+				t.Errorf("Expected conflict on %s, got %v", tt.expectedField, err)
+			}
+		})
+	}
+
+	unchanged, err := repo.GetByUUID(other.UUID)
+	if err != nil || unchanged.Mobile != other.Mobile || unchanged.Version != 1 {
+		t.Errorf("Expected rejected update to leave the contact untouched, got %+v (%v)", unchanged, err)
+	}
+}
+
+func TestContactRepository_FindByEmailAndMobile(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+
+	existing := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "xa@b.com", CountryCode: "USA"}
+	if err := repo.Create(existing); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+	deleted := models.Contact{UUID: uuid.New(), FirstName: "Deleted", LastName: "Contact", Mobile: "+1987654321", Email: "deleted@example.com", CountryCode: "USA"}
+	if err := repo.Create(deleted); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+	if err := repo.Delete(deleted.UUID, 0); err != nil {
+		t.Fatalf("Could not delete test contact: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		find         func() (*models.Contact, error)
+		expectedUUID uuid.UUID
+	}{
+		{
+			name:         "EmailIgnoresCaseAndSpaces",
+			find:         func() (*models.Contact, error) { return repo.FindByEmail(" XA@b.com ") },
+			expectedUUID: existing.UUID,
+		},
+		{
+			name: "EmailIsNotASubstringMatch",
+			find: func() (*models.Contact, error) { return repo.FindByEmail("a@b.com") },
+		},
+		{
+			name:         "MobileIgnoresSeparators",
+			find:         func() (*models.Contact, error) { return repo.FindByMobile("+1 (234) 567-890") },
+			expectedUUID: existing.UUID,
+		},
+		{
+			name: "MobileIsNotAPrefixMatch",
+			find: func() (*models.Contact, error) { return repo.FindByMobile("+1234") },
+		},
+		{
+			name:         "IncludesDeletedContacts",
+			find:         func() (*models.Contact, error) { return repo.FindByEmail(deleted.Email) },
+			expectedUUID: deleted.UUID,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			contact, err := tt.find()
+			if tt.expectedUUID == uuid.Nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					t.Errorf("Expected ErrRecordNotFound, got %v (%+v)", err, contact)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if contact.UUID != tt.expectedUUID {
+				t.Errorf("Expected contact %v, got %v", tt.expectedUUID, contact.UUID)
+			}
+		})
+	}
+}
+
+func TestContactRepository_GetAllAfter(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+
+	var ids []uint
+	for i, name := range []string{"Ana", "Bob", "Ana", "Cid", "Ana"} {
+		contact := models.Contact{UUID: uuid.New(), FirstName: name, LastName: "Contact", Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "USA"}
+		if err := db.Create(&contact).Error; err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+		ids = append(ids, contact.ID)
+	}
+
+	testCases := []struct {
+		name        string
+		filterName  string
+		afterID     uint
+		limit       int
+		expectedIDs []uint
+		expectedErr error
+	}{
+		{
+			name:        "FromStart",
+			limit:       2,
+			expectedIDs: ids[:2],
+		},
+		{
+			name:        "AfterCursor",
+			afterID:     ids[1],
+			limit:       2,
+			expectedIDs: ids[2:4],
+		},
+		{
+			name:        "WithFilter",
+			filterName:  "Ana",
+			afterID:     ids[0],
+			limit:       10,
+			expectedIDs: []uint{ids[2], ids[4]},
+		},
+		{
+			name:    "PastEnd",
+			afterID: ids[4],
+			limit:   2,
+		},
+		{
+			name:        "PurgedCursor",
+			afterID:     ids[4] + 100,
+			limit:       2,
+			expectedErr: repositories.ErrCursorNotFound,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts, err := repo.GetAllAfter(tt.filterName, "", "", nil, false, nil, repositories.Cursor{ID: tt.afterID}, tt.limit)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			var gotIDs []uint
+			for _, contact := range contacts {
+				gotIDs = append(gotIDs, contact.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.expectedIDs) {
+				t.Errorf("Expected contacts %v, got %v", tt.expectedIDs, gotIDs)
+			}
+		})
+	}
+}
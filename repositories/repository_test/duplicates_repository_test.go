@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/duplicate"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestContactRepository_DuplicateBlocks(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			ann := models.Contact{UUID: uuid.New(), FirstName: "Ann", LastName: "Lee", Mobile: "+1555000001", Email: "ann@example.com", CountryCode: "USA"}
+			anna := models.Contact{UUID: uuid.New(), FirstName: "Anna", LastName: "Lee", Mobile: "+1555000002", Email: "ANN+work@example.com", CountryCode: "USA"}
+			andy := models.Contact{UUID: uuid.New(), FirstName: "Andy", LastName: "Lowe", Mobile: "+1555000003", Email: "andy@example.com", CountryCode: "USA"}
+			bob := models.Contact{UUID: uuid.New(), FirstName: "Bob", LastName: "Stone", Mobile: "+1555000004", Email: "bob@example.com", CountryCode: "USA"}
+			for _, contact := range []models.Contact{ann, anna, andy, bob} {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			uuids := func(blocks [][]models.Contact) [][]uuid.UUID {
+				result := make([][]uuid.UUID, len(blocks))
+				for i, block := range blocks {
+					for _, contact := range block {
+						result[i] = append(result[i], contact.UUID)
+					}
+				}
+				return result
+			}
+			expectBlocks := func(blocks [][]models.Contact, err error, expected ...[]uuid.UUID) {
+				t.Helper()
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				found := uuids(blocks)
+				if len(found) != len(expected) {
+					t.Fatalf("Expected %d blocks, got %v", len(expected), found)
+				}
+				for i := range expected {
+					if len(found[i]) != len(expected[i]) {
+						t.Fatalf("Expected block %d to be %v, got %v", i, expected[i], found[i])
+					}
+					for j := range expected[i] {
+						if found[i][j] != expected[i][j] {
+							t.Errorf("Expected block %d to be %v, got %v", i, expected[i], found[i])
+						}
+					}
+				}
+			}
+
+			blocks, err := repos.contacts.DuplicateBlocks([]string{duplicate.BlockEmail, duplicate.BlockMobile}, 100)
+			expectBlocks(blocks, err, []uuid.UUID{ann.UUID, anna.UUID})
+
+			// "an" is shared by three contacts and is over the cap, "le" is not.
+			blocks, err = repos.contacts.DuplicateBlocks([]string{duplicate.BlockName}, 2)
+			expectBlocks(blocks, err, []uuid.UUID{ann.UUID, anna.UUID})
+
+			stored, err := repos.contacts.GetByUUID(ann.UUID)
+			if err != nil {
+				t.Fatalf("Could not get test contact: %v", err)
+			}
+			blocks, err = repos.contacts.DuplicateBlocksOf(stored.ID, []string{duplicate.BlockEmail, duplicate.BlockMobile, duplicate.BlockName}, 100)
+			expectBlocks(blocks, err, []uuid.UUID{ann.UUID, anna.UUID}, []uuid.UUID{ann.UUID, anna.UUID, andy.UUID}, []uuid.UUID{ann.UUID, anna.UUID})
+
+			anna.Email = "anna@example.com"
+			anna.Version = 1
+			if err := repos.contacts.Update(anna); err != nil {
+				t.Fatalf("Could not update test contact: %v", err)
+			}
+			if err := repos.contacts.Delete(andy.UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+			blocks, err = repos.contacts.DuplicateBlocks([]string{duplicate.BlockEmail}, 100)
+			expectBlocks(blocks, err)
+			blocks, err = repos.contacts.DuplicateBlocks([]string{duplicate.BlockName}, 2)
+			expectBlocks(blocks, err, []uuid.UUID{ann.UUID, anna.UUID}, []uuid.UUID{ann.UUID, anna.UUID})
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services/filter"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestContactRepository_Export(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			list := models.List{UUID: uuid.New(), Name: "Family"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			listID, _ := repos.contacts.GetListID(list.UUID)
+			contacts := []models.Contact{
+				{UUID: uuid.New(), FirstName: "Carol", LastName: "Smith", Mobile: "+1555000111", Email: "carol@example.com", CountryCode: "USA", ListIDs: []uint{listID}},
+				{UUID: uuid.New(), FirstName: "Alice", LastName: "Jones", Mobile: "+1555000222", Email: "alice@example.com", CountryCode: "GBR"},
+				{UUID: uuid.New(), FirstName: "Bob", LastName: "Smith", Mobile: "+1555000333", Email: "bob@example.com", CountryCode: "USA", ListIDs: []uint{listID}},
+				{UUID: uuid.New(), FirstName: "Dave", LastName: "Smith", Mobile: "+1555000444", Email: "dave@example.com", CountryCode: "USA", ListIDs: []uint{listID}},
+			}
+			for _, contact := range contacts {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			if err := repos.contacts.Delete(contacts[3].UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+			usa, err := filter.Parse(`country_code eq "USA"`, filter.ContactFields)
+			if err != nil {
+				t.Fatalf("Could not parse test filter: %v", err)
+			}
+			byFirstName := []repositories.SortField{{Column: "first_name"}}
+
+			export := func(listID uint, includeDeleted bool) []string {
+				var names []string
+				err := repos.contacts.Export(listID, "", "", "", usa, includeDeleted, byFirstName, func(contact models.Contact) error {
+					names = append(names, contact.FirstName)
+					if contact.FirstName == "Bob" && !reflect.DeepEqual(contact.ListUUIDs, []uuid.UUID{list.UUID}) {
+						t.Errorf("Expected Bob to be exported with his list, got %v", contact.ListUUIDs)
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return names
+			}
+			if names := export(0, false); !reflect.DeepEqual(names, []string{"Bob", "Carol"}) {
+				t.Errorf("Expected the live contacts from the USA by first name, got %v", names)
+			}
+			if names := export(0, true); !reflect.DeepEqual(names, []string{"Bob", "Carol", "Dave"}) {
+				t.Errorf("Expected the deleted contact as well, got %v", names)
+			}
+			if names := export(listID, false); !reflect.DeepEqual(names, []string{"Bob", "Carol"}) {
+				t.Errorf("Expected the live members of the list, got %v", names)
+			}
+
+			stop := errors.New("stop")
+			calls := 0
+			err = repos.contacts.Export(0, "", "", "", nil, false, nil, func(models.Contact) error {
+				calls++
+				return stop
+			})
+			if !errors.Is(err, stop) || calls != 1 {
+				t.Errorf("Expected the export to stop at the first error, got %v after %d calls", err, calls)
+			}
+		})
+	}
+}
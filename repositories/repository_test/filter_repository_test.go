@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/filter"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestContactRepository_Filter(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			list := models.List{UUID: uuid.New(), Name: "Corp"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			listID, _ := repos.contacts.GetListID(list.UUID)
+
+			contacts := []models.Contact{
+				{UUID: uuid.New(), FirstName: "Sara", LastName: "Savic", Mobile: "+381111111", Email: "sara@corp.com", CountryCode: "SRB", ListIDs: []uint{listID}},
+				{UUID: uuid.New(), FirstName: "Marko", LastName: "Maric", Mobile: "+381222222", Email: "marko@home.com", CountryCode: "SRB"},
+				{UUID: uuid.New(), FirstName: "John", LastName: "Doe", Mobile: "+1333333333", Email: "john_doe@corp.com", CountryCode: "USA", ListIDs: []uint{listID}},
+				{UUID: uuid.New(), FirstName: "Jane", LastName: "Roe", Mobile: "+1444444444", Email: "janexdoe@corp.com", CountryCode: "USA"},
+			}
+			for _, contact := range contacts {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			byUUID := func(indexes ...int) []uuid.UUID {
+				uuids := make([]uuid.UUID, 0, len(indexes))
+				for _, i := range indexes {
+					uuids = append(uuids, contacts[i].UUID)
+				}
+				return uuids
+			}
+			future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+			testCases := []struct {
+				name       string
+				expression string
+				expected   []uuid.UUID
+			}{
+				{
+					name:       "ExactAndSuffix",
+					expression: `country_code eq "SRB" and email ends_with "@corp.com"`,
+					expected:   byUUID(0),
+				},
+				{
+					name:       "PrefixIgnoresCase",
+					expression: `first_name starts_with "j"`,
+					expected:   byUUID(2, 3),
+				},
+				{
+					name:       "WildcardsAreLiteral",
+					expression: `email contains "_doe"`,
+					expected:   byUUID(2),
+				},
+				{
+					name:       "OrAndNot",
+					expression: `not (country_code eq "USA") or last_name eq "Roe"`,
+					expected:   byUUID(0, 1, 3),
+				},
+				{
+					name:       "ListMembership",
+					expression: `list eq "` + list.UUID.String() + `"`,
+					expected:   byUUID(0, 2),
+				},
+				{
+					name:       "NotInList",
+					expression: `list ne "` + list.UUID.String() + `"`,
+					expected:   byUUID(1, 3),
+				},
+				{
+					name:       "DateRange",
+					expression: `created_at ge "2000-01-01" and created_at lt "` + future + `"`,
+					expected:   byUUID(0, 1, 2, 3),
+				},
+				{
+					name:       "DateInTheFuture",
+					expression: `updated_at gt "` + future + `"`,
+					expected:   byUUID(),
+				},
+			}
+			for _, tt := range testCases {
+				t.Run(tt.name, func(t *testing.T) {
+					where, err := filter.Parse(tt.expression, filter.ContactFields)
+					if err != nil {
+						t.Fatalf("Could not parse filter: %v", err)
+					}
+					got, total, err := repos.contacts.GetAll("", "", "", where, false, nil, 0, 0)
+					if err != nil {
+						t.Fatalf("Expected no error, got %v", err)
+					}
+					if !reflect.DeepEqual(contactUUIDs(got), tt.expected) || total != int64(len(tt.expected)) {
+						t.Errorf("Expected %v, got %v (total %d)", tt.expected, contactUUIDs(got), total)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestImportRepository(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			contactImport := models.ContactImport{UUID: uuid.New(), ListUUID: uuid.New(), Header: []string{"email", "mobile"}, ImportedAt: time.Now()}
+			other := models.ContactImport{UUID: uuid.New(), ListUUID: contactImport.ListUUID, Header: []string{"email"}, ImportedAt: time.Now()}
+			for _, i := range []models.ContactImport{contactImport, other} {
+				if err := repos.imports.Create(i); err != nil {
+					t.Fatalf("Could not create test import: %v", err)
+				}
+			}
+			for line := 2; line <= 4; line++ {
+				row := models.ContactImportRow{ImportUUID: contactImport.UUID, Line: line, Values: []string{"bad", "+1"}, Errors: []string{"Email: invalid email format"}}
+				if err := repos.imports.AddRow(row); err != nil {
+					t.Fatalf("Could not add test row: %v", err)
+				}
+				if err := repos.imports.AddRow(models.ContactImportRow{ImportUUID: other.UUID, Line: line, Values: []string{}, Errors: []string{}}); err != nil {
+					t.Fatalf("Could not add test row: %v", err)
+				}
+			}
+			contactImport.Created, contactImport.Skipped, contactImport.Failed = 5, 1, 3
+			if err := repos.imports.UpdateCounts(contactImport); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			found, err := repos.imports.GetByUUID(contactImport.UUID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if found.Created != 5 || found.Skipped != 1 || found.Failed != 3 || len(found.Header) != 2 {
+				t.Errorf("Expected the updated counts and the header, got %+v", found)
+			}
+			if _, err := repos.imports.GetByUUID(uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Expected ErrRecordNotFound, got %v", err)
+			}
+
+			rows, err := repos.imports.GetRows(contactImport.UUID, 0, 2)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(rows) != 2 || rows[0].Line != 2 || rows[1].Line != 3 || rows[0].Values[0] != "bad" || rows[0].Errors[0] != "Email: invalid email format" {
+				t.Fatalf("Expected the first two rows of the import, got %+v", rows)
+			}
+			rows, err = repos.imports.GetRows(contactImport.UUID, rows[1].ID, 2)
+			if err != nil || len(rows) != 1 || rows[0].Line != 4 {
+				t.Errorf("Expected the last row after the first page, got %+v, %v", rows, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"testing"
 
 	"contact-list-api-1/models"
@@ -41,6 +42,7 @@ func TestListRepository_GetAll(t *testing.T) {
 		limit         int
 		offset        int
 		expectedCount int
+		expectedTotal int64
 		expectedName  string
 	}{
 		{
@@ -49,6 +51,7 @@ func TestListRepository_GetAll(t *testing.T) {
 			limit:         0,
 			offset:        0,
 			expectedCount: 3,
+			expectedTotal: 3,
 		},
 		{
 			name:          "WithNameFilter",
@@ -56,6 +59,7 @@ func TestListRepository_GetAll(t *testing.T) {
 			limit:         0,
 			offset:        0,
 			expectedCount: 1,
+			expectedTotal: 1,
 			expectedName:  "List1",
 		},
 		{
@@ -64,11 +68,12 @@ func TestListRepository_GetAll(t *testing.T) {
 			limit:         2,
 			offset:        1,
 			expectedCount: 2,
+			expectedTotal: 3,
 		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			lists, err := repo.GetAll(tt.filter, tt.limit, tt.offset)
+			lists, total, err := repo.GetAll(tt.filter, false, nil, tt.limit, tt.offset)
 			if err != nil {
 				t.Fatalf("Expected no error, got %v", err)
 
@@ -76,6 +81,9 @@ func TestListRepository_GetAll(t *testing.T) {
 			if len(lists) != tt.expectedCount {
 				t.Errorf("Expected %d lists, got %d", tt.expectedCount, len(lists))
 			}
+			if total != tt.expectedTotal {
+				t.Errorf("Expected a total of %d lists, got %d", tt.expectedTotal, total)
+			}
 			if tt.expectedName != "" && len(lists) > 0 && lists[0].Name != tt.expectedName {
 				t.Errorf("Expected list with name '%s', got '%s'", tt.expectedName, lists[0].Name)
 			}
@@ -264,7 +272,7 @@ func TestListRepository_Delete(t *testing.T) {
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := repo.Delete(tt.uuid)
+			err := repo.Delete(tt.uuid, true, 0)
 			if (err != nil) != tt.expectedError {
 				t.Fatalf("Expected error:%v, got %v", tt.expectedError, err)
 
@@ -278,3 +286,220 @@ func TestListRepository_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestListRepository_DeleteCascadesToContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+
+	deletedList := models.List{UUID: uuid.New(), Name: "To be deleted"}
+	keptList := models.List{UUID: uuid.New(), Name: "Kept"}
+	for _, list := range []*models.List{&deletedList, &keptList} {
+		if err := db.Create(list).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	onlyMember := models.Contact{UUID: uuid.New(), FirstName: "Only", LastName: "Member", Mobile: "+1111111111", Email: "only@example.com", CountryCode: "USA", ListIDs: []uint{deletedList.ID}}
+	sharedMember := models.Contact{UUID: uuid.New(), FirstName: "Shared", LastName: "Member", Mobile: "+2222222222", Email: "shared@example.com", CountryCode: "USA", ListIDs: []uint{deletedList.ID, keptList.ID}}
+	for _, contact := range []models.Contact{onlyMember, sharedMember} {
+		if err := contactRepo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	if err := repo.Delete(deletedList.UUID, true, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := contactRepo.GetByUUID(onlyMember.UUID); err == nil {
+		t.Errorf("Expected contact that only belonged to the deleted list to be deleted")
+	}
+	contact, err := contactRepo.GetByUUID(sharedMember.UUID)
+	if err != nil {
+		t.Fatalf("Expected shared contact to still exist, got %v", err)
+	}
+	if len(contact.ListIDs) != 1 || contact.ListIDs[0] != keptList.ID {
+		t.Errorf("Expected shared contact to only belong to list %d, got %v", keptList.ID, contact.ListIDs)
+	}
+}
+
+func TestListRepository_RestoreBringsBackCascadedContacts(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+
+	list := models.List{UUID: uuid.New(), Name: "To be restored"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	cascaded := models.Contact{UUID: uuid.New(), FirstName: "Cascaded", LastName: "Member", Mobile: "+1111111111", Email: "cascaded@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	deletedEarlier := models.Contact{UUID: uuid.New(), FirstName: "Deleted", LastName: "Earlier", Mobile: "+2222222222", Email: "earlier@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	for _, contact := range []models.Contact{cascaded, deletedEarlier} {
+		if err := contactRepo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+	if err := contactRepo.Delete(deletedEarlier.UUID, 0); err != nil {
+		t.Fatalf("Could not delete test contact: %v", err)
+	}
+
+	if err := repo.Delete(list.UUID, true, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lists, _, err := repo.GetAll("", false, nil, 0, 0)
+	if err != nil || len(lists) != 0 {
+		t.Errorf("Expected deleted list to be hidden, got %d (%v)", len(lists), err)
+	}
+	lists, _, err = repo.GetAll("", true, nil, 0, 0)
+	if err != nil || len(lists) != 1 || !lists[0].DeletedAt.Valid {
+		t.Fatalf("Expected deleted list with include_deleted, got %+v (%v)", lists, err)
+	}
+
+	if err := repo.Restore(list.UUID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := repo.GetByUUID(list.UUID); err != nil {
+		t.Errorf("Expected list to be restored, got %v", err)
+	}
+	contact, err := contactRepo.GetByUUID(cascaded.UUID)
+	if err != nil {
+		t.Fatalf("Expected cascaded contact to be restored, got %v", err)
+	}
+	if len(contact.ListUUIDs) != 1 || contact.ListUUIDs[0] != list.UUID {
+		t.Errorf("Expected restored contact to belong to list %v, got %v", list.UUID, contact.ListUUIDs)
+	}
+	if _, err := contactRepo.GetByUUID(deletedEarlier.UUID); err == nil {
+		t.Errorf("Expected contact deleted before the list to stay deleted")
+	}
+	if err := repo.Restore(uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestListRepository_DeleteWithoutCascade(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+
+	list := models.List{UUID: uuid.New(), Name: "Not empty"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	member := models.Contact{UUID: uuid.New(), FirstName: "Only", LastName: "Member", Mobile: "+1111111111", Email: "only@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := contactRepo.Create(member); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+
+	if err := repo.Delete(list.UUID, false, 0); !errors.Is(err, repositories.ErrListNotEmpty) {
+		t.Fatalf("Expected ErrListNotEmpty, got %v", err)
+	}
+	if _, err := repo.GetByUUID(list.UUID); err != nil {
+		t.Errorf("Expected list to be kept, got %v", err)
+	}
+
+	if err := contactRepo.Delete(member.UUID, 0); err != nil {
+		t.Fatalf("Could not delete test contact: %v", err)
+	}
+	if err := repo.Delete(list.UUID, false, 0); err != nil {
+		t.Errorf("Expected list without live contacts to be deleted, got %v", err)
+	}
+}
+
+func TestListRepository_DeleteRollsBackOnFailure(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+
+	list := models.List{UUID: uuid.New(), Name: "To be deleted"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	member := models.Contact{UUID: uuid.New(), FirstName: "Only", LastName: "Member", Mobile: "+1111111111", Email: "only@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := contactRepo.Create(member); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+
+	failure := errors.New("lists table is locked")
+	err := db.Callback().Update().Before("gorm:update").Register("test:fail_list_update", func(tx *gorm.DB) {
+		if tx.Statement.Table == "lists" {
+			tx.AddError(failure)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Could not register callback: %v", err)
+	}
+
+	if err := repo.Delete(list.UUID, true, 0); !errors.Is(err, failure) {
+		t.Fatalf("Expected the list update failure to be reported, got %v", err)
+	}
+	if _, err := contactRepo.GetByUUID(member.UUID); err != nil {
+		t.Errorf("Expected contact delete to be rolled back, got %v", err)
+	}
+}
+
+func TestListRepository_Versioning(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewListRepository(db)
+	list := models.List{UUID: uuid.New(), Name: "Versioned"}
+	if err := repo.Create(list); err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+
+	if err := repo.Update(models.List{UUID: list.UUID, Name: "Renamed", Version: 1}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	updated, err := repo.GetByUUID(list.UUID)
+	if err != nil || updated.Version != 2 || updated.Name != "Renamed" {
+		t.Fatalf("Expected renamed list at version 2, got %+v (%v)", updated, err)
+	}
+	if err := repo.Update(models.List{UUID: list.UUID, Name: "Stale", Version: 1}); !errors.Is(err, repositories.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch for stale update, got %v", err)
+	}
+	if err := repo.Delete(list.UUID, true, 1); !errors.Is(err, repositories.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch for stale delete, got %v", err)
+	}
+	if err := repo.Delete(list.UUID, true, 2); err != nil {
+		t.Errorf("Expected delete with current version to succeed, got %v", err)
+	}
+}
+
+// A restored list keeps its version, so an If-Match from before the delete
+// stays stale.
+func TestListRepository_RestoreKeepsVersion(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+			list := models.List{UUID: uuid.New(), Name: "Versioned"}
+			if err := repos.lists.Create(list); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			if err := repos.lists.Update(models.List{UUID: list.UUID, Name: "Renamed", Version: 1}); err != nil {
+				t.Fatalf("Could not update test list: %v", err)
+			}
+			if err := repos.lists.Delete(list.UUID, true, 2); err != nil {
+				t.Fatalf("Could not delete test list: %v", err)
+			}
+			if err := repos.lists.Restore(list.UUID); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			restored, err := repos.lists.GetByUUID(list.UUID)
+			if err != nil || restored.Version != 2 {
+				t.Fatalf("Expected restored list at version 2, got %+v (%v)", restored, err)
+			}
+			if err := repos.lists.Update(models.List{UUID: list.UUID, Name: "Stale", Version: 1}); !errors.Is(err, repositories.ErrVersionMismatch) {
+				t.Errorf("Expected ErrVersionMismatch for a version from before the delete, got %v", err)
+			}
+		})
+	}
+}
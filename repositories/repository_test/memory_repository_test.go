@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func setMemoryRepositories(t *testing.T) (repositories.ListRepository, repositories.ContactRepository, models.List) {
+	store := repositories.NewMemoryStore()
+	listRepo := repositories.NewMemoryListRepository(store)
+	contactRepo := repositories.NewMemoryContactRepository(store)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	if err := listRepo.Create(list); err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	listID, err := contactRepo.GetListID(list.UUID)
+	if err != nil {
+		t.Fatalf("Could not resolve test list: %v", err)
+	}
+	list.ID = listID
+	return listRepo, contactRepo, list
+}
+
+func TestMemoryContactRepository_GetAll(t *testing.T) {
+	_, repo, list := setMemoryRepositories(t)
+
+	testContacts := []models.Contact{
+		{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Test 1", LastName: "Contact 1", Mobile: "+1987654321", Email: "test.test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Sara", LastName: "Savic", Mobile: "+1122334455", Email: "sara@example.com", CountryCode: "SRB"},
+	}
+	for _, contact := range testContacts {
+		if err := repo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		filterName    string
+		filterMobile  string
+		filterEmail   string
+		limit         int
+		offset        int
+		expectedCount int
+		expectedTotal int64
+	}{
+		{
+			name:          "WithoutFilterAndPagination",
+			expectedCount: 3,
+			expectedTotal: 3,
+		},
+		{
+			name:          "WithCaseInsensitiveNameFilter",
+			filterName:    "contact",
+			expectedCount: 2,
+			expectedTotal: 2,
+		},
+		{
+			name:          "WithMobileFilter",
+			filterMobile:  "+1987",
+			expectedCount: 1,
+			expectedTotal: 1,
+		},
+		{
+			name:          "WithEmailFilter",
+			filterEmail:   "sara@",
+			expectedCount: 1,
+			expectedTotal: 1,
+		},
+		{
+			name:          "WithPagination",
+			limit:         2,
+			offset:        2,
+			expectedCount: 1,
+			expectedTotal: 3,
+		},
+		{
+			name:          "OffsetPastEnd",
+			limit:         2,
+			offset:        10,
+			expectedCount: 0,
+			expectedTotal: 3,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts, total, err := repo.GetAll(tt.filterName, tt.filterMobile, tt.filterEmail, nil, false, nil, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(contacts) != tt.expectedCount {
+				t.Errorf("Expected %d contacts, got %d", tt.expectedCount, len(contacts))
+			}
+			if total != tt.expectedTotal {
+				t.Errorf("Expected a total of %d contacts, got %d", tt.expectedTotal, total)
+			}
+		})
+	}
+
+	first, err := repo.GetByUUID(testContacts[0].UUID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	after, err := repo.GetAllAfter("", "", "", nil, false, nil, repositories.Cursor{ID: first.ID}, 1)
+	if err != nil || len(after) != 1 || after[0].UUID != testContacts[1].UUID {
+		t.Errorf("Expected the page after the first contact to hold %v, got %+v (%v)", testContacts[1].UUID, after, err)
+	}
+
+	members, total, err := repo.GetAllByList(list.ID, "", "", "", nil, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(members) != 2 || total != 2 {
+		t.Errorf("Expected 2 list members, got %d of %d", len(members), total)
+	}
+}
+
+func TestMemoryContactRepository_CreateUpdateDelete(t *testing.T) {
+	_, repo, list := setMemoryRepositories(t)
+
+	testUUID := uuid.New()
+	testContact := models.Contact{UUID: testUUID, FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+
+	if err := repo.Create(testContact); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repo.Create(testContact); err == nil {
+		t.Errorf("Expected duplicate contact to be rejected")
+	}
+
+	contact, err := repo.GetByUUID(testUUID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(contact.ListUUIDs) != 1 || contact.ListUUIDs[0] != list.UUID {
+		t.Errorf("Expected contact to belong to list %v, got %v", list.UUID, contact.ListUUIDs)
+	}
+
+	if err := repo.Update(models.Contact{UUID: testUUID, LastName: "Updated"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	contact, _ = repo.GetByUUID(testUUID)
+	if contact.LastName != "Updated" || contact.FirstName != "" {
+		t.Errorf("Expected the contact to be replaced, got %+v", contact)
+	}
+	if err := repo.Update(models.Contact{UUID: uuid.New(), LastName: "Missing"}); err == nil {
+		t.Errorf("Expected updating a non-existent contact to fail")
+	}
+
+	if err := repo.RemoveFromList(contact.ID, list.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repo.RemoveFromList(contact.ID, list.ID); !errors.Is(err, repositories.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := repo.Delete(testUUID, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := repo.GetByUUID(testUUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+	if err := repo.Delete(testUUID, 0); err == nil {
+		t.Errorf("Expected deleting a non-existent contact to fail")
+	}
+}
+
+func TestMemoryListRepository(t *testing.T) {
+	listRepo, contactRepo, list := setMemoryRepositories(t)
+
+	keptList := models.List{UUID: uuid.New(), Name: "Kept"}
+	if err := listRepo.Create(keptList); err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	if err := listRepo.Create(keptList); err == nil {
+		t.Errorf("Expected duplicate list to be rejected")
+	}
+	keptListID, _ := contactRepo.GetListID(keptList.UUID)
+
+	after, err := listRepo.GetAllAfter("", false, nil, repositories.Cursor{ID: list.ID}, 10)
+	if err != nil || len(after) != 1 || after[0].UUID != keptList.UUID {
+		t.Errorf("Expected only the kept list after the first list, got %+v (%v)", after, err)
+	}
+	lists, _, err := listRepo.GetAll("kept", false, nil, 0, 0)
+	if err != nil || len(lists) != 1 {
+		t.Fatalf("Expected 1 list, got %d (%v)", len(lists), err)
+	}
+	if err := listRepo.Update(models.List{UUID: keptList.UUID, Name: "Renamed"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	renamed, err := listRepo.GetByUUID(keptList.UUID)
+	if err != nil || renamed.Name != "Renamed" || renamed.Version != 2 {
+		t.Errorf("Expected list to be renamed at version 2, got %+v (%v)", renamed, err)
+	}
+	if err := listRepo.Update(models.List{UUID: keptList.UUID, Name: "Stale", Version: 1}); !errors.Is(err, repositories.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+
+	onlyMember := models.Contact{UUID: uuid.New(), FirstName: "Only", LastName: "Member", Mobile: "+1111111111", Email: "only@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	sharedMember := models.Contact{UUID: uuid.New(), FirstName: "Shared", LastName: "Member", Mobile: "+2222222222", Email: "shared@example.com", CountryCode: "USA", ListIDs: []uint{list.ID, keptListID}}
+	for _, contact := range []models.Contact{onlyMember, sharedMember} {
+		if err := contactRepo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	if err := listRepo.Delete(list.UUID, false, 0); !errors.Is(err, repositories.ErrListNotEmpty) {
+		t.Errorf("Expected ErrListNotEmpty, got %v", err)
+	}
+	if err := listRepo.Delete(list.UUID, true, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := listRepo.GetByUUID(list.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+	if _, err := contactRepo.GetByUUID(onlyMember.UUID); err == nil {
+		t.Errorf("Expected contact that only belonged to the deleted list to be deleted")
+	}
+	shared, err := contactRepo.GetByUUID(sharedMember.UUID)
+	if err != nil {
+		t.Fatalf("Expected shared contact to still exist, got %v", err)
+	}
+	if len(shared.ListUUIDs) != 1 || shared.ListUUIDs[0] != keptList.UUID {
+		t.Errorf("Expected shared contact to only belong to list %v, got %v", keptList.UUID, shared.ListUUIDs)
+	}
+	lists, _, err = listRepo.GetAll("", true, nil, 0, 0)
+	if err != nil || len(lists) != 2 {
+		t.Errorf("Expected 2 lists with include_deleted, got %d (%v)", len(lists), err)
+	}
+
+	if err := listRepo.Restore(list.UUID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	restored, err := contactRepo.GetByUUID(onlyMember.UUID)
+	if err != nil {
+		t.Fatalf("Expected cascaded contact to be restored, got %v", err)
+	}
+	if len(restored.ListUUIDs) != 1 || restored.ListUUIDs[0] != list.UUID {
+		t.Errorf("Expected restored contact to belong to list %v, got %v", list.UUID, restored.ListUUIDs)
+	}
+	if err := listRepo.Restore(uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestMemoryContactRepository_Conflict(t *testing.T) {
+	_, repo, list := setMemoryRepositories(t)
+
+	existing := models.Contact{UUID: uuid.New(), FirstName: "Test", LastName: "Contact", Mobile: "+1234567890", Email: "test.contact@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(existing); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+	other := models.Contact{UUID: uuid.New(), FirstName: "Other", LastName: "Contact", Mobile: "+1987654321", Email: "other@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}}
+	if err := repo.Create(other); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+
+	duplicate := other
+	duplicate.UUID = uuid.New()
+	duplicate.Email = "new@example.com"
+	var conflictErr *repositories.ConflictError
+	if err := repo.Create(duplicate); !errors.As(err, &conflictErr) || conflictErr.Field != "mobile" {
+		t.Errorf("Expected mobile conflict, got %v", err)
+	}
+	if err := repo.Update(models.Contact{UUID: other.UUID, Email: existing.Email}); !errors.Is(err, repositories.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+
+	if found, err := repo.FindByMobile("+1 234-567-890"); err != nil || found.UUID != existing.UUID {
+		t.Errorf("Expected to find contact %v by mobile, got %+v (%v)", existing.UUID, found, err)
+	}
+	if _, err := repo.FindByEmail("contact@example.com"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for a partial email, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestContactRepository_Merge(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			var listUUIDs []uuid.UUID
+			var listIDs []uint
+			for _, name := range []string{"Family", "Work", "Old"} {
+				list := models.List{UUID: uuid.New(), Name: name}
+				if err := repos.lists.Create(list); err != nil {
+					t.Fatalf("Could not create test list: %v", err)
+				}
+				listID, _ := repos.contacts.GetListID(list.UUID)
+				listUUIDs = append(listUUIDs, list.UUID)
+				listIDs = append(listIDs, listID)
+			}
+			primary := models.Contact{UUID: uuid.New(), FirstName: "John", LastName: "Smith", Mobile: "+1555000111", Email: "john.old@example.com", CountryCode: "USA", ListIDs: listIDs[:1]}
+			first := models.Contact{UUID: uuid.New(), FirstName: "Jon", LastName: "Smith", Mobile: "+1555000222", Email: "john@example.com", CountryCode: "USA", ListIDs: listIDs[:2]}
+			second := models.Contact{UUID: uuid.New(), FirstName: "J.", LastName: "Smith", Mobile: "+381641234567", Email: "js@example.com", CountryCode: "SRB", ListIDs: listIDs[1:]}
+			for _, contact := range []models.Contact{primary, first, second} {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			// Memberships of a deleted list move as well.
+			if err := repos.lists.Delete(listUUIDs[2], true, 0); err != nil {
+				t.Fatalf("Could not delete test list: %v", err)
+			}
+
+			missing := models.ContactMerge{UUID: uuid.New(), PrimaryUUID: primary.UUID, SecondaryUUIDs: []uuid.UUID{first.UUID, uuid.New()}, FieldSources: map[string]uuid.UUID{}}
+			if err := repos.contacts.Merge(missing); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("Expected ErrRecordNotFound for a missing secondary, got %v", err)
+			}
+			if _, err := repos.contacts.GetByUUID(first.UUID); err != nil {
+				t.Fatalf("Expected a failed merge to leave the secondary, got %v", err)
+			}
+
+			merge := models.ContactMerge{
+				UUID:           uuid.New(),
+				PrimaryUUID:    primary.UUID,
+				SecondaryUUIDs: []uuid.UUID{first.UUID, second.UUID},
+				FieldSources:   map[string]uuid.UUID{"email": first.UUID, "mobile": second.UUID, "first_name": primary.UUID},
+			}
+			if err := repos.contacts.Merge(merge); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			merged, err := repos.contacts.GetByUUID(primary.UUID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if merged.FirstName != "John" || merged.Email != "john@example.com" || merged.Mobile != "+381641234567" || merged.CountryCode != "USA" || merged.Version != 2 {
+				t.Errorf("Expected the chosen fields on the primary at version 2, got %+v", merged)
+			}
+			if !reflect.DeepEqual(merged.ListUUIDs, listUUIDs[:2]) {
+				t.Errorf("Expected the primary on %v, got %v", listUUIDs[:2], merged.ListUUIDs)
+			}
+			if err := repos.lists.Restore(listUUIDs[2]); err != nil {
+				t.Fatalf("Could not restore test list: %v", err)
+			}
+			if merged, _ := repos.contacts.GetByUUID(primary.UUID); !reflect.DeepEqual(merged.ListUUIDs, listUUIDs) {
+				t.Errorf("Expected the restored list to include the primary, got %v", merged.ListUUIDs)
+			}
+			for _, secondary := range []models.Contact{first, second} {
+				if err := repos.contacts.Restore(secondary.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+					t.Errorf("Expected secondary %v to be gone, got %v", secondary.UUID, err)
+				}
+			}
+
+			merges, err := repos.contacts.GetMerges(primary.UUID)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(merges) != 1 || merges[0].UUID != merge.UUID || !reflect.DeepEqual(merges[0].FieldSources, merge.FieldSources) || !reflect.DeepEqual(merges[0].SecondaryUUIDs, merge.SecondaryUUIDs) {
+				t.Fatalf("Expected the merge to be recorded, got %+v", merges)
+			}
+			snapshot := merges[0].Contacts
+			if len(snapshot) != 3 || snapshot[0].Email != "john.old@example.com" || snapshot[1].Email != "john@example.com" || snapshot[2].FirstName != "J." {
+				t.Errorf("Expected the contacts as they were before the merge, got %+v", snapshot)
+			}
+			if merges, err := repos.contacts.GetMerges(first.UUID); err != nil || len(merges) != 0 {
+				t.Errorf("Expected no merges into a secondary, got %+v (%v)", merges, err)
+			}
+		})
+	}
+}
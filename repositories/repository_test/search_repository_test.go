@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestContactRepository_Search(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			family := models.List{UUID: uuid.New(), Name: "Savic family"}
+			if err := repos.lists.Create(family); err != nil {
+				t.Fatalf("Could not create test list: %v", err)
+			}
+			familyID, _ := repos.contacts.GetListID(family.UUID)
+			contacts := map[string]models.Contact{
+				"sara":    {UUID: uuid.New(), FirstName: "Sara", LastName: "Savic", Mobile: "+381641111111", Email: "sara.savic@example.com", CountryCode: "SRB"},
+				"marko":   {UUID: uuid.New(), FirstName: "Marko", LastName: "Sarac", Mobile: "+381642222222", Email: "marko.savo@example.com", CountryCode: "SRB", ListIDs: []uint{familyID}},
+				"savo":    {UUID: uuid.New(), FirstName: "Savo", LastName: "Saric", Mobile: "+381643333333", Email: "savo.saric@example.com", CountryCode: "SRB"},
+				"deleted": {UUID: uuid.New(), FirstName: "Sara", LastName: "Savic", Mobile: "+381644444444", Email: "old.sara@example.com", CountryCode: "SRB"},
+			}
+			for _, key := range []string{"sara", "marko", "savo", "deleted"} {
+				if err := repos.contacts.Create(contacts[key]); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+			if err := repos.contacts.Delete(contacts["deleted"].UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+
+			testCases := []struct {
+				name     string
+				terms    []string
+				limit    int
+				offset   int
+				expected []string
+				total    int64
+			}{
+				{name: "AnyWordOrder", terms: []string{"savic", "sara"}, expected: []string{"sara", "marko"}, total: 2},
+				{name: "CaseInsensitive", terms: []string{"SARA", "Savic"}, expected: []string{"sara", "marko"}, total: 2},
+				{name: "ListName", terms: []string{"family"}, expected: []string{"marko"}, total: 1},
+				{name: "Mobile", terms: []string{"+38164333"}, expected: []string{"savo"}, total: 1},
+				{name: "ExactBeforeContains", terms: []string{"savo"}, expected: []string{"savo", "marko"}, total: 2},
+				{name: "Prefix", terms: []string{"sa"}, expected: []string{"sara", "marko", "savo"}, total: 3},
+				{name: "Pagination", terms: []string{"sa"}, limit: 1, offset: 1, expected: []string{"marko"}, total: 3},
+				{name: "EveryTermMustMatch", terms: []string{"sara", "nobody"}, expected: []string{}, total: 0},
+				{name: "LikeWildcardsAreLiteral", terms: []string{"%"}, expected: []string{}, total: 0},
+			}
+			for _, tt := range testCases {
+				t.Run(tt.name, func(t *testing.T) {
+					results, total, err := repos.contacts.Search(tt.terms, tt.limit, tt.offset)
+					if err != nil {
+						t.Fatalf("Expected no error, got %v", err)
+					}
+					found := []string{}
+					for _, result := range results {
+						for key, contact := range contacts {
+							if contact.UUID == result.UUID {
+								found = append(found, key)
+							}
+						}
+					}
+					if !reflect.DeepEqual(found, tt.expected) || total != tt.total {
+						t.Errorf("Expected %v of %d, got %v of %d", tt.expected, tt.total, found, total)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestContactRepository_Sort(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			names := [][2]string{{"Ann", "Bravo"}, {"Zed", "Alpha"}, {"Zoe", "Bravo"}, {"Bob", "Charlie"}}
+			var created []uuid.UUID
+			for i, name := range names {
+				contact := models.Contact{UUID: uuid.New(), FirstName: name[0], LastName: name[1], Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "USA"}
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+				created = append(created, contact.UUID)
+			}
+
+			testCases := []struct {
+				name     string
+				sort     []repositories.SortField
+				expected []uuid.UUID
+			}{
+				{
+					name:     "Unsorted",
+					expected: created,
+				},
+				{
+					name:     "LastNameThenFirstNameDescending",
+					sort:     []repositories.SortField{{Column: "last_name"}, {Column: "first_name", Desc: true}},
+					expected: []uuid.UUID{created[1], created[2], created[0], created[3]},
+				},
+				{
+					name:     "TiesBrokenByInsertionOrder",
+					sort:     []repositories.SortField{{Column: "last_name", Desc: true}},
+					expected: []uuid.UUID{created[3], created[0], created[2], created[1]},
+				},
+			}
+			for _, tt := range testCases {
+				t.Run(tt.name, func(t *testing.T) {
+					contacts, _, err := repos.contacts.GetAll("", "", "", nil, false, tt.sort, 0, 0)
+					if err != nil {
+						t.Fatalf("Expected no error, got %v", err)
+					}
+					if got := contactUUIDs(contacts); !reflect.DeepEqual(got, tt.expected) {
+						t.Errorf("Expected %v, got %v", tt.expected, got)
+					}
+
+					page, _, err := repos.contacts.GetAll("", "", "", nil, false, tt.sort, 2, 1)
+					if err != nil {
+						t.Fatalf("Expected no error, got %v", err)
+					}
+					if got := contactUUIDs(page); !reflect.DeepEqual(got, tt.expected[1:3]) {
+						t.Errorf("Expected offset page %v, got %v", tt.expected[1:3], got)
+					}
+
+					var walked []models.Contact
+					var after repositories.Cursor
+					for range tt.expected {
+						next, err := repos.contacts.GetAllAfter("", "", "", nil, false, tt.sort, after, 1)
+						if err != nil || len(next) != 1 {
+							t.Fatalf("Expected one contact after %+v, got %d (%v)", after, len(next), err)
+						}
+						walked = append(walked, next...)
+						after = repositories.ContactCursor(next[0], tt.sort)
+					}
+					if got := contactUUIDs(walked); !reflect.DeepEqual(got, tt.expected) {
+						t.Errorf("Expected keyset scan %v, got %v", tt.expected, got)
+					}
+					if rest, err := repos.contacts.GetAllAfter("", "", "", nil, false, tt.sort, after, 1); err != nil || len(rest) != 0 {
+						t.Errorf("Expected the scan to end, got %d contacts (%v)", len(rest), err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestListRepository_Sort(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			for _, name := range []string{"Bravo", "Alpha", "Charlie"} {
+				if err := repos.lists.Create(models.List{UUID: uuid.New(), Name: name}); err != nil {
+					t.Fatalf("Could not create test list: %v", err)
+				}
+			}
+			sort := []repositories.SortField{{Column: "name", Desc: true}}
+			lists, _, err := repos.lists.GetAll("", false, sort, 0, 0)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := listNames(lists); !reflect.DeepEqual(got, []string{"Charlie", "Bravo", "Alpha"}) {
+				t.Errorf("Expected lists sorted by name descending, got %v", got)
+			}
+			after, err := repos.lists.GetAllAfter("", false, sort, repositories.ListCursor(lists[0], sort), 0)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := listNames(after); !reflect.DeepEqual(got, []string{"Bravo", "Alpha"}) {
+				t.Errorf("Expected lists after Charlie, got %v", got)
+			}
+		})
+	}
+}
+
+func contactUUIDs(contacts []models.Contact) []uuid.UUID {
+	uuids := make([]uuid.UUID, 0, len(contacts))
+	for _, contact := range contacts {
+		uuids = append(uuids, contact.UUID)
+	}
+	return uuids
+}
+
+func listNames(lists []models.List) []string {
+	names := make([]string, 0, len(lists))
+	for _, list := range lists {
+		names = append(names, list.Name)
+	}
+	return names
+}
+
+func TestContactRepository_GetAllAfterRowEditedBetweenPages(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			var created []uuid.UUID
+			for i := 0; i < 4; i++ {
+				contact := models.Contact{UUID: uuid.New(), FirstName: fmt.Sprintf("Contact%d", i), LastName: "Doe", Mobile: fmt.Sprintf("+123456789%d", i), Email: fmt.Sprintf("contact%d@example.com", i), CountryCode: "USA"}
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+				created = append(created, contact.UUID)
+				time.Sleep(5 * time.Millisecond)
+			}
+			edit := func(contactUUID uuid.UUID) {
+				t.Helper()
+				time.Sleep(5 * time.Millisecond)
+				contact, err := repos.contacts.GetByUUID(contactUUID)
+				if err != nil {
+					t.Fatalf("Could not get test contact: %v", err)
+				}
+				contact.FirstName += " Edited"
+				contact.ListIDs = nil
+				if err := repos.contacts.Update(*contact); err != nil {
+					t.Fatalf("Could not update test contact: %v", err)
+				}
+			}
+
+			// Ascending, the edited row moves behind the cursor and is seen
+			// again at the end instead of ending the scan.
+			sort := []repositories.SortField{{Column: "updated_at"}}
+			first, err := repos.contacts.GetAllAfter("", "", "", nil, false, sort, repositories.Cursor{}, 2)
+			if err != nil || len(first) != 2 {
+				t.Fatalf("Expected the first page, got %d contacts (%v)", len(first), err)
+			}
+			after := repositories.ContactCursor(first[1], sort)
+			edit(created[1])
+			rest, err := repos.contacts.GetAllAfter("", "", "", nil, false, sort, after, 0)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got, expected := contactUUIDs(rest), []uuid.UUID{created[2], created[3], created[1]}; !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %v after the edited row, got %v", expected, got)
+			}
+
+			// Descending, the edited row moves ahead of the cursor and is not
+			// repeated.
+			sort = []repositories.SortField{{Column: "updated_at", Desc: true}}
+			first, err = repos.contacts.GetAllAfter("", "", "", nil, false, sort, repositories.Cursor{}, 2)
+			if err != nil || len(first) != 2 {
+				t.Fatalf("Expected the first page, got %d contacts (%v)", len(first), err)
+			}
+			after = repositories.ContactCursor(first[1], sort)
+			edit(first[1].UUID)
+			rest, err = repos.contacts.GetAllAfter("", "", "", nil, false, sort, after, 0)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got, expected := contactUUIDs(rest), []uuid.UUID{created[2], created[0]}; !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %v after the edited row, got %v", expected, got)
+			}
+
+			for _, invalid := range []repositories.Cursor{
+				{ID: after.ID},
+				{ID: after.ID, Values: []string{"yesterday"}},
+				{ID: after.ID, Values: []string{after.Values[0], "Doe"}},
+			} {
+				if _, err := repos.contacts.GetAllAfter("", "", "", nil, false, sort, invalid, 0); !errors.Is(err, repositories.ErrInvalidCursor) {
+					t.Errorf("Expected ErrInvalidCursor for %+v, got %v", invalid, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type backendRepositories struct {
+	lists        repositories.ListRepository
+	contacts     repositories.ContactRepository
+	trash        repositories.TrashRepository
+	imports      repositories.ImportRepository
+	addressBooks repositories.AddressBookRepository
+}
+
+func repositoryBackends(t *testing.T) map[string]func() backendRepositories {
+	return map[string]func() backendRepositories{
+		"Gorm": func() backendRepositories {
+			db, cleanup := setTestDB(t)
+			t.Cleanup(cleanup)
+			return backendRepositories{repositories.NewListRepository(db), repositories.NewContactRepository(db), repositories.NewTrashRepository(db), repositories.NewImportRepository(db), repositories.NewAddressBookRepository(db)}
+		},
+		"Memory": func() backendRepositories {
+			store := repositories.NewMemoryStore()
+			return backendRepositories{repositories.NewMemoryListRepository(store), repositories.NewMemoryContactRepository(store), repositories.NewMemoryTrashRepository(store), repositories.NewMemoryImportRepository(store), repositories.NewMemoryAddressBookRepository(store)}
+		},
+	}
+}
+
+func TestTrashRepository(t *testing.T) {
+	for name, newRepos := range repositoryBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			repos := newRepos()
+
+			expiredList := models.List{UUID: uuid.New(), Name: "Expired"}
+			recentList := models.List{UUID: uuid.New(), Name: "Recent"}
+			for _, list := range []models.List{expiredList, recentList} {
+				if err := repos.lists.Create(list); err != nil {
+					t.Fatalf("Could not create test list: %v", err)
+				}
+			}
+			expiredListID, _ := repos.contacts.GetListID(expiredList.UUID)
+			recentListID, _ := repos.contacts.GetListID(recentList.UUID)
+			expiredContact := models.Contact{UUID: uuid.New(), FirstName: "Expired", LastName: "Contact", Mobile: "+1111111111", Email: "expired@example.com", CountryCode: "USA", ListIDs: []uint{expiredListID}}
+			recentContact := models.Contact{UUID: uuid.New(), FirstName: "Recent", LastName: "Contact", Mobile: "+2222222222", Email: "recent@example.com", CountryCode: "USA", ListIDs: []uint{recentListID}}
+			for _, contact := range []models.Contact{expiredContact, recentContact} {
+				if err := repos.contacts.Create(contact); err != nil {
+					t.Fatalf("Could not create test contact: %v", err)
+				}
+			}
+
+			if err := repos.lists.Delete(expiredList.UUID, true, 0); err != nil {
+				t.Fatalf("Could not delete test list: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			cutoff := time.Now()
+			time.Sleep(10 * time.Millisecond)
+			if err := repos.contacts.Delete(recentContact.UUID, 0); err != nil {
+				t.Fatalf("Could not delete test contact: %v", err)
+			}
+
+			items, err := repos.trash.GetAll(0, 0)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(items) != 3 {
+				t.Fatalf("Expected 3 items in the trash, got %+v", items)
+			}
+			if items[0].UUID != recentContact.UUID || items[0].Type != models.TrashItemContact || items[0].Name != "Recent Contact" {
+				t.Errorf("Expected most recently deleted contact first, got %+v", items[0])
+			}
+			page, err := repos.trash.GetAll(1, 1)
+			if err != nil || len(page) != 1 || page[0] != items[1] {
+				t.Errorf("Expected second item on page 2, got %+v (%v)", page, err)
+			}
+
+			item, err := repos.trash.GetByUUID(expiredList.UUID)
+			if err != nil || item.Type != models.TrashItemList {
+				t.Errorf("Expected deleted list in the trash, got %+v (%v)", item, err)
+			}
+			if _, err := repos.trash.GetByUUID(recentList.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Expected live list not to be in the trash, got %v", err)
+			}
+
+			purged, err := repos.trash.Purge(cutoff)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if purged != 2 {
+				t.Errorf("Expected 2 purged items, got %d", purged)
+			}
+			if err := repos.lists.Restore(expiredList.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Expected purged list to be gone, got %v", err)
+			}
+			if err := repos.contacts.Restore(expiredContact.UUID); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Expected purged contact to be gone, got %v", err)
+			}
+			items, err = repos.trash.GetAll(0, 0)
+			if err != nil || len(items) != 1 || items[0].UUID != recentContact.UUID {
+				t.Errorf("Expected only the recent contact to remain in the trash, got %+v (%v)", items, err)
+			}
+		})
+	}
+}
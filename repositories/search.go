@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Search returns live contacts where every term matches the name, email or
+// mobile of the contact or the name of one of its lists, in any order. The best
+// matches come first.
+func (c *contactRepository) Search(terms []string, limit, offset int) ([]models.Contact, int64, error) {
+	var (
+		query *gorm.DB
+		order clause.Expr
+	)
+	if c.db.Dialector.Name() == "mysql" {
+		query, order = c.fulltextSearch(terms)
+	} else {
+		query, order = c.likeSearch(terms)
+	}
+	if query == nil {
+		return []models.Contact{}, 0, nil
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Model(&models.Contact{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order.SQL += ", contacts.id"
+	query = query.Clauses(clause.OrderBy{Expression: order})
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var contacts []models.Contact
+	if err := query.Find(&contacts).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := c.loadLists(contacts); err != nil {
+		return nil, 0, err
+	}
+	return contacts, total, nil
+}
+
+// fulltextSearch uses the FULLTEXT indexes from migration 6, which match
+// words by prefix and rank by relevance. Terms without letters or digits
+// cannot be matched by the index and are left out.
+func (c *contactRepository) fulltextSearch(terms []string) (*gorm.DB, clause.Expr) {
+	query := c.db
+	var words []string
+	for _, term := range terms {
+		termWords := fulltextWords(term)
+		if len(termWords) == 0 {
+			continue
+		}
+		all := "+" + strings.Join(termWords, "* +") + "*"
+		query = query.Where("(MATCH(contacts.first_name, contacts.last_name, contacts.email, contacts.mobile) AGAINST (? IN BOOLEAN MODE)"+
+			" OR contacts.id IN (SELECT m.contact_id FROM list_memberships m JOIN lists l ON l.id = m.list_id"+
+			" WHERE l.deleted_at IS NULL AND MATCH(l.name) AGAINST (? IN BOOLEAN MODE)))", all, all)
+		words = append(words, termWords...)
+	}
+	if len(words) == 0 {
+		return nil, clause.Expr{}
+	}
+	return query, clause.Expr{
+		SQL:  "MATCH(contacts.first_name, contacts.last_name, contacts.email, contacts.mobile) AGAINST (? IN BOOLEAN MODE) DESC",
+		Vars: []interface{}{strings.Join(words, "* ") + "*"},
+	}
+}
+
+// likeSearch is the fallback for databases without a FULLTEXT index. Each term
+// scores 3 when it equals a field, 2 when a field starts with it and 1 when a
+// field only contains it, the same as searchScore for the memory store.
+func (c *contactRepository) likeSearch(terms []string) (*gorm.DB, clause.Expr) {
+	query := c.db
+	var (
+		scores []string
+		vars   []interface{}
+	)
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		escaped := likeEscaper.Replace(term)
+		contains := searchFieldsSQL("LIKE ? ESCAPE '!'")
+		query = query.Where(contains, repeat("%"+escaped+"%", 5)...)
+		scores = append(scores, "CASE WHEN "+searchFieldsSQL("= ?")+" THEN 3 WHEN "+searchFieldsSQL("LIKE ? ESCAPE '!'")+" THEN 2 ELSE 1 END")
+		vars = append(append(vars, repeat(term, 5)...), repeat(escaped+"%", 5)...)
+	}
+	if len(scores) == 0 {
+		return nil, clause.Expr{}
+	}
+	return query, clause.Expr{SQL: "(" + strings.Join(scores, " + ") + ") DESC", Vars: vars}
+}
+
+func searchFieldsSQL(operator string) string {
+	var fields []string
+	for _, column := range []string{"first_name", "last_name", "email", "mobile"} {
+		fields = append(fields, "LOWER(contacts."+column+") "+operator)
+	}
+	fields = append(fields, "EXISTS (SELECT 1 FROM list_memberships m JOIN lists l ON l.id = m.list_id"+
+		" WHERE m.contact_id = contacts.id AND l.deleted_at IS NULL AND LOWER(l.name) "+operator+")")
+	return "(" + strings.Join(fields, " OR ") + ")"
+}
+
+// fulltextWords splits a term the way the FULLTEXT parser does, which also
+// drops the characters that are operators in boolean mode.
+func fulltextWords(term string) []string {
+	return strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func repeat(value interface{}, count int) []interface{} {
+	values := make([]interface{}, count)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SortField orders a collection by a column. Callers only pass columns from
+// their allow-list; the id column is always added last to break ties.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+func orderBy(query *gorm.DB, sort []SortField) *gorm.DB {
+	for _, field := range sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: field.Column}, Desc: field.Desc})
+	}
+	return query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}})
+}
+
+// cursorRowExists makes sure the row of a cursor is still there, deleted or
+// not. A cursor whose row has been purged is older than the trash retention,
+// so it is rejected and the client starts the scan over.
+func cursorRowExists(db *gorm.DB, model interface{}, afterID uint) error {
+	if afterID == 0 {
+		return nil
+	}
+	var count int64
+	if err := db.Unscoped().Model(model).Where("id = ?", afterID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCursorNotFound
+	}
+	return nil
+}
+
+// Cursor is the position after a row in a sort order: the values the row had
+// in the sort columns, in the order of the sort, and its id. Pages continue
+// from these values rather than from the row as it is now, so editing the row
+// between two pages does not move the boundary.
+type Cursor struct {
+	ID     uint
+	Values []string
+}
+
+// ContactCursor returns the cursor after the contact.
+func ContactCursor(contact models.Contact, sort []SortField) Cursor {
+	return newCursor(contact, contact.ID, sort, contactSortValue)
+}
+
+// ListCursor returns the cursor after the list.
+func ListCursor(list models.List, sort []SortField) Cursor {
+	return newCursor(list, list.ID, sort, listSortValue)
+}
+
+func newCursor[T any](row T, id uint, sort []SortField, value func(T, string) interface{}) Cursor {
+	values := make([]string, len(sort))
+	for i, field := range sort {
+		switch v := value(row, field.Column).(type) {
+		case time.Time:
+			values[i] = v.Format(time.RFC3339Nano)
+		default:
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return Cursor{ID: id, Values: values}
+}
+
+// cursorValues turns the values of the cursor back into the types of the sort
+// columns, which are taken from the zero row, and appends its id. The zero
+// cursor, the start of the collection, has no values.
+func cursorValues[T any](after Cursor, sort []SortField, value func(T, string) interface{}) ([]interface{}, error) {
+	if after.ID == 0 {
+		return nil, nil
+	}
+	if len(after.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+	var zero T
+	values := make([]interface{}, 0, len(sort)+1)
+	for i, field := range sort {
+		switch value(zero, field.Column).(type) {
+		case time.Time:
+			t, err := time.Parse(time.RFC3339Nano, after.Values[i])
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values = append(values, t)
+		case uint:
+			n, err := strconv.ParseUint(after.Values[i], 10, 0)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values = append(values, uint(n))
+		default:
+			values = append(values, after.Values[i])
+		}
+	}
+	return append(values, after.ID), nil
+}
+
+// whereAfter keeps the rows that come after the cursor values, as returned by
+// cursorValues, in the given order.
+func whereAfter(query *gorm.DB, sort []SortField, after []interface{}) *gorm.DB {
+	if after == nil {
+		return query
+	}
+	fields := append(append([]SortField(nil), sort...), SortField{Column: "id"})
+	var (
+		alternatives []string
+		args         []interface{}
+	)
+	for i, field := range fields {
+		var parts []string
+		for j, equal := range fields[:i] {
+			parts = append(parts, "? = ?")
+			args = append(args, clause.Column{Name: equal.Column}, after[j])
+		}
+		operator := ">"
+		if field.Desc {
+			operator = "<"
+		}
+		parts = append(parts, "? "+operator+" ?")
+		args = append(args, clause.Column{Name: field.Column}, after[i])
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return query.Where(strings.Join(alternatives, " OR "), args...)
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"contact-list-api-1/models"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TrashRepository interface {
+	GetAll(limit, offset int) ([]models.TrashItem, error)
+	GetByUUID(uuid uuid.UUID) (*models.TrashItem, error)
+	Purge(before time.Time) (int64, error)
+}
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+func (t *trashRepository) GetAll(limit, offset int) ([]models.TrashItem, error) {
+	var lists []models.List
+	var contacts []models.Contact
+	query := t.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+	if limit > 0 {
+		// Both tables are read up to the end of the requested page and merged.
+		query = query.Limit(offset + limit)
+	}
+	query = query.Session(&gorm.Session{})
+	if err := query.Find(&lists).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]models.TrashItem, 0, len(lists)+len(contacts))
+	for _, list := range lists {
+		items = append(items, listTrashItem(list))
+	}
+	for _, contact := range contacts {
+		items = append(items, contactTrashItem(contact))
+	}
+	sortTrashItems(items)
+	start, end := paginate(len(items), limit, offset)
+	return items[start:end], nil
+}
+func (t *trashRepository) GetByUUID(uuid uuid.UUID) (*models.TrashItem, error) {
+	var list models.List
+	err := t.db.Unscoped().Where("uuid = ? AND deleted_at IS NOT NULL", uuid).First(&list).Error
+	if err == nil {
+		item := listTrashItem(list)
+		return &item, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var contact models.Contact
+	if err := t.db.Unscoped().Where("uuid = ? AND deleted_at IS NOT NULL", uuid).First(&contact).Error; err != nil {
+		return nil, err
+	}
+	item := contactTrashItem(contact)
+	return &item, nil
+}
+
+// Purge hard-deletes every list and contact deleted before the given time,
+// along with their memberships and duplicate keys, and returns how many of
+// them were removed.
+func (t *trashRepository) Purge(before time.Time) (int64, error) {
+	var purged int64
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		contacts := tx.Unscoped().Model(&models.Contact{}).Select("id").Where("deleted_at < ?", before)
+		lists := tx.Unscoped().Model(&models.List{}).Select("id").Where("deleted_at < ?", before)
+		if err := tx.Where("contact_id IN (?) OR list_id IN (?)", contacts, lists).Delete(&models.ListMembership{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contact_id IN (?)", contacts).Delete(&models.ContactDuplicateKey{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Contact{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+		result = tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.List{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+func listTrashItem(list models.List) models.TrashItem {
+	return models.TrashItem{Type: models.TrashItemList, UUID: list.UUID, Name: list.Name, DeletedAt: list.DeletedAt.Time}
+}
+
+func contactTrashItem(contact models.Contact) models.TrashItem {
+	return models.TrashItem{Type: models.TrashItemContact, UUID: contact.UUID, Name: contact.FirstName + " " + contact.LastName, DeletedAt: contact.DeletedAt.Time}
+}
+
+func sortTrashItems(items []models.TrashItem) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+}
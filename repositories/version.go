@@ -0,0 +1,20 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// bumpVersion increments the version of the rows matched by query. A non-zero
+// expected version must match the stored one, otherwise nothing is changed and
+// ErrVersionMismatch is returned.
+func bumpVersion(query *gorm.DB, expected uint) error {
+	if expected > 0 {
+		query = query.Where("version = ?", expected)
+	}
+	result := query.UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
+}
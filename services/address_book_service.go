@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSyncToken is returned for a sync token the list never had, or one
+// too old to be kept. The client has to sync the address book from scratch.
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// maxSyncSnapshots is how many sync tokens of a list stay valid.
+const maxSyncSnapshots = 50
+
+// AddressBookService serves lists as CardDAV address books, whose cards are the
+// members of the list.
+type AddressBookService interface {
+	GetAddressBooks() ([]models.List, error)
+	GetAddressBook(listUUID uuid.UUID) (*models.List, error)
+	GetCards(listUUID uuid.UUID, contactUUIDs []uuid.UUID) ([]models.Contact, error)
+	GetCard(listUUID, contactUUID uuid.UUID) (*models.Contact, error)
+	PutCard(listUUID uuid.UUID, contact models.Contact, version uint) (bool, error)
+	DeleteCard(listUUID, contactUUID uuid.UUID, version uint) error
+	SyncToken(listUUID uuid.UUID) (string, error)
+	GetChanges(listUUID uuid.UUID, token string, limit int) (*AddressBookChanges, error)
+}
+
+// AddressBookChanges are the cards changed and removed since a sync token.
+// Truncated is set when limit left some out; Token then covers only the
+// changes returned, so the client picks up the rest with it.
+type AddressBookChanges struct {
+	Changed   []models.Contact
+	Removed   []uuid.UUID
+	Token     string
+	Truncated bool
+}
+
+type addressBookService struct {
+	repo        repositories.AddressBookRepository
+	listRepo    repositories.ListRepository
+	contactRepo repositories.ContactRepository
+	contacts    ContactService
+}
+
+func NewAddressBookService(repo repositories.AddressBookRepository, listRepo repositories.ListRepository, contactRepo repositories.ContactRepository, contacts ContactService) AddressBookService {
+	return &addressBookService{repo: repo, listRepo: listRepo, contactRepo: contactRepo, contacts: contacts}
+}
+func (s *addressBookService) GetAddressBooks() ([]models.List, error) {
+	lists, _, err := s.listRepo.GetAll("", false, nil, 0, 0)
+	return lists, err
+}
+func (s *addressBookService) GetAddressBook(listUUID uuid.UUID) (*models.List, error) {
+	return s.listRepo.GetByUUID(listUUID)
+}
+
+// GetCards returns the members of the list, or only those among contactUUIDs
+// when it is not nil.
+func (s *addressBookService) GetCards(listUUID uuid.UUID, contactUUIDs []uuid.UUID) ([]models.Contact, error) {
+	listID, err := s.contactRepo.GetListID(listUUID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(listID, contactUUIDs)
+}
+func (s *addressBookService) GetCard(listUUID, contactUUID uuid.UUID) (*models.Contact, error) {
+	cards, err := s.GetCards(listUUID, []uuid.UUID{contactUUID})
+	if err != nil {
+		return nil, err
+	}
+	if len(cards) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &cards[0], nil
+}
+
+// PutCard creates the contact on the list, or replaces the fields of the
+// member with its UUID. A contact that exists but is not a member is updated
+// and added to the list, so a card can be copied between address books. A
+// non-zero version must be that of the member. It reports whether the card is
+// new to the list.
+func (s *addressBookService) PutCard(listUUID uuid.UUID, contact models.Contact, version uint) (bool, error) {
+	listID, err := s.contactRepo.GetListID(listUUID)
+	if err != nil {
+		return false, err
+	}
+	existing, err := s.contactRepo.GetByUUID(contact.UUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if version > 0 {
+			return false, repositories.ErrVersionMismatch
+		}
+		contact.ListUUIDs = []uuid.UUID{listUUID}
+		return true, s.contacts.CreateContact(contact)
+	}
+	if err != nil {
+		return false, err
+	}
+	member := slices.Contains(existing.ListIDs, listID)
+	if version > 0 && !member {
+		return false, repositories.ErrVersionMismatch
+	}
+	// The lists are only needed for validation; Update leaves the memberships
+	// alone when ListIDs is nil.
+	contact.ListUUIDs = existing.ListUUIDs
+	if !member {
+		contact.ListUUIDs = append(slices.Clone(contact.ListUUIDs), listUUID)
+	}
+	if validationErrors := validateContact(s.contactRepo, contact, existing.ID); validationErrors != nil {
+		return false, validationErrors
+	}
+	contact.Version = version
+	contact.ListIDs = nil
+	if err := s.contactRepo.Update(contact); err != nil {
+		return false, err
+	}
+	if member {
+		return false, nil
+	}
+	return true, s.contactRepo.AddToList(existing.ID, listID, models.MembershipSourceAPI)
+}
+
+// DeleteCard removes the contact from the list. The contact itself is kept,
+// as it is by RemoveContactFromList.
+func (s *addressBookService) DeleteCard(listUUID, contactUUID uuid.UUID, version uint) error {
+	card, err := s.GetCard(listUUID, contactUUID)
+	if err != nil {
+		return err
+	}
+	if version > 0 && card.Version != version {
+		return repositories.ErrVersionMismatch
+	}
+	listID, err := s.contactRepo.GetListID(listUUID)
+	if err != nil {
+		return err
+	}
+	return s.contactRepo.RemoveFromList(card.ID, listID)
+}
+
+// SyncToken returns the token of the list as it is now.
+func (s *addressBookService) SyncToken(listUUID uuid.UUID) (string, error) {
+	cards, err := s.GetCards(listUUID, nil)
+	if err != nil {
+		return "", err
+	}
+	return s.issueToken(listUUID, versions(cards))
+}
+
+// GetChanges compares the list with the snapshot of token, or with an empty
+// list when token is empty. A positive limit caps the number of changes.
+func (s *addressBookService) GetChanges(listUUID uuid.UUID, token string, limit int) (*AddressBookChanges, error) {
+	cards, err := s.GetCards(listUUID, nil)
+	if err != nil {
+		return nil, err
+	}
+	synced := make(map[uuid.UUID]uint)
+	if token != "" {
+		snapshot, err := s.repo.GetSnapshot(listUUID, token)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSyncToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(synced, snapshot.Members)
+	}
+
+	current := versions(cards)
+	changes := &AddressBookChanges{}
+	for _, card := range cards {
+		if version, ok := synced[card.UUID]; !ok || version != card.Version {
+			changes.Changed = append(changes.Changed, card)
+		}
+	}
+	for contactUUID := range synced {
+		if _, ok := current[contactUUID]; !ok {
+			changes.Removed = append(changes.Removed, contactUUID)
+		}
+	}
+	slices.SortFunc(changes.Removed, compareUUIDs)
+
+	if limit > 0 && len(changes.Changed)+len(changes.Removed) > limit {
+		changes.Truncated = true
+		changes.Changed = changes.Changed[:min(limit, len(changes.Changed))]
+		changes.Removed = changes.Removed[:limit-len(changes.Changed)]
+		// The token given out is the synced state with the returned changes
+		// applied.
+		for _, card := range changes.Changed {
+			synced[card.UUID] = card.Version
+		}
+		for _, contactUUID := range changes.Removed {
+			delete(synced, contactUUID)
+		}
+		current = synced
+	}
+	if changes.Token, err = s.issueToken(listUUID, current); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// issueToken stores the snapshot of members under a token derived from them.
+func (s *addressBookService) issueToken(listUUID uuid.UUID, members map[uuid.UUID]uint) (string, error) {
+	contactUUIDs := make([]uuid.UUID, 0, len(members))
+	for contactUUID := range members {
+		contactUUIDs = append(contactUUIDs, contactUUID)
+	}
+	slices.SortFunc(contactUUIDs, compareUUIDs)
+	hash := sha256.New()
+	for _, contactUUID := range contactUUIDs {
+		fmt.Fprintf(hash, "%s:%d\n", contactUUID, members[contactUUID])
+	}
+	token := hex.EncodeToString(hash.Sum(nil))[:32]
+
+	snapshot := models.AddressBookSnapshot{ListUUID: listUUID, Token: token, Members: members, IssuedAt: time.Now()}
+	if err := s.repo.SaveSnapshot(snapshot); err != nil {
+		return "", err
+	}
+	if err := s.repo.PruneSnapshots(listUUID, maxSyncSnapshots); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func compareUUIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func versions(cards []models.Contact) map[uuid.UUID]uint {
+	members := make(map[uuid.UUID]uint, len(cards))
+	for _, card := range cards {
+		members[card.UUID] = card.Version
+	}
+	return members
+}
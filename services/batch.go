@@ -0,0 +1,153 @@
+package services
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxBatchOperations keeps a batch small enough to validate from a handful of
+// queries and to hold in one transaction.
+const maxBatchOperations = 1000
+
+// BatchContacts validates all operations against the contacts and lists as they
+// were before the batch, then hands the valid ones to the repository. The
+// returned errors line up with operations. Without atomic the valid operations
+// are applied even if others fail; with atomic a single failure rejects the
+// whole batch and every other operation reports repositories.ErrRolledBack.
+// Creates without a UUID get a new one in operations.
+func (s *contactService) BatchContacts(operations []repositories.ContactOperation, atomic bool) ([]error, error) {
+	if len(operations) == 0 {
+		return nil, NewValidationErrors([]ValidationError{{Field: "operations", Message: "At least one operation is required"}})
+	}
+	if len(operations) > maxBatchOperations {
+		return nil, NewValidationErrors([]ValidationError{{Field: "operations", Message: fmt.Sprintf("A batch can have at most %d operations", maxBatchOperations)}})
+	}
+	lookup, existing, err := s.loadBatch(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(operations))
+	var valid []int
+	for i := range operations {
+		if errs[i] = validateOperation(lookup, existing, &operations[i]); errs[i] == nil {
+			valid = append(valid, i)
+		}
+	}
+	if atomic && len(valid) < len(operations) {
+		for _, i := range valid {
+			errs[i] = repositories.ErrRolledBack
+		}
+		return errs, nil
+	}
+
+	batch := make([]repositories.ContactOperation, len(valid))
+	for j, i := range valid {
+		batch[j] = operations[i]
+	}
+	batchErrs, err := s.repo.Batch(batch, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range valid {
+		errs[i] = batchErrs[j]
+	}
+	return errs, nil
+}
+
+// loadBatch fetches everything validateOperation needs in one query per kind,
+// instead of several queries per operation.
+func (s *contactService) loadBatch(operations []repositories.ContactOperation) (*batchLookup, map[uuid.UUID]models.Contact, error) {
+	var (
+		uuids, listUUIDs []uuid.UUID
+		emails, mobiles  []string
+	)
+	for _, operation := range operations {
+		if operation.Op != repositories.BatchCreate {
+			uuids = append(uuids, operation.Contact.UUID)
+		}
+		if operation.Op != repositories.BatchDelete {
+			emails = append(emails, operation.Contact.Email)
+			mobiles = append(mobiles, operation.Contact.Mobile)
+			listUUIDs = append(listUUIDs, operation.Contact.ListUUIDs...)
+		}
+	}
+	existing, err := s.repo.GetByUUIDs(uuids)
+	if err != nil {
+		return nil, nil, err
+	}
+	lookup := &batchLookup{}
+	if lookup.emails, err = s.repo.FindByEmails(emails); err != nil {
+		return nil, nil, err
+	}
+	if lookup.mobiles, err = s.repo.FindByMobiles(mobiles); err != nil {
+		return nil, nil, err
+	}
+	if lookup.listIDs, err = s.repo.GetListIDs(listUUIDs); err != nil {
+		return nil, nil, err
+	}
+	return lookup, existing, nil
+}
+
+// validateOperation checks an operation like the single contact endpoints do
+// and resolves the list IDs of the contact.
+func validateOperation(lookup *batchLookup, existing map[uuid.UUID]models.Contact, operation *repositories.ContactOperation) error {
+	var ownID uint
+	switch operation.Op {
+	case repositories.BatchCreate:
+		if operation.Contact.UUID == uuid.Nil {
+			operation.Contact.UUID = uuid.New()
+		}
+	case repositories.BatchUpdate, repositories.BatchDelete:
+		if operation.Contact.UUID == uuid.Nil {
+			return NewValidationErrors([]ValidationError{{Field: "uuid", Message: "UUID is required"}})
+		}
+		contact, ok := existing[operation.Contact.UUID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		if operation.Op == repositories.BatchDelete {
+			return nil
+		}
+		ownID = contact.ID
+	default:
+		return NewValidationErrors([]ValidationError{{Field: "op", Message: fmt.Sprintf("Unknown operation %q, expected create, update or delete", operation.Op)}})
+	}
+
+	if validationErrors := validateContact(lookup, operation.Contact, ownID); validationErrors != nil {
+		return validationErrors
+	}
+	return resolveListIDs(lookup, &operation.Contact)
+}
+
+type batchLookup struct {
+	emails  map[string]models.Contact
+	mobiles map[string]models.Contact
+	listIDs map[uuid.UUID]uint
+}
+
+func (l *batchLookup) FindByEmail(email string) (*models.Contact, error) {
+	return findLoaded(l.emails, email)
+}
+func (l *batchLookup) FindByMobile(mobile string) (*models.Contact, error) {
+	return findLoaded(l.mobiles, mobile)
+}
+func (l *batchLookup) GetListID(listUUID uuid.UUID) (uint, error) {
+	listID, ok := l.listIDs[listUUID]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return listID, nil
+}
+
+func findLoaded(contacts map[string]models.Contact, key string) (*models.Contact, error) {
+	contact, ok := contacts[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &contact, nil
+}
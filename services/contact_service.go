@@ -3,18 +3,37 @@ package services
 import (
 	"contact-list-api-1/models"
 	"contact-list-api-1/repositories"
+	"contact-list-api-1/services/filter"
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ContactService interface {
-	GetAllContacts(name, mobile, email string, page, pageSize int) ([]models.Contact, error)
+	GetAllContacts(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, page, pageSize int) ([]models.Contact, int64, error)
+	GetContactsAfter(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, cursor string, pageSize int) ([]models.Contact, string, error)
+	SearchContacts(query string, page, pageSize int) ([]models.Contact, int64, error)
+	ExportContacts(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, fn func(models.Contact) error) error
+	ExportListContacts(listUUID uuid.UUID, name, mobile, email, filterExpression string, sort []repositories.SortField, fn func(models.Contact) error) error
+	FindDuplicates(minScore float64, page, pageSize int) ([]DuplicateGroup, int64, error)
+	FindDuplicatesOf(contactUUID uuid.UUID, minScore float64) ([]DuplicateMatch, error)
+	MergeContacts(primaryUUID uuid.UUID, secondaryUUIDs []uuid.UUID, fieldSources map[string]uuid.UUID) (*models.Contact, error)
+	GetContactMerges(contactUUID uuid.UUID) ([]models.ContactMerge, error)
 	GetContactByUUID(uuid uuid.UUID) (*models.Contact, error)
 	CreateContact(contact models.Contact) error
 	UpdateContact(contact models.Contact) error
-	DeleteContact(uuid uuid.UUID) error
+	DeleteContact(uuid uuid.UUID, version uint) error
+	RestoreContact(uuid uuid.UUID) error
+	GetContactsByList(listUUID uuid.UUID, name, mobile, email, filterExpression string, sort []repositories.SortField, page, pageSize int) ([]models.Contact, int64, error)
+	CreateContactInList(listUUID uuid.UUID, contact models.Contact) error
+	AddContactToList(listUUID, contactUUID uuid.UUID) error
+	RemoveContactFromList(listUUID, contactUUID uuid.UUID) error
+	BatchContacts(operations []repositories.ContactOperation, atomic bool) ([]error, error)
 }
 
 type contactService struct {
@@ -24,13 +43,127 @@ type contactService struct {
 func NewContactService(repo repositories.ContactRepository) ContactService {
 	return &contactService{repo: repo}
 }
-func (s *contactService) GetAllContacts(name, mobile, email string, page, pageSize int) ([]models.Contact, error) {
+func (s *contactService) GetAllContacts(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, page, pageSize int) ([]models.Contact, int64, error) {
+	where, err := parseContactFilter(filterExpression)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	contacts, total, err := s.repo.GetAll(name, mobile, email, where, includeDeleted, sort, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return contacts, total, nil
+}
+
+// GetContactsAfter returns the page after cursor and the cursor of the next
+// page, which is empty on the last page.
+func (s *contactService) GetContactsAfter(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, cursorValue string, pageSize int) ([]models.Contact, string, error) {
+	where, err := parseContactFilter(filterExpression)
+	if err != nil {
+		return nil, "", err
+	}
+	after, err := decodeCursor(cursorValue, sort)
+	if err != nil {
+		return nil, "", err
+	}
+	contacts, err := s.repo.GetAllAfter(name, mobile, email, where, includeDeleted, sort, after, pageSize+1)
+	if errors.Is(err, repositories.ErrCursorNotFound) || errors.Is(err, repositories.ErrInvalidCursor) {
+		return nil, "", ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(contacts) <= pageSize {
+		return contacts, "", nil
+	}
+	contacts = contacts[:pageSize]
+	return contacts, encodeCursor(repositories.ContactCursor(contacts[pageSize-1], sort), sort), nil
+}
+
+// maxSearchTerms keeps a search from growing into an arbitrarily large query,
+// since every term adds its own condition.
+const maxSearchTerms = 10
+
+// SearchContacts splits query into terms on whitespace, so the terms can match
+// in any order.
+func (s *contactService) SearchContacts(query string, page, pageSize int) ([]models.Contact, int64, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, 0, NewValidationErrors([]ValidationError{{Field: "q", Message: "Search query is required"}})
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, 0, NewValidationErrors([]ValidationError{{Field: "q", Message: fmt.Sprintf("Search query can have at most %d terms", maxSearchTerms)}})
+	}
 	offset := (page - 1) * pageSize
-	contacts, err := s.repo.GetAll(name, mobile, email, pageSize, offset)
+	return s.repo.Search(terms, pageSize, offset)
+}
+
+// ExportContacts passes every contact matching the filters of GetAllContacts to
+// fn, without pages.
+func (s *contactService) ExportContacts(name, mobile, email, filterExpression string, includeDeleted bool, sort []repositories.SortField, fn func(models.Contact) error) error {
+	where, err := parseContactFilter(filterExpression)
+	if err != nil {
+		return err
+	}
+	return s.repo.Export(0, name, mobile, email, where, includeDeleted, sort, fn)
+}
+func (s *contactService) ExportListContacts(listUUID uuid.UUID, name, mobile, email, filterExpression string, sort []repositories.SortField, fn func(models.Contact) error) error {
+	where, err := parseContactFilter(filterExpression)
+	if err != nil {
+		return err
+	}
+	listID, err := s.repo.GetListID(listUUID)
 	if err != nil {
+		return err
+	}
+	return s.repo.Export(listID, name, mobile, email, where, false, sort, fn)
+}
+
+// MergeContacts merges the secondary contacts into the primary one. Fields
+// missing from fieldSources keep the value of the primary.
+func (s *contactService) MergeContacts(primaryUUID uuid.UUID, secondaryUUIDs []uuid.UUID, fieldSources map[string]uuid.UUID) (*models.Contact, error) {
+	var errs []ValidationError
+	if primaryUUID == uuid.Nil {
+		errs = append(errs, ValidationError{Field: "primary_uuid", Message: "Primary UUID is required"})
+	}
+	participants := map[uuid.UUID]bool{primaryUUID: true}
+	if len(secondaryUUIDs) == 0 {
+		errs = append(errs, ValidationError{Field: "secondary_uuids", Message: "At least one secondary UUID is required"})
+	}
+	for _, secondaryUUID := range secondaryUUIDs {
+		if participants[secondaryUUID] {
+			errs = append(errs, ValidationError{Field: "secondary_uuids", Message: fmt.Sprintf("%v is given more than once or is the primary", secondaryUUID)})
+		}
+		participants[secondaryUUID] = true
+	}
+	fields := make([]string, 0, len(fieldSources))
+	for field := range fieldSources {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	for _, field := range fields {
+		if !slices.Contains(repositories.MergeFields, field) {
+			errs = append(errs, ValidationError{Field: "fields", Message: fmt.Sprintf("Unknown field %q, expected one of %s", field, strings.Join(repositories.MergeFields, ", "))})
+		} else if !participants[fieldSources[field]] {
+			errs = append(errs, ValidationError{Field: "fields", Message: fmt.Sprintf("%s has to come from the primary or a secondary contact", field)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, NewValidationErrors(errs)
+	}
+
+	if fieldSources == nil {
+		fieldSources = map[string]uuid.UUID{}
+	}
+	merge := models.ContactMerge{UUID: uuid.New(), PrimaryUUID: primaryUUID, SecondaryUUIDs: secondaryUUIDs, FieldSources: fieldSources}
+	if err := s.repo.Merge(merge); err != nil {
 		return nil, err
 	}
-	return contacts, nil
+	return s.repo.GetByUUID(primaryUUID)
+}
+func (s *contactService) GetContactMerges(contactUUID uuid.UUID) ([]models.ContactMerge, error) {
+	return s.repo.GetMerges(contactUUID)
 }
 func (s *contactService) GetContactByUUID(uuid uuid.UUID) (*models.Contact, error) {
 	contact, err := s.repo.GetByUUID(uuid)
@@ -41,10 +174,13 @@ func (s *contactService) GetContactByUUID(uuid uuid.UUID) (*models.Contact, erro
 }
 func (s *contactService) CreateContact(contact models.Contact) error {
 
-	validationErrors := s.validateContact(models.Contact{}, contact, false)
+	validationErrors := validateContact(s.repo, contact, 0)
 	if validationErrors != nil {
 		return validationErrors
 	}
+	if err := resolveListIDs(s.repo, &contact); err != nil {
+		return err
+	}
 
 	return s.repo.Create(contact)
 }
@@ -57,13 +193,16 @@ func (s *contactService) UpdateContact(contact models.Contact) error {
 		return errors.New("contact not found")
 	}
 
-	validationErrors := s.validateContact(*existingContact, contact, true)
+	validationErrors := validateContact(s.repo, contact, existingContact.ID)
 	if validationErrors != nil {
 		return validationErrors
 	}
+	if err := resolveListIDs(s.repo, &contact); err != nil {
+		return err
+	}
 	return s.repo.Update(contact)
 }
-func (s *contactService) DeleteContact(uuid uuid.UUID) error {
+func (s *contactService) DeleteContact(uuid uuid.UUID, version uint) error {
 	existingContact, err := s.repo.GetByUUID(uuid)
 	if err != nil {
 		return err
@@ -71,89 +210,139 @@ func (s *contactService) DeleteContact(uuid uuid.UUID) error {
 	if existingContact == nil {
 		return errors.New("contact not found")
 	}
-	return s.repo.Delete(uuid)
+	return s.repo.Delete(uuid, version)
+}
+func (s *contactService) RestoreContact(uuid uuid.UUID) error {
+	return s.repo.Restore(uuid)
+}
+func (s *contactService) GetContactsByList(listUUID uuid.UUID, name, mobile, email, filterExpression string, sort []repositories.SortField, page, pageSize int) ([]models.Contact, int64, error) {
+	where, err := parseContactFilter(filterExpression)
+	if err != nil {
+		return nil, 0, err
+	}
+	listID, err := s.repo.GetListID(listUUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	return s.repo.GetAllByList(listID, name, mobile, email, where, sort, pageSize, offset)
+}
+func (s *contactService) CreateContactInList(listUUID uuid.UUID, contact models.Contact) error {
+	if _, err := s.repo.GetListID(listUUID); err != nil {
+		return err
+	}
+	contact.ListUUIDs = append(contact.ListUUIDs, listUUID)
+	return s.CreateContact(contact)
+}
+func (s *contactService) AddContactToList(listUUID, contactUUID uuid.UUID) error {
+	listID, err := s.repo.GetListID(listUUID)
+	if err != nil {
+		return err
+	}
+	contact, err := s.repo.GetByUUID(contactUUID)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddToList(contact.ID, listID, models.MembershipSourceAPI)
+}
+func (s *contactService) RemoveContactFromList(listUUID, contactUUID uuid.UUID) error {
+	listID, err := s.repo.GetListID(listUUID)
+	if err != nil {
+		return err
+	}
+	contact, err := s.repo.GetByUUID(contactUUID)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveFromList(contact.ID, listID)
 }
-func (s *contactService) validateContact(existingContact, contact models.Contact, isUpdate bool) *ValidationErrors {
-	var errs []ValidationError
 
-	if isUpdate {
-
-		if contact.Email != "" && contact.Email != existingContact.Email {
-			if !isValidEmail(contact.Email) {
-				errs = append(errs, ValidationError{Field: "Email", Message: "invalid email format"})
-			}
-			if isUnique, err := s.isEmailUnique(contact.Email); err != nil {
-				errs = append(errs, ValidationError{Field: "Email", Message: "error checking email uniqueness"})
-			} else if !isUnique {
-				errs = append(errs, ValidationError{Field: "Email", Message: "email already exists"})
-			}
-		}
+// contactLookup answers the queries validateContact needs. The repository
+// answers them one at a time, a batch loads them all up front.
+type contactLookup interface {
+	FindByEmail(email string) (*models.Contact, error)
+	FindByMobile(mobile string) (*models.Contact, error)
+	GetListID(listUUID uuid.UUID) (uint, error)
+}
 
-		if contact.Mobile != "" && contact.Mobile != existingContact.Mobile {
-			if !isValidMobile(contact.Mobile) {
-				errs = append(errs, ValidationError{Field: "Mobile", Message: "invalid mobile format"})
-			}
-			if isUnique, err := s.isMobileUnique(contact.Mobile); err != nil {
-				errs = append(errs, ValidationError{Field: "Mobile", Message: "error checking mobile uniqueness"})
-			} else if !isUnique {
-				errs = append(errs, ValidationError{Field: "Mobile", Message: "mobile already exists"})
-			}
-		}
+// validateContact checks a complete contact, both on create and on update,
+// which replaces the whole record. ownID is the ID of the contact being
+// updated, so its own email and mobile do not count as taken.
+func validateContact(lookup contactLookup, contact models.Contact, ownID uint) *ValidationErrors {
+	var errs []ValidationError
 
-		if contact.CountryCode != "" && contact.CountryCode != existingContact.CountryCode {
-			if len(contact.CountryCode) != 3 {
-				errs = append(errs, ValidationError{Field: "CountryCode", Message: "country code must be exactly 3 characters long"})
-			}
-		}
+	if contact.FirstName == "" {
+		errs = append(errs, ValidationError{Field: "FirstName", Message: "first name cannot be empty"})
 
-	} else {
-		if contact.FirstName == "" {
-			errs = append(errs, ValidationError{Field: "FirstName", Message: "first name cannot be empty"})
+	}
+	if contact.LastName == "" {
+		errs = append(errs, ValidationError{Field: "LastName", Message: "last name cannot be empty"})
 
-		}
-		if contact.LastName == "" {
-			errs = append(errs, ValidationError{Field: "LastName", Message: "last name cannot be empty"})
+	}
+	if contact.Email == "" || !isValidEmail(contact.Email) {
+		errs = append(errs, ValidationError{Field: "Email", Message: "invalid email format"})
 
-		}
-		if contact.Email == "" || !isValidEmail(contact.Email) {
-			errs = append(errs, ValidationError{Field: "Email", Message: "invalid email format"})
+	}
+	if contact.Mobile == "" || !isValidMobile(contact.Mobile) {
+		errs = append(errs, ValidationError{Field: "Mobile", Message: "invalid mobile format"})
+	}
+	if len(contact.CountryCode) != 3 {
+		errs = append(errs, ValidationError{Field: "CountryCode", Message: "country code must be exactly 3 characters long"})
+	}
+	if isUnique, err := isEmailUnique(lookup, contact.Email, ownID); err != nil {
+		errs = append(errs, ValidationError{Field: "Email", Message: "error checking email uniqueness"})
+	} else if !isUnique {
+		errs = append(errs, ValidationError{Field: "Email", Message: "email already exists"})
+	}
 
-		}
-		if contact.Mobile == "" || !isValidMobile(contact.Mobile) {
-			errs = append(errs, ValidationError{Field: "Mobile", Message: "invalid mobile format"})
-		}
-		if len(contact.CountryCode) != 3 {
-			errs = append(errs, ValidationError{Field: "CountryCode", Message: "country code must be exactly 3 characters long"})
-		}
-		if contact.ListID == 0 {
-			errs = append(errs, ValidationError{Field: "ListID", Message: "contact must belong to a list"})
-		}
-		if isUnique, err := s.isEmailUnique(contact.Email); err != nil {
-			errs = append(errs, ValidationError{Field: "Email", Message: "error checking email uniqueness"})
-		} else if !isUnique {
-			errs = append(errs, ValidationError{Field: "Email", Message: "email already exists"})
-		}
+	if isUnique, err := isMobileUnique(lookup, contact.Mobile, ownID); err != nil {
+		errs = append(errs, ValidationError{Field: "Mobile", Message: "error checking mobile uniqueness"})
+	} else if !isUnique {
+		errs = append(errs, ValidationError{Field: "Mobile", Message: "mobile already exists"})
+	}
 
-		if isUnique, err := s.isMobileUnique(contact.Mobile); err != nil {
-			errs = append(errs, ValidationError{Field: "Mobile", Message: "error checking mobile uniqueness"})
-		} else if !isUnique {
-			errs = append(errs, ValidationError{Field: "Mobile", Message: "mobile already exists"})
-		}
-		if contact.ListID != 0 {
-			exists, err := s.repo.ListExists(contact.ListID)
-			if err != nil {
-				errs = append(errs, ValidationError{Field: "ListID", Message: "error checking list existence"})
-			} else if !exists {
-				errs = append(errs, ValidationError{Field: "ListID", Message: "the associated list does not exist"})
-			}
+	if len(contact.ListUUIDs) == 0 {
+		errs = append(errs, ValidationError{Field: "ListUUIDs", Message: "contact must belong to at least one list"})
+	}
+	for _, listUUID := range contact.ListUUIDs {
+		if _, err := lookup.GetListID(listUUID); errors.Is(err, gorm.ErrRecordNotFound) {
+			errs = append(errs, ValidationError{Field: "ListUUIDs", Message: fmt.Sprintf("the associated list %v does not exist", listUUID)})
+		} else if err != nil {
+			errs = append(errs, ValidationError{Field: "ListUUIDs", Message: "error checking list existence"})
 		}
-
 	}
 	if len(errs) > 0 {
 		return NewValidationErrors(errs)
 	}
 	return nil
 }
+func resolveListIDs(lookup contactLookup, contact *models.Contact) error {
+	if contact.ListUUIDs == nil {
+		contact.ListIDs = nil
+		return nil
+	}
+	listIDs := make([]uint, 0, len(contact.ListUUIDs))
+	for _, listUUID := range contact.ListUUIDs {
+		listID, err := lookup.GetListID(listUUID)
+		if err != nil {
+			return err
+		}
+		listIDs = append(listIDs, listID)
+	}
+	contact.ListIDs = listIDs
+	return nil
+}
+
+// parseContactFilter reports syntax errors as ValidationErrors on the filter
+// parameter.
+func parseContactFilter(expression string) (filter.Node, error) {
+	where, err := filter.Parse(expression, filter.ContactFields)
+	var syntaxErr *filter.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, NewValidationErrors([]ValidationError{{Field: "filter", Message: syntaxErr.Error()}})
+	}
+	return where, err
+}
 func isValidEmail(email string) bool {
 	re := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
 	return re.MatchString(email)
@@ -162,18 +351,25 @@ func isValidMobile(mobile string) bool {
 	re := regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
 	return re.MatchString(mobile)
 }
-func (s *contactService) isEmailUnique(email string) (bool, error) {
-	contacts, err := s.repo.GetAll("", "", email, 1, 0)
-	if err != nil {
-		return false, err
-	}
-	return len(contacts) == 0, nil
+
+// Deleted contacts still hold their email and mobile until they are purged.
+// ownID is the contact being updated, which may keep its own values.
+func isEmailUnique(lookup contactLookup, email string, ownID uint) (bool, error) {
+	contact, err := lookup.FindByEmail(email)
+	return isOwnOrMissing(contact, err, ownID)
 }
 
-func (s *contactService) isMobileUnique(mobile string) (bool, error) {
-	contacts, err := s.repo.GetAll("", mobile, "", 1, 0)
+func isMobileUnique(lookup contactLookup, mobile string, ownID uint) (bool, error) {
+	contact, err := lookup.FindByMobile(mobile)
+	return isOwnOrMissing(contact, err, ownID)
+}
+
+func isOwnOrMissing(contact *models.Contact, err error, ownID uint) (bool, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
 	if err != nil {
 		return false, err
 	}
-	return len(contacts) == 0, nil
+	return contact.ID == ownID, nil
 }
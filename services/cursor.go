@@ -0,0 +1,58 @@
+package services
+
+import (
+	"contact-list-api-1/repositories"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor is also returned for a cursor whose row has been purged,
+// and for one whose values do not fit the sort order it claims.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last item of a page: its id and its values
+// in the sort columns. Clients treat the encoded form as opaque, so fields can
+// be added without breaking them.
+type cursor struct {
+	ID     uint     `json:"id"`
+	Sort   string   `json:"sort,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+func encodeCursor(after repositories.Cursor, sort []repositories.SortField) string {
+	data, _ := json.Marshal(cursor{ID: after.ID, Sort: sortKey(sort), Values: after.Values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the zero cursor, the start of the collection, for an
+// empty value. A cursor is only valid for the sort order it was issued for and
+// needs a value for every sort column; the repository checks the values
+// themselves.
+func decodeCursor(value string, sort []repositories.SortField) (repositories.Cursor, error) {
+	if value == "" {
+		return repositories.Cursor{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return repositories.Cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == 0 || c.Sort != sortKey(sort) || len(c.Values) != len(sort) {
+		return repositories.Cursor{}, ErrInvalidCursor
+	}
+	return repositories.Cursor{ID: c.ID, Values: c.Values}, nil
+}
+
+func sortKey(sort []repositories.SortField) string {
+	fields := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Desc {
+			fields = append(fields, "-"+field.Column)
+		} else {
+			fields = append(fields, field.Column)
+		}
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,97 @@
+// Package duplicate normalizes contacts for duplicate detection. The contact
+// service scores pairs of keys, and the repositories index the blocks of every
+// contact so that candidates are looked up instead of comparing the whole
+// table pair by pair.
+package duplicate
+
+import (
+	"contact-list-api-1/models"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The kinds of block a contact is indexed under.
+const (
+	BlockEmail  = "email"
+	BlockMobile = "mobile"
+	BlockName   = "name"
+)
+
+// Key holds the fields of a contact as they are compared.
+type Key struct {
+	NameTokens []string
+	Name       string
+	Email      string
+	Mobile     string
+}
+
+// Block is a value that contacts worth comparing have in common.
+type Block struct {
+	Kind  string
+	Value string
+}
+
+// NewKey normalizes the fields the way people tend to vary them: case, accents,
+// word order of the name, "+tag" in emails and the formatting and trunk prefix
+// of mobiles.
+func NewKey(contact models.Contact) Key {
+	tokens := strings.Fields(foldName(contact.FirstName + " " + contact.LastName))
+	slices.Sort(tokens)
+
+	email := strings.ToLower(strings.TrimSpace(contact.Email))
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		local, domain := email[:at], email[at:]
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
+		email = local + domain
+	}
+
+	mobile := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, contact.Mobile)
+	if len(mobile) > 9 {
+		mobile = mobile[len(mobile)-9:]
+	}
+	return Key{NameTokens: tokens, Name: strings.Join(tokens, " "), Email: email, Mobile: mobile}
+}
+
+// Blocks are the normalized email, the mobile suffix and the first two letters
+// of every name token.
+func (k Key) Blocks() []Block {
+	var blocks []Block
+	if k.Email != "" {
+		blocks = append(blocks, Block{Kind: BlockEmail, Value: k.Email})
+	}
+	if k.Mobile != "" {
+		blocks = append(blocks, Block{Kind: BlockMobile, Value: k.Mobile})
+	}
+	for _, token := range k.NameTokens {
+		runes := []rune(token)
+		block := Block{Kind: BlockName, Value: string(runes[:min(2, len(runes))])}
+		if !slices.Contains(blocks, block) {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+func foldName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
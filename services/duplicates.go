@@ -0,0 +1,263 @@
+package services
+
+import (
+	"cmp"
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/duplicate"
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// DefaultDuplicateScore is the lowest score reported as a likely duplicate when
+// the caller does not ask for another one. Two contacts with the same name but
+// nothing else in common stay below it.
+const DefaultDuplicateScore = 0.6
+
+// A pair scores up to 0.5 for the similarity of the names and 0.25 each for the
+// same email and the same mobile.
+const (
+	duplicateNameWeight   = 0.5
+	duplicateEmailWeight  = 0.25
+	duplicateMobileWeight = 0.25
+	similarNameScore      = 0.8
+)
+
+const (
+	DuplicateReasonName   = "similar_name"
+	DuplicateReasonEmail  = "same_email"
+	DuplicateReasonMobile = "same_mobile"
+)
+
+type DuplicatePair struct {
+	UUIDs   [2]uuid.UUID `json:"uuids"`
+	Score   float64      `json:"score"`
+	Reasons []string     `json:"reasons"`
+}
+
+// DuplicateGroup holds contacts that are linked by likely duplicate pairs,
+// directly or through each other.
+type DuplicateGroup struct {
+	Score    float64          `json:"score"`
+	Contacts []models.Contact `json:"contacts"`
+	Pairs    []DuplicatePair  `json:"pairs"`
+}
+
+type DuplicateMatch struct {
+	Contact models.Contact `json:"contact"`
+	Score   float64        `json:"score"`
+	Reasons []string       `json:"reasons"`
+}
+
+// MaxDuplicateBlock is the most contacts that may share an email, a mobile or
+// a name prefix for the block to be searched for duplicates. Larger blocks,
+// typically a common name prefix, are skipped rather than compared pair by pair.
+const MaxDuplicateBlock = 100
+
+// FindDuplicates groups the live contacts whose pairs score at least minScore,
+// best group first, and returns the requested page of groups along with how
+// many groups there are.
+func (s *contactService) FindDuplicates(minScore float64, page, pageSize int) ([]DuplicateGroup, int64, error) {
+	blocks, err := s.repo.DuplicateBlocks(duplicateBlockKinds(minScore), MaxDuplicateBlock)
+	if err != nil {
+		return nil, 0, err
+	}
+	index := make(map[uint]int)
+	var contacts []models.Contact
+	for _, block := range blocks {
+		for _, contact := range block {
+			if _, ok := index[contact.ID]; !ok {
+				index[contact.ID] = len(contacts)
+				contacts = append(contacts, contact)
+			}
+		}
+	}
+	keys := make([]duplicate.Key, len(contacts))
+	for i, contact := range contacts {
+		keys[i] = duplicate.NewKey(contact)
+	}
+
+	parent := make([]int, len(contacts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+
+	var pairs [][2]int
+	scores := make(map[[2]int]DuplicatePair)
+	for _, pair := range candidatePairs(blocks, index) {
+		score, reasons := scoreDuplicate(keys[pair[0]], keys[pair[1]])
+		if score < minScore {
+			continue
+		}
+		pairs = append(pairs, pair)
+		scores[pair] = DuplicatePair{UUIDs: [2]uuid.UUID{contacts[pair[0]].UUID, contacts[pair[1]].UUID}, Score: score, Reasons: reasons}
+		parent[root(pair[0])] = root(pair[1])
+	}
+
+	groupIndex := make(map[int]int)
+	groups := make([]DuplicateGroup, 0)
+	for _, pair := range pairs {
+		r := root(pair[0])
+		i, ok := groupIndex[r]
+		if !ok {
+			i = len(groups)
+			groupIndex[r] = i
+			groups = append(groups, DuplicateGroup{})
+		}
+		groups[i].Pairs = append(groups[i].Pairs, scores[pair])
+		groups[i].Score = max(groups[i].Score, scores[pair].Score)
+	}
+	for i, contact := range contacts {
+		if g, ok := groupIndex[root(i)]; ok {
+			groups[g].Contacts = append(groups[g].Contacts, contact)
+		}
+	}
+	for _, group := range groups {
+		slices.SortFunc(group.Contacts, func(a, b models.Contact) int { return cmp.Compare(a.ID, b.ID) })
+	}
+	slices.SortStableFunc(groups, func(a, b DuplicateGroup) int {
+		return compareScores(a.Score, b.Score)
+	})
+
+	total := int64(len(groups))
+	offset := min((page-1)*pageSize, len(groups))
+	return groups[offset:min(offset+pageSize, len(groups))], total, nil
+}
+
+// FindDuplicatesOf returns the live contacts that are likely duplicates of the
+// contact, best match first.
+func (s *contactService) FindDuplicatesOf(contactUUID uuid.UUID, minScore float64) ([]DuplicateMatch, error) {
+	contact, err := s.repo.GetByUUID(contactUUID)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := s.repo.DuplicateBlocksOf(contact.ID, duplicateBlockKinds(minScore), MaxDuplicateBlock)
+	if err != nil {
+		return nil, err
+	}
+	others := make(map[uint]models.Contact)
+	for _, block := range blocks {
+		for _, other := range block {
+			if other.ID != contact.ID {
+				others[other.ID] = other
+			}
+		}
+	}
+	candidates := make([]models.Contact, 0, len(others))
+	for _, other := range others {
+		candidates = append(candidates, other)
+	}
+	slices.SortFunc(candidates, func(a, b models.Contact) int { return cmp.Compare(a.ID, b.ID) })
+
+	key := duplicate.NewKey(*contact)
+	matches := make([]DuplicateMatch, 0)
+	for _, other := range candidates {
+		if score, reasons := scoreDuplicate(key, duplicate.NewKey(other)); score >= minScore {
+			matches = append(matches, DuplicateMatch{Contact: other, Score: score, Reasons: reasons})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b DuplicateMatch) int {
+		return compareScores(a.Score, b.Score)
+	})
+	return matches, nil
+}
+
+// duplicateBlockKinds leaves the name blocks out when minScore is above what
+// similar names alone can score, a pair then has to share an email or a mobile.
+func duplicateBlockKinds(minScore float64) []string {
+	kinds := []string{duplicate.BlockEmail, duplicate.BlockMobile}
+	if minScore <= duplicateNameWeight {
+		kinds = append(kinds, duplicate.BlockName)
+	}
+	return kinds
+}
+
+// candidatePairs only pairs contacts that share a block, so the contacts are
+// not compared pair by pair. index maps the ID of every contact to its
+// position.
+func candidatePairs(blocks [][]models.Contact, index map[uint]int) [][2]int {
+	seen := make(map[[2]int]bool)
+	var pairs [][2]int
+	for _, block := range blocks {
+		for x, a := range block {
+			for _, b := range block[x+1:] {
+				pair := [2]int{index[a.ID], index[b.ID]}
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				if !seen[pair] {
+					seen[pair] = true
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+	slices.SortFunc(pairs, func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return a[1] - b[1]
+	})
+	return pairs
+}
+
+func scoreDuplicate(a, b duplicate.Key) (float64, []string) {
+	reasons := make([]string, 0, 3)
+	nameScore := similarity(a.Name, b.Name)
+	if nameScore >= similarNameScore {
+		reasons = append(reasons, DuplicateReasonName)
+	}
+	score := duplicateNameWeight * nameScore
+	if a.Email != "" && a.Email == b.Email {
+		score += duplicateEmailWeight
+		reasons = append(reasons, DuplicateReasonEmail)
+	}
+	if a.Mobile != "" && a.Mobile == b.Mobile {
+		score += duplicateMobileWeight
+		reasons = append(reasons, DuplicateReasonMobile)
+	}
+	return math.Round(score*100) / 100, reasons
+}
+
+// similarity is 1 minus the Levenshtein distance relative to the longer string.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func compareScores(a, b float64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+// Package filter parses the filter query parameter of the collection endpoints,
+// e.g. `country_code eq "SRB" and email ends_with "@corp.com"`, into a typed
+// tree that the repositories translate into parameterized queries.
+package filter
+
+import "time"
+
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindTime
+	KindList
+)
+
+type Operator string
+
+const (
+	Eq         Operator = "eq"
+	Ne         Operator = "ne"
+	Contains   Operator = "contains"
+	StartsWith Operator = "starts_with"
+	EndsWith   Operator = "ends_with"
+	Gt         Operator = "gt"
+	Ge         Operator = "ge"
+	Lt         Operator = "lt"
+	Le         Operator = "le"
+)
+
+var operators = map[Kind][]Operator{
+	KindString: {Eq, Ne, Contains, StartsWith, EndsWith},
+	KindTime:   {Gt, Ge, Lt, Le},
+	KindList:   {Eq, Ne},
+}
+
+// ContactFields are the fields a contact filter can test. Apart from list,
+// which matches the UUID of a list the contact belongs to, they are columns.
+var ContactFields = map[string]Kind{
+	"first_name":   KindString,
+	"last_name":    KindString,
+	"mobile":       KindString,
+	"email":        KindString,
+	"country_code": KindString,
+	"created_at":   KindTime,
+	"updated_at":   KindTime,
+	"list":         KindList,
+}
+
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Operand Node
+}
+
+// Comparison tests a single field. Value is a string for KindString, a
+// time.Time for KindTime and a uuid.UUID for KindList.
+type Comparison struct {
+	Field string
+	Kind  Kind
+	Op    Operator
+	Value interface{}
+}
+
+func (And) node()        {}
+func (Or) node()         {}
+func (Not) node()        {}
+func (Comparison) node() {}
+
+// Date values without a time are midnight UTC.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"contact-list-api-1/services/filter"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	listUUID := uuid.New()
+	countryCode := filter.Comparison{Field: "country_code", Kind: filter.KindString, Op: filter.Eq, Value: "SRB"}
+	email := filter.Comparison{Field: "email", Kind: filter.KindString, Op: filter.EndsWith, Value: "@corp.com"}
+	firstName := filter.Comparison{Field: "first_name", Kind: filter.KindString, Op: filter.StartsWith, Value: `Sa "the" \ra`}
+
+	testCases := []struct {
+		name       string
+		expression string
+		expected   filter.Node
+	}{
+		{
+			name:       "Empty",
+			expression: "  ",
+		},
+		{
+			name:       "And",
+			expression: `country_code eq "SRB" and email ends_with "@corp.com"`,
+			expected:   filter.And{Left: countryCode, Right: email},
+		},
+		{
+			name:       "AndBindsTighterThanOr",
+			expression: `first_name starts_with "Sa \"the\" \\ra" OR country_code eq "SRB" AND email ends_with "@corp.com"`,
+			expected:   filter.Or{Left: firstName, Right: filter.And{Left: countryCode, Right: email}},
+		},
+		{
+			name:       "ParenthesesAndNot",
+			expression: `not (first_name starts_with "Sa \"the\" \\ra" or country_code eq "SRB") and email ends_with "@corp.com"`,
+			expected:   filter.And{Left: filter.Not{Operand: filter.Or{Left: firstName, Right: countryCode}}, Right: email},
+		},
+		{
+			name:       "DateRange",
+			expression: `created_at ge "2024-01-01" and created_at lt "2024-02-01T12:30:00+01:00"`,
+			expected: filter.And{
+				Left:  filter.Comparison{Field: "created_at", Kind: filter.KindTime, Op: filter.Ge, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				Right: filter.Comparison{Field: "created_at", Kind: filter.KindTime, Op: filter.Lt, Value: time.Date(2024, 2, 1, 12, 30, 0, 0, time.FixedZone("", 3600))},
+			},
+		},
+		{
+			name:       "List",
+			expression: `list ne "` + listUUID.String() + `"`,
+			expected:   filter.Comparison{Field: "list", Kind: filter.KindList, Op: filter.Ne, Value: listUUID},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := filter.Parse(tt.expression, filter.ContactFields)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(node, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, node)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	testCases := []struct {
+		name             string
+		expression       string
+		expectedPosition int
+	}{
+		{name: "UnknownField", expression: `email eq "a" and password eq "b"`, expectedPosition: 18},
+		{name: "OperatorNotAllowedForField", expression: `created_at contains "2024"`, expectedPosition: 12},
+		{name: "MissingValue", expression: `email eq`, expectedPosition: 9},
+		{name: "UnquotedValue", expression: `email eq a`, expectedPosition: 10},
+		{name: "InvalidDate", expression: `updated_at gt "yesterday"`, expectedPosition: 15},
+		{name: "InvalidListUUID", expression: `list eq "family"`, expectedPosition: 9},
+		{name: "UnterminatedString", expression: `email eq "a`, expectedPosition: 10},
+		{name: "UnbalancedParenthesis", expression: `(email eq "a"`, expectedPosition: 14},
+		{name: "TrailingTokens", expression: `email eq "a" email eq "b"`, expectedPosition: 14},
+		{name: "UnexpectedCharacter", expression: `email = "a"`, expectedPosition: 7},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := filter.Parse(tt.expression, filter.ContactFields)
+			var syntaxErr *filter.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected a SyntaxError, got %v", err)
+			}
+			if syntaxErr.Position != tt.expectedPosition {
+				t.Errorf("Expected error at position %d, got %v", tt.expectedPosition, syntaxErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// SyntaxError reports where an expression could not be parsed. Position counts
+// characters from 1.
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Position, e.Message)
+}
+
+// Parse reads an expression over the given fields. An empty expression yields a
+// nil Node, which matches everything.
+//
+//	expression := term { "or" term }
+//	term       := factor { "and" factor }
+//	factor     := "not" factor | "(" expression ")" | field operator "string"
+func Parse(expression string, fields map[string]Kind) (Node, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	node, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, p.errorAt(next, "unexpected %s", next)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of filter"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpen, "(", i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenClose, ")", i + 1})
+			i++
+		case r == '"':
+			start := i
+			var value strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, &SyntaxError{start + 1, "unterminated string"}
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+			tokens = append(tokens, token{tokenString, value.String(), start + 1})
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[start:i]), start + 1})
+		default:
+			return nil, &SyntaxError{i + 1, fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(runes) + 1}), nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	fields map[string]Kind
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorAt(t token, format string, args ...interface{}) error {
+	return &SyntaxError{t.position, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expression() (Node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (Node, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) factor() (Node, error) {
+	if p.keyword("not") {
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return Not{operand}, nil
+	}
+	if p.peek().kind == tokenOpen {
+		p.advance()
+		node, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.advance(); t.kind != tokenClose {
+			return nil, p.errorAt(t, "expected \")\", got %s", t)
+		}
+		return node, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Node, error) {
+	fieldToken := p.advance()
+	if fieldToken.kind != tokenWord {
+		return nil, p.errorAt(fieldToken, "expected a field, got %s", fieldToken)
+	}
+	field := strings.ToLower(fieldToken.text)
+	kind, ok := p.fields[field]
+	if !ok {
+		return nil, p.errorAt(fieldToken, "unknown field %q", fieldToken.text)
+	}
+
+	operatorToken := p.advance()
+	if operatorToken.kind != tokenWord {
+		return nil, p.errorAt(operatorToken, "expected an operator after %s, got %s", field, operatorToken)
+	}
+	op := Operator(strings.ToLower(operatorToken.text))
+	if !slices.Contains(operators[kind], op) {
+		allowed := make([]string, 0, len(operators[kind]))
+		for _, candidate := range operators[kind] {
+			allowed = append(allowed, string(candidate))
+		}
+		return nil, p.errorAt(operatorToken, "operator %q cannot be used with %s, expected one of %s", operatorToken.text, field, strings.Join(allowed, ", "))
+	}
+
+	valueToken := p.advance()
+	if valueToken.kind != tokenString {
+		return nil, p.errorAt(valueToken, "expected a quoted value after %s, got %s", op, valueToken)
+	}
+	value, err := parseValue(kind, valueToken.text)
+	if err != nil {
+		return nil, p.errorAt(valueToken, "invalid value for %s: %v", field, err)
+	}
+	return Comparison{Field: field, Kind: kind, Op: op, Value: value}, nil
+}
+
+func parseValue(kind Kind, text string) (interface{}, error) {
+	switch kind {
+	case KindTime:
+		for _, layout := range timeLayouts {
+			if value, err := time.ParseInLocation(layout, text, time.UTC); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a date such as 2024-01-31 or an RFC 3339 time")
+	case KindList:
+		return uuid.Parse(text)
+	}
+	return text, nil
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services/vcard"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ImportFields are the contact fields a CSV column can be mapped to. All of them
+// need a column, because every contact needs all of them.
+var ImportFields = []string{"first_name", "last_name", "mobile", "email", "country_code"}
+
+// importReportPageSize is how many rejected rows the error report reads at a
+// time.
+const importReportPageSize = 500
+
+type ImportService interface {
+	ImportCSV(listUUID uuid.UUID, body io.Reader, mapping map[string]string) (*models.ContactImport, error)
+	ImportVCards(listUUID uuid.UUID, body io.Reader) (*models.ContactImport, error)
+	GetImport(listUUID, importUUID uuid.UUID) (*models.ContactImport, error)
+	WriteErrorReport(listUUID, importUUID uuid.UUID, w io.Writer) error
+}
+
+type importService struct {
+	repo        repositories.ImportRepository
+	contactRepo repositories.ContactRepository
+	contacts    ContactService
+}
+
+func NewImportService(repo repositories.ImportRepository, contactRepo repositories.ContactRepository, contacts ContactService) ImportService {
+	return &importService{repo: repo, contactRepo: contactRepo, contacts: contacts}
+}
+
+// ImportCSV reads body one row at a time and creates a contact in the list for
+// every row, validated like any other new contact. mapping maps CSV headers to
+// ImportFields, and headers it leaves out are matched to the field of the same
+// name. Rows whose email or mobile belongs to a contact already add that
+// contact to the list instead, and rows of contacts on the list are skipped, so
+// importing a file twice is harmless. Rows that fail are stored for the error
+// report.
+func (s *importService) ImportCSV(listUUID uuid.UUID, body io.Reader, mapping map[string]string) (*models.ContactImport, error) {
+	listID, err := s.contactRepo.GetListID(listUUID)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(body)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, NewValidationErrors([]ValidationError{{Field: "file", Message: "The file has no header row"}})
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, NewValidationErrors([]ValidationError{{Field: "file", Message: parseErr.Error()}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, validationErrors := mapColumns(header, mapping)
+	if validationErrors != nil {
+		return nil, validationErrors
+	}
+
+	contactImport := models.ContactImport{UUID: uuid.New(), ListUUID: listUUID, Header: header, ImportedAt: time.Now()}
+	if err := s.repo.Create(contactImport); err != nil {
+		return nil, err
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.As(err, &parseErr) {
+			err = s.reject(&contactImport, parseErr.StartLine, record, []string{parseErr.Err.Error()})
+		} else if err == nil {
+			line, _ := reader.FieldPos(0)
+			err = s.importRow(&contactImport, listID, line, record, columns)
+		}
+		if err != nil {
+			// Keep the counts of the rows that made it in.
+			s.repo.UpdateCounts(contactImport)
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateCounts(contactImport); err != nil {
+		return nil, err
+	}
+	return &contactImport, nil
+}
+
+func (s *importService) importRow(contactImport *models.ContactImport, listID uint, line int, record []string, columns map[string]int) error {
+	var contact models.Contact
+	for field, column := range columns {
+		value := strings.TrimSpace(record[column])
+		switch field {
+		case "first_name":
+			contact.FirstName = value
+		case "last_name":
+			contact.LastName = value
+		case "mobile":
+			contact.Mobile = value
+		case "email":
+			contact.Email = value
+		case "country_code":
+			contact.CountryCode = value
+		}
+	}
+	return s.importContact(contactImport, listID, line, record, contact)
+}
+
+// ImportVCards creates a contact in the list for every card in body, like
+// ImportCSV does for rows. A card whose UID is that of a contact adds the
+// contact like a matching email or mobile does. The report of a vCard import
+// has the ImportFields as its columns, since a card has no columns of its own.
+func (s *importService) ImportVCards(listUUID uuid.UUID, body io.Reader) (*models.ContactImport, error) {
+	listID, err := s.contactRepo.GetListID(listUUID)
+	if err != nil {
+		return nil, err
+	}
+	contactImport := models.ContactImport{UUID: uuid.New(), ListUUID: listUUID, Header: ImportFields, ImportedAt: time.Now()}
+	if err := s.repo.Create(contactImport); err != nil {
+		return nil, err
+	}
+	decoder := vcard.NewDecoder(body)
+	for {
+		contact, line, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		var syntaxErr *vcard.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = s.reject(&contactImport, syntaxErr.Line, nil, []string{syntaxErr.Message})
+		} else if err == nil {
+			values := []string{contact.FirstName, contact.LastName, contact.Mobile, contact.Email, contact.CountryCode}
+			err = s.importContact(&contactImport, listID, line, values, contact)
+		}
+		if err != nil {
+			s.repo.UpdateCounts(contactImport)
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateCounts(contactImport); err != nil {
+		return nil, err
+	}
+	return &contactImport, nil
+}
+
+// importContact creates the contact on the list of the import, or adds the
+// contact that has its UUID, email or mobile already. Existing contacts are
+// added as they are, the values of the row do not change them. values are what
+// the report shows for a rejected row.
+func (s *importService) importContact(contactImport *models.ContactImport, listID uint, line int, values []string, contact models.Contact) error {
+	existing, messages, err := s.findExisting(contact)
+	if err != nil {
+		return err
+	}
+	if messages != nil {
+		return s.reject(contactImport, line, values, messages)
+	}
+	if existing != nil {
+		return s.link(contactImport, listID, existing)
+	}
+
+	if contact.UUID == uuid.Nil {
+		contact.UUID = uuid.New()
+	}
+	contact.ListUUIDs = []uuid.UUID{contactImport.ListUUID}
+	err = s.contacts.CreateContact(contact)
+	var validationErrors *ValidationErrors
+	switch {
+	case err == nil:
+		contactImport.Created++
+		return nil
+	case errors.As(err, &validationErrors):
+		messages := make([]string, len(validationErrors.Errors))
+		for i, validationError := range validationErrors.Errors {
+			messages[i] = validationError.Field + ": " + validationError.Message
+		}
+		return s.reject(contactImport, line, values, messages)
+	case !errors.Is(err, repositories.ErrConflict):
+		return err
+	}
+
+	// Someone else created the contact since findExisting looked.
+	if existing, messages, err = s.findExisting(contact); err != nil {
+		return err
+	}
+	if existing == nil && messages == nil {
+		messages = []string{"Conflicts with a deleted contact"}
+	}
+	if messages != nil {
+		return s.reject(contactImport, line, values, messages)
+	}
+	return s.link(contactImport, listID, existing)
+}
+
+// findExisting returns the contact with the UUID, email or mobile of contact,
+// or nil if there is none. A row that matches several contacts, or a deleted
+// one, cannot be imported; the messages say why.
+func (s *importService) findExisting(contact models.Contact) (*models.Contact, []string, error) {
+	type match struct {
+		field   string
+		contact *models.Contact
+	}
+	var matches []match
+	if contact.UUID != uuid.Nil {
+		existing, err := s.contactRepo.GetByUUID(contact.UUID)
+		if err == nil {
+			matches = append(matches, match{"uid", existing})
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+	if contact.Email != "" {
+		existing, err := s.contactRepo.FindByEmail(contact.Email)
+		if err == nil {
+			matches = append(matches, match{"email", existing})
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+	if contact.Mobile != "" {
+		existing, err := s.contactRepo.FindByMobile(contact.Mobile)
+		if err == nil {
+			matches = append(matches, match{"mobile", existing})
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil, nil
+	}
+
+	var messages []string
+	for _, m := range matches {
+		switch {
+		case m.contact.DeletedAt.Valid:
+			messages = append(messages, fmt.Sprintf("%s: Belongs to the deleted contact %s", m.field, m.contact.UUID))
+		case m.contact.ID != matches[0].contact.ID:
+			messages = append(messages, fmt.Sprintf("%s: Belongs to the contact %s, not %s", m.field, m.contact.UUID, matches[0].contact.UUID))
+		}
+	}
+	if messages != nil {
+		return nil, messages, nil
+	}
+	// FindByEmail and FindByMobile do not load the lists.
+	existing, err := s.contactRepo.GetByUUID(matches[0].contact.UUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return existing, nil, nil
+}
+
+// link adds an existing contact to the list of the import, unless it is on it
+// already.
+func (s *importService) link(contactImport *models.ContactImport, listID uint, contact *models.Contact) error {
+	if slices.Contains(contact.ListIDs, listID) {
+		contactImport.Skipped++
+		return nil
+	}
+	if err := s.contactRepo.AddToList(contact.ID, listID, models.MembershipSourceImport); err != nil {
+		return err
+	}
+	contactImport.Linked++
+	return nil
+}
+
+func (s *importService) reject(contactImport *models.ContactImport, line int, record []string, messages []string) error {
+	contactImport.Failed++
+	if record == nil {
+		record = []string{}
+	}
+	return s.repo.AddRow(models.ContactImportRow{ImportUUID: contactImport.UUID, Line: line, Values: record, Errors: messages})
+}
+
+func (s *importService) GetImport(listUUID, importUUID uuid.UUID) (*models.ContactImport, error) {
+	contactImport, err := s.repo.GetByUUID(importUUID)
+	if err != nil {
+		return nil, err
+	}
+	if contactImport.ListUUID != listUUID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return contactImport, nil
+}
+
+// WriteErrorReport writes the rejected rows of an import as CSV, each preceded
+// by its line in the imported file and its errors. The file's own columns
+// follow under their original headers, so a fixed report can be imported again.
+func (s *importService) WriteErrorReport(listUUID, importUUID uuid.UUID, w io.Writer) error {
+	contactImport, err := s.GetImport(listUUID, importUUID)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"line", "errors"}, contactImport.Header...)); err != nil {
+		return err
+	}
+	var afterID uint
+	for {
+		rows, err := s.repo.GetRows(importUUID, afterID, importReportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writer.Write(append([]string{strconv.Itoa(row.Line), strings.Join(row.Errors, "; ")}, row.Values...)); err != nil {
+				return err
+			}
+			afterID = row.ID
+		}
+		if len(rows) < importReportPageSize {
+			break
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// mapColumns finds the column of every import field. Headers are matched
+// without regard to case and surrounding space.
+func mapColumns(header []string, mapping map[string]string) (map[string]int, *ValidationErrors) {
+	var errs []ValidationError
+	fields := make(map[string]string, len(mapping))
+	for name, field := range mapping {
+		if !slices.Contains(ImportFields, field) {
+			errs = append(errs, ValidationError{Field: "map", Message: fmt.Sprintf("Unknown field %q, expected one of %s", field, strings.Join(ImportFields, ", "))})
+			continue
+		}
+		fields[strings.ToLower(strings.TrimSpace(name))] = field
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		field, ok := fields[name]
+		if !ok && slices.Contains(ImportFields, name) {
+			field, ok = name, true
+		}
+		if !ok {
+			continue
+		}
+		if _, seen := columns[field]; seen {
+			errs = append(errs, ValidationError{Field: "map", Message: fmt.Sprintf("More than one column is mapped to %s", field)})
+		}
+		columns[field] = i
+	}
+	for _, field := range ImportFields {
+		if _, ok := columns[field]; !ok {
+			errs = append(errs, ValidationError{Field: "map", Message: fmt.Sprintf("No column is mapped to %s", field)})
+		}
+	}
+	if len(errs) > 0 {
+		slices.SortStableFunc(errs, func(a, b ValidationError) int { return strings.Compare(a.Message, b.Message) })
+		return nil, NewValidationErrors(errs)
+	}
+	return columns, nil
+}
@@ -9,11 +9,13 @@ import (
 )
 
 type ListService interface {
-	GetAllLists(name string, page, pageSize int) ([]models.List, error)
+	GetAllLists(name string, includeDeleted bool, sort []repositories.SortField, page, pageSize int) ([]models.List, int64, error)
+	GetListsAfter(name string, includeDeleted bool, sort []repositories.SortField, cursor string, pageSize int) ([]models.List, string, error)
 	GetListByUUID(uuid uuid.UUID) (*models.List, error)
 	CreateList(list models.List) error
 	UpdateList(list models.List) error
-	DeleteList(uuid uuid.UUID) error
+	DeleteList(uuid uuid.UUID, cascade bool, version uint) error
+	RestoreList(uuid uuid.UUID) error
 }
 
 type listService struct {
@@ -23,14 +25,35 @@ type listService struct {
 func NewListService(repo repositories.ListRepository) ListService {
 	return &listService{repo: repo}
 }
-func (s *listService) GetAllLists(name string, page, pageSize int) ([]models.List, error) {
+func (s *listService) GetAllLists(name string, includeDeleted bool, sort []repositories.SortField, page, pageSize int) ([]models.List, int64, error) {
 
 	offset := (page - 1) * pageSize
-	lists, err := s.repo.GetAll(name, pageSize, offset)
+	lists, total, err := s.repo.GetAll(name, includeDeleted, sort, pageSize, offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	return lists, total, nil
+}
+
+// GetListsAfter returns the page after cursor and the cursor of the next page,
+// which is empty on the last page.
+func (s *listService) GetListsAfter(name string, includeDeleted bool, sort []repositories.SortField, cursorValue string, pageSize int) ([]models.List, string, error) {
+	after, err := decodeCursor(cursorValue, sort)
+	if err != nil {
+		return nil, "", err
+	}
+	lists, err := s.repo.GetAllAfter(name, includeDeleted, sort, after, pageSize+1)
+	if errors.Is(err, repositories.ErrCursorNotFound) || errors.Is(err, repositories.ErrInvalidCursor) {
+		return nil, "", ErrInvalidCursor
 	}
-	return lists, nil
+	if err != nil {
+		return nil, "", err
+	}
+	if len(lists) <= pageSize {
+		return lists, "", nil
+	}
+	lists = lists[:pageSize]
+	return lists, encodeCursor(repositories.ListCursor(lists[pageSize-1], sort), sort), nil
 }
 func (s *listService) GetListByUUID(uuid uuid.UUID) (*models.List, error) {
 
@@ -59,9 +82,12 @@ func (s *listService) UpdateList(list models.List) error {
 	if existingList == nil {
 		return errors.New("list not found")
 	}
+	if validationErrors := s.validateList(list); validationErrors != nil {
+		return validationErrors
+	}
 	return s.repo.Update(list)
 }
-func (s *listService) DeleteList(uuid uuid.UUID) error {
+func (s *listService) DeleteList(uuid uuid.UUID, cascade bool, version uint) error {
 	existingList, err := s.repo.GetByUUID(uuid)
 	if err != nil {
 		return err
@@ -70,7 +96,10 @@ func (s *listService) DeleteList(uuid uuid.UUID) error {
 		return errors.New("list not found")
 	}
 
-	return s.repo.Delete(uuid)
+	return s.repo.Delete(uuid, cascade, version)
+}
+func (s *listService) RestoreList(uuid uuid.UUID) error {
+	return s.repo.Restore(uuid)
 }
 
 type ValidationError struct {
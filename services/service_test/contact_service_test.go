@@ -32,7 +32,7 @@ func TestContactService_GetAllContacts(t *testing.T) {
 			Mobile:      "+1234567890",
 			Email:       "test.test@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListUUIDs:   []uuid.UUID{list.UUID},
 		},
 		{
 			UUID:        uuid.New(),
@@ -41,7 +41,7 @@ func TestContactService_GetAllContacts(t *testing.T) {
 			Mobile:      "+1987654321",
 			Email:       "contact.contact@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListUUIDs:   []uuid.UUID{list.UUID},
 		},
 		{
 			UUID:        uuid.New(),
@@ -50,7 +50,7 @@ func TestContactService_GetAllContacts(t *testing.T) {
 			Mobile:      "+1122334455",
 			Email:       "test1.test1@example.com",
 			CountryCode: "USA",
-			ListID:      list.ID,
+			ListUUIDs:   []uuid.UUID{list.UUID},
 		},
 	}
 	for _, contact := range contacts {
@@ -100,7 +100,7 @@ func TestContactService_GetAllContacts(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := service.GetAllContacts(tt.filterName, tt.filterMobile, tt.filterEmail, tt.page, tt.pageSize)
+			results, _, err := service.GetAllContacts(tt.filterName, tt.filterMobile, tt.filterEmail, "", false, nil, tt.page, tt.pageSize)
 			if err != nil {
 				t.Fatalf("Expected no error, got %v", err)
 			}
@@ -136,7 +136,7 @@ func TestContactService_GetContactByUUID(t *testing.T) {
 		Mobile:      "+1122334455",
 		Email:       "test1.test1@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListUUIDs:   []uuid.UUID{list.UUID},
 	}
 	db.Create(&contact)
 
@@ -205,7 +205,7 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "test1.test1@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: false,
 		},
@@ -218,7 +218,7 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "+987654321",
 				Email:       "test.test@example.com",
 				CountryCode: "USA",
-				ListID:      111111,
+				ListUUIDs:   []uuid.UUID{uuid.New()},
 			},
 			expectedError: true,
 		},
@@ -231,7 +231,7 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "invalid-email",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: true,
 		},
@@ -244,17 +244,17 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "+1987654321",
 				Email:       "duplicate@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			existingContacts: []models.Contact{
 				{
 					UUID:        uuid.New(),
 					FirstName:   "Existing",
 					LastName:    "Contact",
-					Mobile:      "+1234567890",
+					Mobile:      "+1555000111",
 					Email:       "duplicate@example.com",
 					CountryCode: "USA",
-					ListID:      list.ID,
+					ListUUIDs:   []uuid.UUID{list.UUID},
 				},
 			},
 			expectedError: true,
@@ -268,7 +268,7 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "1122334455",
 				Email:       "valid@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: true,
 		},
@@ -281,7 +281,7 @@ func TestContactService_CreateContact(t *testing.T) {
 				Mobile:      "+1234567890",
 				Email:       "unique@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			existingContacts: []models.Contact{
 				{
@@ -291,11 +291,57 @@ func TestContactService_CreateContact(t *testing.T) {
 					Mobile:      "+1234567890",
 					Email:       "existing@example.com",
 					CountryCode: "USA",
-					ListID:      list.ID,
+					ListUUIDs:   []uuid.UUID{list.UUID},
 				},
 			},
 			expectedError: true,
 		},
+		{
+			name: "EmailContainedInExistingEmail",
+			contact: models.Contact{
+				UUID:        uuid.New(),
+				FirstName:   "New",
+				LastName:    "Contact",
+				Mobile:      "+1555000222",
+				Email:       "a@b.com",
+				CountryCode: "USA",
+				ListUUIDs:   []uuid.UUID{list.UUID},
+			},
+			existingContacts: []models.Contact{
+				{
+					UUID:        uuid.New(),
+					FirstName:   "Existing",
+					LastName:    "Contact",
+					Mobile:      "+1555000333",
+					Email:       "xa@b.com",
+					CountryCode: "USA",
+				},
+			},
+			expectedError: false,
+		},
+		{
+			name: "MobilePrefixOfExistingMobile",
+			contact: models.Contact{
+				UUID:        uuid.New(),
+				FirstName:   "New",
+				LastName:    "Contact",
+				Mobile:      "+1666",
+				Email:       "prefix@example.com",
+				CountryCode: "USA",
+				ListUUIDs:   []uuid.UUID{list.UUID},
+			},
+			existingContacts: []models.Contact{
+				{
+					UUID:        uuid.New(),
+					FirstName:   "Existing",
+					LastName:    "Contact",
+					Mobile:      "+1666000111",
+					Email:       "longer@example.com",
+					CountryCode: "USA",
+				},
+			},
+			expectedError: false,
+		},
 		{
 			name: "EmptyFieldsAndInvalidCountryCode",
 			contact: models.Contact{
@@ -377,7 +423,7 @@ func TestContactService_UpdateContact(t *testing.T) {
 		Mobile:      "+1122334455",
 		Email:       "test1.test1@example.com",
 		CountryCode: "USA",
-		ListID:      list.ID,
+		ListUUIDs:   []uuid.UUID{list.UUID},
 	}
 	db.Create(&contact)
 	testCases := []struct {
@@ -394,7 +440,7 @@ func TestContactService_UpdateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "test.new@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: false,
 		},
@@ -407,7 +453,7 @@ func TestContactService_UpdateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "invalid-email",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: true,
 		},
@@ -420,7 +466,7 @@ func TestContactService_UpdateContact(t *testing.T) {
 				Mobile:      "1122334455",
 				Email:       "valid@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: true,
 		},
@@ -434,7 +480,7 @@ func TestContactService_UpdateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "test1.test1@example.com",
 				CountryCode: "USA",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
 			},
 			expectedError: true,
 		},
@@ -447,7 +493,18 @@ func TestContactService_UpdateContact(t *testing.T) {
 				Mobile:      "+1122334455",
 				Email:       "test1.test1@example.com",
 				CountryCode: "US",
-				ListID:      list.ID,
+				ListUUIDs:   []uuid.UUID{list.UUID},
+			},
+			expectedError: true,
+		},
+		{
+			name: "IncompleteReplacement",
+			updatedContact: models.Contact{
+				UUID:        existingUUID,
+				LastName:    "Test",
+				Mobile:      "+1122334455",
+				Email:       "test1.test1@example.com",
+				CountryCode: "USA",
 			},
 			expectedError: true,
 		},
@@ -502,7 +559,7 @@ func TestContactService_DeleteContact(t *testing.T) {
 			Mobile:      "+1234567890",
 			Email:       "test1@example.com",
 			CountryCode: "USA",
-			ListID:      testLists[0].ID,
+			ListUUIDs:   []uuid.UUID{testLists[0].UUID},
 		},
 		{
 			UUID:        uuid.New(),
@@ -511,7 +568,7 @@ func TestContactService_DeleteContact(t *testing.T) {
 			Mobile:      "+1987654321",
 			Email:       "test2@example.com",
 			CountryCode: "USA",
-			ListID:      testLists[0].ID,
+			ListUUIDs:   []uuid.UUID{testLists[0].UUID},
 		},
 	}
 
@@ -547,12 +604,12 @@ func TestContactService_DeleteContact(t *testing.T) {
 		},
 	}
 
-	service.DeleteContact(testContacts[1].UUID)
+	service.DeleteContact(testContacts[1].UUID, 0)
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 
-			err := service.DeleteContact(tt.contactUUID)
+			err := service.DeleteContact(tt.contactUUID, 0)
 			if (err != nil) != tt.expectError {
 				t.Fatalf("Expected error: %v, got %v", tt.expectError, err)
 			}
@@ -565,3 +622,81 @@ func TestContactService_DeleteContact(t *testing.T) {
 		})
 	}
 }
+
+func TestContactService_GetContactsByList(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+
+	list := models.List{UUID: uuid.New(), Name: "Test List"}
+	otherList := models.List{UUID: uuid.New(), Name: "Other List"}
+	for _, l := range []*models.List{&list, &otherList} {
+		if err := db.Create(l).Error; err != nil {
+			t.Fatalf("Could not create test list: %v", err)
+		}
+	}
+	contacts := []models.Contact{
+		{UUID: uuid.New(), FirstName: "Sara", LastName: "Test", Mobile: "+1234567890", Email: "sara@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Test", LastName: "Test", Mobile: "+1987654321", Email: "test@example.com", CountryCode: "USA", ListIDs: []uint{list.ID}},
+		{UUID: uuid.New(), FirstName: "Sara", LastName: "Other", Mobile: "+1122334455", Email: "other@example.com", CountryCode: "USA", ListIDs: []uint{otherList.ID}},
+	}
+	for _, contact := range contacts {
+		if err := repo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		listUUID      uuid.UUID
+		filterName    string
+		page          int
+		pageSize      int
+		expectedCount int
+		expectedError bool
+	}{
+		{
+			name:          "AllMembers",
+			listUUID:      list.UUID,
+			page:          1,
+			pageSize:      10,
+			expectedCount: 2,
+		},
+		{
+			name:          "WithFilter",
+			listUUID:      list.UUID,
+			filterName:    "Sara",
+			page:          1,
+			pageSize:      10,
+			expectedCount: 1,
+		},
+		{
+			name:          "WithPagination",
+			listUUID:      list.UUID,
+			page:          2,
+			pageSize:      1,
+			expectedCount: 1,
+		},
+		{
+			name:          "NonExistentList",
+			listUUID:      uuid.New(),
+			page:          1,
+			pageSize:      10,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			results, _, err := service.GetContactsByList(tt.listUUID, tt.filterName, "", "", "", nil, tt.page, tt.pageSize)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("Expected error: %v, got %v", tt.expectedError, err)
+			}
+			if len(results) != tt.expectedCount {
+				t.Errorf("Expected %d contacts, got %d", tt.expectedCount, len(results))
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestContactService_FindDuplicates(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewContactRepository(db)
+	service := services.NewContactService(repo)
+	contacts := map[string]models.Contact{
+		"jon":       {UUID: uuid.New(), FirstName: "Jon", LastName: "Smith", Mobile: "+381641234567", Email: "jon@example.com", CountryCode: "SRB"},
+		"john":      {UUID: uuid.New(), FirstName: "John", LastName: "Smith", Mobile: "+3810641234567", Email: "john.smith@example.com", CountryCode: "SRB"},
+		"smithJohn": {UUID: uuid.New(), FirstName: "Smith", LastName: "John", Mobile: "+1555000111", Email: "jon+work@example.com", CountryCode: "USA"},
+		"sara":      {UUID: uuid.New(), FirstName: "Sara", LastName: "Savić", Mobile: "+381601111111", Email: "sara@example.com", CountryCode: "SRB"},
+		"saraCopy":  {UUID: uuid.New(), FirstName: "sara", LastName: "Savic", Mobile: "+381602222222", Email: "SARA+home@example.com", CountryCode: "SRB"},
+		"mark":      {UUID: uuid.New(), FirstName: "Mark", LastName: "Twain", Mobile: "+1555000222", Email: "mark@example.com", CountryCode: "USA"},
+		"deleted":   {UUID: uuid.New(), FirstName: "Mark", LastName: "Twain", Mobile: "+1555000333", Email: "mark+old@example.com", CountryCode: "USA"},
+	}
+	for _, key := range []string{"jon", "john", "smithJohn", "sara", "saraCopy", "mark", "deleted"} {
+		contact := contacts[key]
+		if err := repo.Create(contact); err != nil {
+			t.Fatalf("Could not create test contact: %v", err)
+		}
+	}
+	if err := repo.Delete(contacts["deleted"].UUID, 0); err != nil {
+		t.Fatalf("Could not delete test contact: %v", err)
+	}
+
+	names := func(found []models.Contact) []string {
+		var keys []string
+		for _, contact := range found {
+			for key, expected := range contacts {
+				if expected.UUID == contact.UUID {
+					keys = append(keys, key)
+				}
+			}
+		}
+		return keys
+	}
+
+	groups, total, err := service.FindDuplicates(services.DefaultDuplicateScore, 1, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(groups) != 2 || total != 2 {
+		t.Fatalf("Expected 2 groups, got %d: %+v", total, groups)
+	}
+	if found := names(groups[0].Contacts); groups[0].Score != 0.75 || !reflect.DeepEqual(found, []string{"sara", "saraCopy"}) {
+		t.Errorf("Expected the Sara Savic group scoring 0.75 first, got %v scoring %v", found, groups[0].Score)
+	}
+	if reasons := groups[0].Pairs[0].Reasons; !reflect.DeepEqual(reasons, []string{services.DuplicateReasonName, services.DuplicateReasonEmail}) {
+		t.Errorf("Expected similar name and same email, got %v", reasons)
+	}
+	// Smith John only matches John Smith through Jon Smith.
+	if found := names(groups[1].Contacts); groups[1].Score != 0.7 || len(groups[1].Pairs) != 2 || !reflect.DeepEqual(found, []string{"jon", "john", "smithJohn"}) {
+		t.Errorf("Expected the Smith group with 2 pairs scoring 0.7, got %v scoring %v with %+v", found, groups[1].Score, groups[1].Pairs)
+	}
+
+	groups, total, err = service.FindDuplicates(services.DefaultDuplicateScore, 2, 1)
+	if err != nil || total != 2 || len(groups) != 1 || groups[0].Score != 0.7 {
+		t.Errorf("Expected the Smith group alone on the second page of 2 groups, got %d groups %+v (%v)", total, groups, err)
+	}
+
+	groups, _, err = service.FindDuplicates(0.72, 1, 10)
+	if err != nil || len(groups) != 1 {
+		t.Errorf("Expected only the Sara Savic group above 0.72, got %+v (%v)", groups, err)
+	}
+
+	matches, err := service.FindDuplicatesOf(contacts["jon"].UUID, services.DefaultDuplicateScore)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var matched []models.Contact
+	for _, match := range matches {
+		matched = append(matched, match.Contact)
+	}
+	if found := names(matched); !reflect.DeepEqual(found, []string{"john", "smithJohn"}) {
+		t.Errorf("Expected John Smith and Smith John as duplicates of Jon Smith, got %v", found)
+	}
+	if matches, err := service.FindDuplicatesOf(contacts["mark"].UUID, services.DefaultDuplicateScore); err != nil || len(matches) != 0 {
+		t.Errorf("Expected deleted contacts not to be reported, got %+v (%v)", matches, err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"contact-list-api-1/repositories"
 	"contact-list-api-1/services"
 	"contact-list-api-1/tests"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -69,7 +72,7 @@ func TestListService_GetAllLists(t *testing.T) {
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := service.GetAllLists(tt.filter, tt.page, tt.pageSize)
+			results, _, err := service.GetAllLists(tt.filter, false, nil, tt.page, tt.pageSize)
 			if err != nil {
 				t.Fatalf("Excpected no error. got %v", err)
 			}
@@ -301,10 +304,10 @@ func TestListService_DeleteList(t *testing.T) {
 			shouldExist: false,
 		},
 	}
-	service.DeleteList(testLists[0].UUID)
+	service.DeleteList(testLists[0].UUID, true, 0)
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.DeleteList(tt.listUUID)
+			err := service.DeleteList(tt.listUUID, true, 0)
 			if (err != nil) != tt.expectError {
 				t.Fatalf("Expected error: %v, got %v", tt.expectError, err)
 			}
@@ -317,3 +320,71 @@ func TestListService_DeleteList(t *testing.T) {
 		})
 	}
 }
+
+func TestListService_GetListsAfter(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	service := services.NewListService(repositories.NewListRepository(db))
+	for _, name := range []string{"List 1", "List 2", "List 3", "List 4", "List 5"} {
+		if err := db.Create(&models.List{UUID: uuid.New(), Name: name}).Error; err != nil {
+			t.Fatalf("Could not create list: %v", err)
+		}
+	}
+
+	var names []string
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatalf("Expected the scan to end, still going after %d pages", page)
+		}
+		lists, next, err := service.GetListsAfter("", false, nil, cursor, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, list := range lists {
+			names = append(names, list.Name)
+		}
+		if page == 0 {
+			// Rows inserted during the scan end up on a later page instead of
+			// shifting the ones already returned.
+			if err := db.Create(&models.List{UUID: uuid.New(), Name: "List 6"}).Error; err != nil {
+				t.Fatalf("Could not create list: %v", err)
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	expected := []string{"List 1", "List 2", "List 3", "List 4", "List 5", "List 6"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	for _, invalid := range []string{"not base64!", "e30"} {
+		if _, _, err := service.GetListsAfter("", false, nil, invalid, 2); !errors.Is(err, services.ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", invalid, err)
+		}
+	}
+
+	// A cursor has to carry a value of the right type for every sort column.
+	byUpdate := []repositories.SortField{{Column: "updated_at"}}
+	for _, invalid := range []string{`{"id":1,"sort":"updated_at"}`, `{"id":1,"sort":"updated_at","values":["yesterday"]}`} {
+		encoded := base64.RawURLEncoding.EncodeToString([]byte(invalid))
+		if _, _, err := service.GetListsAfter("", false, byUpdate, encoded, 2); !errors.Is(err, services.ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for %s, got %v", invalid, err)
+		}
+	}
+
+	_, stale, err := service.GetListsAfter("", false, nil, "", 2)
+	if err != nil || stale == "" {
+		t.Fatalf("Expected a cursor, got %q, %v", stale, err)
+	}
+	if err := db.Unscoped().Where("name = ?", "List 2").Delete(&models.List{}).Error; err != nil {
+		t.Fatalf("Could not purge list: %v", err)
+	}
+	if _, _, err := service.GetListsAfter("", false, nil, stale, 2); !errors.Is(err, services.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for the cursor of a purged list, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"contact-list-api-1/services"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestTrashService_RestoreAndPurge(t *testing.T) {
+	db, cleanup := setTestDB(t)
+	defer cleanup()
+
+	listRepo := repositories.NewListRepository(db)
+	contactRepo := repositories.NewContactRepository(db)
+	service := services.NewTrashService(repositories.NewTrashRepository(db), listRepo, contactRepo)
+
+	list := models.List{UUID: uuid.New(), Name: "Family"}
+	if err := db.Create(&list).Error; err != nil {
+		t.Fatalf("Could not create test list: %v", err)
+	}
+	contact := models.Contact{UUID: uuid.New(), FirstName: "Sara", LastName: "Savic", Mobile: "+381641234567", Email: "sara@example.com", CountryCode: "SRB", ListIDs: []uint{list.ID}}
+	if err := contactRepo.Create(contact); err != nil {
+		t.Fatalf("Could not create test contact: %v", err)
+	}
+	if err := listRepo.Delete(list.UUID, true, 0); err != nil {
+		t.Fatalf("Could not delete test list: %v", err)
+	}
+
+	items, err := service.GetTrash(1, 10)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Expected the list and its contact in the trash, got %+v (%v)", items, err)
+	}
+
+	testCases := []struct {
+		name        string
+		uuid        uuid.UUID
+		expectedErr error
+	}{
+		{name: "RestoreList", uuid: list.UUID},
+		{name: "RestoreLiveList", uuid: list.UUID, expectedErr: gorm.ErrRecordNotFound},
+		{name: "RestoreUnknownUUID", uuid: uuid.New(), expectedErr: gorm.ErrRecordNotFound},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.Restore(tt.uuid)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+	if _, err := contactRepo.GetByUUID(contact.UUID); err != nil {
+		t.Errorf("Expected contact to be restored together with its list, got %v", err)
+	}
+
+	if err := contactRepo.Delete(contact.UUID, 0); err != nil {
+		t.Fatalf("Could not delete test contact: %v", err)
+	}
+	if purged, err := service.Purge(time.Hour); err != nil || purged != 0 {
+		t.Errorf("Expected nothing to be purged within the retention period, got %d (%v)", purged, err)
+	}
+	if purged, err := service.Purge(-time.Hour); err != nil || purged != 1 {
+		t.Errorf("Expected the deleted contact to be purged, got %d (%v)", purged, err)
+	}
+}
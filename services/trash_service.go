@@ -0,0 +1,71 @@
+package services
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/repositories"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TrashService interface {
+	GetTrash(page, pageSize int) ([]models.TrashItem, error)
+	Restore(uuid uuid.UUID) error
+	Purge(retention time.Duration) (int64, error)
+}
+
+type trashService struct {
+	repo        repositories.TrashRepository
+	listRepo    repositories.ListRepository
+	contactRepo repositories.ContactRepository
+}
+
+func NewTrashService(repo repositories.TrashRepository, listRepo repositories.ListRepository, contactRepo repositories.ContactRepository) TrashService {
+	return &trashService{repo: repo, listRepo: listRepo, contactRepo: contactRepo}
+}
+func (s *trashService) GetTrash(page, pageSize int) ([]models.TrashItem, error) {
+	offset := (page - 1) * pageSize
+	return s.repo.GetAll(pageSize, offset)
+}
+func (s *trashService) Restore(uuid uuid.UUID) error {
+	item, err := s.repo.GetByUUID(uuid)
+	if err != nil {
+		return err
+	}
+	if item.Type == models.TrashItemList {
+		return s.listRepo.Restore(uuid)
+	}
+	return s.contactRepo.Restore(uuid)
+}
+func (s *trashService) Purge(retention time.Duration) (int64, error) {
+	return s.repo.Purge(time.Now().Add(-retention))
+}
+
+// StartPurger purges expired trash right away and then once every interval
+// until the returned stop function is called.
+func StartPurger(service TrashService, retention, interval time.Duration) func() {
+	done := make(chan struct{})
+	purge := func() {
+		purged, err := service.Purge(retention)
+		if err != nil {
+			log.Println("Error purging trash: ", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d item(s) from the trash", purged)
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		purge()
+		for {
+			select {
+			case <-ticker.C:
+				purge()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,57 @@
+package vcard
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+var (
+	countryNamesOnce sync.Once
+	countryNames     map[string]string
+)
+
+// countryCode returns the ISO 3166-1 alpha-3 code for the country of an ADR.
+// That component is meant to hold a name such as "Germany", but Write and many
+// exporters put a code such as "DEU" or "DE" there. Names are matched against
+// the English names of the countries; anything else gives an empty code.
+func countryCode(country string) string {
+	country = strings.TrimSpace(country)
+	if (len(country) == 2 || len(country) == 3) && isLetters(country) {
+		if region, err := language.ParseRegion(country); err == nil && isCountry(region.Canonicalize()) {
+			return region.Canonicalize().ISO3()
+		}
+	}
+	countryNamesOnce.Do(loadCountryNames)
+	return countryNames[strings.ToLower(country)]
+}
+
+// isCountry leaves out regions such as the EU and codes without an alpha-3
+// code.
+func isCountry(region language.Region) bool {
+	return region.IsCountry() && region.ISO3() != "ZZZ"
+}
+
+func loadCountryNames() {
+	countryNames = make(map[string]string)
+	names := display.English.Regions()
+	for a := 'A'; a <= 'Z'; a++ {
+		for b := 'A'; b <= 'Z'; b++ {
+			region, err := language.ParseRegion(string([]rune{a, b}))
+			if err == nil && isCountry(region) {
+				countryNames[strings.ToLower(names.Name(region))] = region.ISO3()
+			}
+		}
+	}
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
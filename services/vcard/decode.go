@@ -0,0 +1,371 @@
+package vcard
+
+import (
+	"bufio"
+	"bytes"
+	"contact-list-api-1/models"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MaxLineLength is the longest line, after unfolding, that a card may have:
+// 1 MiB. Phones export photos and keys inline as base64, which takes most of
+// it. A card with a longer line is rejected with a *SyntaxError, and the cards
+// after it are still read.
+const MaxLineLength = 1 << 20
+
+// SyntaxError reports a card that could not be read. Line is where the card, or
+// the stray line outside of any card, starts.
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Decoder reads the cards of a stream one at a time. Versions 2.1, 3.0 and 4.0
+// are understood as far as the fields of a contact go.
+type Decoder struct {
+	reader  *bufio.Reader
+	lineNum int
+	next    *rawLine
+	unread  *rawLine
+}
+
+// rawLine is a line of at most MaxLineLength. The text of a longer line is
+// dropped and tooLong set instead.
+type rawLine struct {
+	text    string
+	num     int
+	tooLong bool
+}
+
+type property struct {
+	name   string
+	params map[string][]string
+	value  string
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{reader: bufio.NewReader(r)}
+}
+
+// Decode returns the next card as a contact together with the line it starts
+// on, and io.EOF after the last card. A *SyntaxError means the card was
+// skipped; decoding can go on with the next one.
+func (d *Decoder) Decode() (models.Contact, int, error) {
+	var start int
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return models.Contact{}, 0, err
+		}
+		if strings.TrimSpace(line.text) == "" && !line.tooLong {
+			continue
+		}
+		if p, err := parseProperty(line.text); err != nil || p.name != "BEGIN" || !strings.EqualFold(p.value, "VCARD") {
+			return models.Contact{}, line.num, &SyntaxError{Line: line.num, Message: "expected BEGIN:VCARD"}
+		}
+		start = line.num
+		break
+	}
+
+	var (
+		properties []property
+		firstErr   error
+	)
+	for {
+		line, err := d.readLine()
+		if err == io.EOF {
+			return models.Contact{}, start, &SyntaxError{Line: start, Message: "card has no END:VCARD"}
+		}
+		if err != nil {
+			return models.Contact{}, start, err
+		}
+		if line.tooLong {
+			if firstErr == nil {
+				firstErr = &SyntaxError{Line: start, Message: fmt.Sprintf("line %d: longer than %d bytes", line.num, MaxLineLength)}
+			}
+			continue
+		}
+		if strings.TrimSpace(line.text) == "" {
+			continue
+		}
+		p, err := parseProperty(line.text)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = &SyntaxError{Line: start, Message: fmt.Sprintf("line %d: %v", line.num, err)}
+			}
+			continue
+		}
+		if p.name == "BEGIN" && strings.EqualFold(p.value, "VCARD") {
+			// The card was never closed, so this line starts the next one.
+			d.unread = &line
+			return models.Contact{}, start, &SyntaxError{Line: start, Message: "card has no END:VCARD"}
+		}
+		if p.name == "END" && strings.EqualFold(p.value, "VCARD") {
+			break
+		}
+		properties = append(properties, p)
+	}
+	if firstErr != nil {
+		return models.Contact{}, start, firstErr
+	}
+	return toContact(properties), start, nil
+}
+
+// readLine returns the next logical line, with folded lines unfolded and the
+// soft line breaks of quoted-printable values joined.
+func (d *Decoder) readLine() (rawLine, error) {
+	if d.unread != nil {
+		line := *d.unread
+		d.unread = nil
+		return line, nil
+	}
+	line, err := d.readPhysical()
+	if err != nil {
+		return rawLine{}, err
+	}
+	text := []byte(line.text)
+	unfold := func(next rawLine, continued string) {
+		if line.tooLong || next.tooLong || len(text)+len(continued) > MaxLineLength {
+			text, line.tooLong = nil, true
+			return
+		}
+		text = append(text, continued...)
+	}
+	for {
+		next, err := d.readPhysical()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rawLine{}, err
+		}
+		if strings.HasPrefix(next.text, " ") || strings.HasPrefix(next.text, "\t") {
+			unfold(next, next.text[1:])
+			continue
+		}
+		if bytes.HasSuffix(text, []byte("=")) && isQuotedPrintable(string(text)) {
+			text = text[:len(text)-1]
+			unfold(next, next.text)
+			continue
+		}
+		d.next = &next
+		break
+	}
+	line.text = string(text)
+	return line, nil
+}
+
+func (d *Decoder) readPhysical() (rawLine, error) {
+	if d.next != nil {
+		line := *d.next
+		d.next = nil
+		return line, nil
+	}
+	var (
+		text    []byte
+		read    bool
+		tooLong bool
+	)
+	for {
+		chunk, err := d.reader.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if tooLong || len(text)+len(chunk) > MaxLineLength+2 {
+			// The rest of the line is read and thrown away.
+			text, tooLong = nil, true
+		} else {
+			text = append(text, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && read {
+			break
+		}
+		if err != nil {
+			return rawLine{}, err
+		}
+		break
+	}
+	d.lineNum++
+	line := rawLine{text: strings.TrimRight(string(text), "\r\n"), num: d.lineNum, tooLong: tooLong}
+	if len(line.text) > MaxLineLength {
+		line.text, line.tooLong = "", true
+	}
+	return line, nil
+}
+
+func isQuotedPrintable(line string) bool {
+	colon := strings.Index(line, ":")
+	return colon >= 0 && strings.Contains(strings.ToUpper(line[:colon]), "QUOTED-PRINTABLE")
+}
+
+// parseProperty splits a line such as item1.TEL;TYPE=cell,voice:+1 555 into
+// its name, parameters and value. The group is dropped.
+func parseProperty(line string) (property, error) {
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return property{}, fmt.Errorf("%q is not a property", line)
+	}
+	parts := splitUnquoted(line[:colon], ';')
+	name := strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	p := property{name: name, params: make(map[string][]string), value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !found {
+			// vCard 2.1 writes types without TYPE=, as in TEL;CELL.
+			key, value = "TYPE", key
+		}
+		for _, v := range splitUnquoted(value, ',') {
+			p.params[key] = append(p.params[key], strings.ToUpper(strings.Trim(v, `"`)))
+		}
+	}
+	if slices.Contains(p.params["ENCODING"], "QUOTED-PRINTABLE") {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(p.value)))
+		if err != nil {
+			return property{}, fmt.Errorf("invalid quoted-printable value for %s", name)
+		}
+		p.value = string(decoded)
+	}
+	return p, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == sep && !quoted {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// components splits a structured value such as N or ADR on the semicolons
+// that are not escaped, and unescapes each component.
+func components(value string) []string {
+	var (
+		parts   []string
+		current bytes.Buffer
+	)
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n', 'N':
+				current.WriteByte('\n')
+			default:
+				current.WriteByte(value[i])
+			}
+		case value[i] == ';':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(value[i])
+		}
+	}
+	return append(parts, current.String())
+}
+
+func unescape(value string) string {
+	return strings.Join(components(strings.ReplaceAll(value, ";", `\;`)), "")
+}
+
+// toContact maps the properties a contact has a field for. A mobile is the
+// cell number, or else the preferred or first number; the email is the
+// preferred or first one. The country comes from the preferred or first
+// address when it can be told, and the UID becomes the UUID when it is one.
+func toContact(properties []property) models.Contact {
+	var contact models.Contact
+	if n := find(properties, "N", nil); n != nil {
+		parts := components(n.value)
+		contact.LastName = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			contact.FirstName = strings.TrimSpace(parts[1])
+		}
+	}
+	if contact.FirstName == "" && contact.LastName == "" {
+		if fn := find(properties, "FN", nil); fn != nil {
+			name := strings.TrimSpace(unescape(fn.value))
+			if i := strings.LastIndex(name, " "); i >= 0 {
+				contact.FirstName, contact.LastName = strings.TrimSpace(name[:i]), name[i+1:]
+			} else {
+				contact.FirstName = name
+			}
+		}
+	}
+	if tel := find(properties, "TEL", []string{"CELL", "PREF"}); tel != nil {
+		number := strings.TrimPrefix(unescape(tel.value), "tel:")
+		number, _, _ = strings.Cut(number, ";")
+		contact.Mobile = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(" -.()/", r) {
+				return -1
+			}
+			return r
+		}, number)
+	}
+	if email := find(properties, "EMAIL", []string{"PREF"}); email != nil {
+		contact.Email = strings.ToLower(strings.TrimSpace(unescape(email.value)))
+	}
+	if adr := find(properties, "ADR", []string{"PREF"}); adr != nil {
+		if parts := components(adr.value); len(parts) >= 7 {
+			contact.CountryCode = countryCode(parts[6])
+		}
+	}
+	if uid := find(properties, "UID", nil); uid != nil {
+		if parsed, err := uuid.Parse(strings.TrimPrefix(uid.value, "urn:uuid:")); err == nil {
+			contact.UUID = parsed
+		}
+	}
+	return contact
+}
+
+// find returns the first property with the name that has the first of the
+// types that any property has, or else the first property with the name. A
+// PREF parameter, as used by vCard 4.0, counts as the PREF type.
+func find(properties []property, name string, types []string) *property {
+	var found []property
+	for _, p := range properties {
+		if p.name == name {
+			found = append(found, p)
+		}
+	}
+	for _, t := range types {
+		for i, p := range found {
+			if slices.Contains(p.params["TYPE"], t) || (t == "PREF" && len(p.params["PREF"]) > 0) {
+				return &found[i]
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return &found[0]
+}
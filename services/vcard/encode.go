@@ -0,0 +1,73 @@
+// Package vcard reads and writes contacts as vCards, version 4.0 (RFC 6350) and
+// 3.0 (RFC 2426).
+package vcard
+
+import (
+	"contact-list-api-1/models"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	Version3 = "3.0"
+	Version4 = "4.0"
+)
+
+// maxLineLength is the length in octets after which RFC 6350 asks for lines to
+// be folded.
+const maxLineLength = 75
+
+// Write writes the contact as one vCard of the given version.
+func Write(w io.Writer, contact models.Contact, version string) error {
+	var b strings.Builder
+	line := func(name, value string) {
+		fold(&b, name+":"+value)
+	}
+	line("BEGIN", "VCARD")
+	line("VERSION", version)
+	line("UID", "urn:uuid:"+contact.UUID.String())
+	line("FN", escape(strings.TrimSpace(contact.FirstName+" "+contact.LastName)))
+	line("N", escape(contact.LastName)+";"+escape(contact.FirstName)+";;;")
+	if version == Version3 {
+		line("TEL;TYPE=CELL", escape(contact.Mobile))
+		line("EMAIL;TYPE=INTERNET", escape(contact.Email))
+	} else {
+		line("TEL;VALUE=uri;TYPE=cell", "tel:"+contact.Mobile)
+		line("EMAIL", escape(contact.Email))
+	}
+	// The country is the last of the seven address components.
+	line("ADR", ";;;;;;"+escape(contact.CountryCode))
+	if !contact.UpdatedAt.IsZero() {
+		line("REV", contact.UpdatedAt.UTC().Format("20060102T150405Z"))
+	}
+	line("END", "VCARD")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape escapes a text value, in which backslashes, commas, semicolons and
+// newlines have a meaning.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// fold ends the line with CRLF and breaks it into lines of at most
+// maxLineLength octets, continued with a leading space, without splitting a
+// UTF-8 sequence.
+func fold(b *strings.Builder, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the length of the next line.
+		limit = maxLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,297 @@
+package vcard
+
+import (
+	"contact-list-api-1/models"
+	"contact-list-api-1/services/vcard"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestWrite(t *testing.T) {
+	contact := models.Contact{
+		UUID:        uuid.MustParse("8c1f2a4e-6a3b-4d2e-9f10-2b3c4d5e6f70"),
+		FirstName:   "Ana;Maria",
+		LastName:    "Petrović, Jr.",
+		Mobile:      "+381641234567",
+		Email:       "ana@example.com",
+		CountryCode: "SRB",
+		UpdatedAt:   time.Date(2024, 3, 1, 10, 30, 0, 0, time.FixedZone("CET", 3600)),
+	}
+
+	testCases := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{
+			name:    "Version4",
+			version: vcard.Version4,
+			expected: "BEGIN:VCARD\r\n" +
+				"VERSION:4.0\r\n" +
+				"UID:urn:uuid:8c1f2a4e-6a3b-4d2e-9f10-2b3c4d5e6f70\r\n" +
+				"FN:Ana\\;Maria Petrović\\, Jr.\r\n" +
+				"N:Petrović\\, Jr.;Ana\\;Maria;;;\r\n" +
+				"TEL;VALUE=uri;TYPE=cell:tel:+381641234567\r\n" +
+				"EMAIL:ana@example.com\r\n" +
+				"ADR:;;;;;;SRB\r\n" +
+				"REV:20240301T093000Z\r\n" +
+				"END:VCARD\r\n",
+		},
+		{
+			name:    "Version3",
+			version: vcard.Version3,
+			expected: "BEGIN:VCARD\r\n" +
+				"VERSION:3.0\r\n" +
+				"UID:urn:uuid:8c1f2a4e-6a3b-4d2e-9f10-2b3c4d5e6f70\r\n" +
+				"FN:Ana\\;Maria Petrović\\, Jr.\r\n" +
+				"N:Petrović\\, Jr.;Ana\\;Maria;;;\r\n" +
+				"TEL;TYPE=CELL:+381641234567\r\n" +
+				"EMAIL;TYPE=INTERNET:ana@example.com\r\n" +
+				"ADR:;;;;;;SRB\r\n" +
+				"REV:20240301T093000Z\r\n" +
+				"END:VCARD\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := vcard.Write(&b, contact, tc.version); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if b.String() != tc.expected {
+				t.Errorf("Write() =\n%q\nwant\n%q", b.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestWrite_FoldsLongLines(t *testing.T) {
+	contact := models.Contact{FirstName: strings.Repeat("ž", 60), LastName: "Long"}
+	var b strings.Builder
+	if err := vcard.Write(&b, contact, vcard.Version4); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a UTF-8 sequence: %q", line)
+		}
+	}
+
+	decoded, _, err := vcard.NewDecoder(strings.NewReader(b.String())).Decode()
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if decoded.FirstName != contact.FirstName {
+		t.Errorf("FirstName = %q, want %q", decoded.FirstName, contact.FirstName)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	contact := models.Contact{
+		UUID:        uuid.New(),
+		FirstName:   `Back\slash, "quoted"`,
+		LastName:    "Semi;colon",
+		Mobile:      "+381641234567",
+		Email:       "round@example.com",
+		CountryCode: "SRB",
+	}
+	for _, version := range []string{vcard.Version3, vcard.Version4} {
+		t.Run(version, func(t *testing.T) {
+			var b strings.Builder
+			if err := vcard.Write(&b, contact, version); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			decoded, line, err := vcard.NewDecoder(strings.NewReader(b.String())).Decode()
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if line != 1 {
+				t.Errorf("line = %d, want 1", line)
+			}
+			if !reflect.DeepEqual(decoded, contact) {
+				t.Errorf("Decode() = %+v, want %+v", decoded, contact)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected models.Contact
+	}{
+		{
+			name: "Version21QuotedPrintable",
+			input: "BEGIN:VCARD\n" +
+				"VERSION:2.1\n" +
+				"N;ENCODING=QUOTED-PRINTABLE;CHARSET=UTF-8:Petrovi=C4=87;Jo=\n" +
+				"van;;;\n" +
+				"TEL;HOME:011 123 456\n" +
+				"TEL;CELL:+381 (64) 123-45.67\n" +
+				"EMAIL;INTERNET: Jovan@Example.COM \n" +
+				"END:VCARD\n",
+			expected: models.Contact{FirstName: "Jovan", LastName: "Petrović", Mobile: "+381641234567", Email: "jovan@example.com"},
+		},
+		{
+			name: "Version4PreferredAndGroups",
+			input: "BEGIN:VCARD\r\n" +
+				"VERSION:4.0\r\n" +
+				"UID:urn:uuid:8c1f2a4e-6a3b-4d2e-9f10-2b3c4d5e6f70\r\n" +
+				"item1.EMAIL;TYPE=work:work@example.com\r\n" +
+				"item2.EMAIL;PREF=1:home@example.com\r\n" +
+				"TEL;VALUE=uri;TYPE=\"voice,cell\":tel:+1-555-0100;ext=12\r\n" +
+				"ADR;TYPE=home:;;Main St 1;Springfield;;12345;usa\r\n" +
+				"N:Doe;Jane;;;\r\n" +
+				"END:VCARD\r\n",
+			expected: models.Contact{
+				UUID:        uuid.MustParse("8c1f2a4e-6a3b-4d2e-9f10-2b3c4d5e6f70"),
+				FirstName:   "Jane",
+				LastName:    "Doe",
+				Mobile:      "+15550100",
+				Email:       "home@example.com",
+				CountryCode: "USA",
+			},
+		},
+		{
+			name: "FormattedNameOnlyFolded",
+			input: "BEGIN:VCARD\n" +
+				"VERSION:3.0\n" +
+				"FN:Mary Ann \n" +
+				" Smith\n" +
+				"UID:not-a-uuid\n" +
+				"END:VCARD\n",
+			expected: models.Contact{FirstName: "Mary Ann", LastName: "Smith"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			contact, _, err := vcard.NewDecoder(strings.NewReader(tc.input)).Decode()
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(contact, tc.expected) {
+				t.Errorf("Decode() = %+v, want %+v", contact, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDecode_SkipsBrokenCards(t *testing.T) {
+	input := "BEGIN:VCARD\n" + // 1
+		"FN:First One\n" +
+		"END:VCARD\n" +
+		"\n" +
+		"garbage\n" + // 5
+		"BEGIN:VCARD\n" + // 6
+		"FN:Unclosed\n" +
+		"BEGIN:VCARD\n" + // 8
+		"no colon here\n" +
+		"END:VCARD\n" +
+		"BEGIN:VCARD\n" + // 11
+		"FN:Last One\n" +
+		"END:VCARD\n" +
+		"BEGIN:VCARD\n" + // 14
+		"FN:Truncated\n"
+
+	type result struct {
+		firstName string
+		line      int
+		errLine   int
+	}
+	expected := []result{
+		{firstName: "First", line: 1},
+		{errLine: 5},
+		{errLine: 6},
+		{errLine: 8},
+		{firstName: "Last", line: 11},
+		{errLine: 14},
+	}
+
+	decoder := vcard.NewDecoder(strings.NewReader(input))
+	var results []result
+	for {
+		contact, line, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		var syntaxErr *vcard.SyntaxError
+		switch {
+		case errors.As(err, &syntaxErr):
+			results = append(results, result{errLine: syntaxErr.Line})
+		case err != nil:
+			t.Fatalf("Decode() error = %v", err)
+		default:
+			results = append(results, result{firstName: contact.FirstName, line: line})
+		}
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("results = %+v, want %+v", results, expected)
+	}
+}
+
+func TestDecode_RejectsOverlongLines(t *testing.T) {
+	folded := strings.Repeat(" "+strings.Repeat("A", 74)+"\r\n", vcard.MaxLineLength/74+1)
+	input := "BEGIN:VCARD\r\n" + // 1
+		"FN:Long Line\r\n" +
+		"PHOTO;ENCODING=b:" + strings.Repeat("A", vcard.MaxLineLength+10) + "\r\n" +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" + // 5
+		"FN:Long Unfolded\r\n" +
+		"PHOTO;ENCODING=b:\r\n" + folded +
+		"END:VCARD\r\n" +
+		"BEGIN:VCARD\r\n" +
+		"FN:Short Card\r\n" +
+		"END:VCARD\r\n"
+
+	decoder := vcard.NewDecoder(strings.NewReader(input))
+	for _, line := range []int{1, 5} {
+		_, _, err := decoder.Decode()
+		var syntaxErr *vcard.SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Line != line || !strings.Contains(syntaxErr.Message, "longer than") {
+			t.Fatalf("Decode() error = %v, want a line too long in the card on line %d", err, line)
+		}
+	}
+	contact, _, err := decoder.Decode()
+	if err != nil || contact.FirstName != "Short" {
+		t.Fatalf("Decode() = %+v, %v, want the card after the long ones", contact, err)
+	}
+	if _, _, err := decoder.Decode(); err != io.EOF {
+		t.Errorf("Decode() error = %v, want io.EOF", err)
+	}
+}
+
+func TestDecode_Country(t *testing.T) {
+	testCases := map[string]string{
+		"USA":           "USA",
+		"de":            "DEU",
+		"UK":            "GBR",
+		"Germany":       "DEU",
+		"united states": "USA",
+		"Atlantis":      "",
+		"EU":            "",
+		"276":           "",
+	}
+	for country, expected := range testCases {
+		t.Run(country, func(t *testing.T) {
+			input := "BEGIN:VCARD\r\nFN:Jane Doe\r\nADR:;;Main St 1;Springfield;;12345;" + country + "\r\nEND:VCARD\r\n"
+			contact, _, err := vcard.NewDecoder(strings.NewReader(input)).Decode()
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if contact.CountryCode != expected {
+				t.Errorf("CountryCode = %q, want %q", contact.CountryCode, expected)
+			}
+		})
+	}
+}
@@ -2,32 +2,39 @@ package tests
 
 import (
 	"contact-list-api-1/config"
+	"contact-list-api-1/database"
+	"contact-list-api-1/migrations"
 	"contact-list-api-1/models"
-	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 //var db *gorm.DB
 
+// SetupTestDB opens a fresh SQLite database per test. Set CONTACT_LIST_TEST_CONFIG
+// to a config file to run the suite against another database server instead.
 func SetupTestDB(t *testing.T) *gorm.DB {
-	cfg, err := config.LoadTestConfig("/home/osboxes/Documents/contact-list-api-1/tests/config_test.json")
-	if err != nil {
-		t.Fatalf("Error loading configuration: %v", err)
+	dbConfig := config.DBConfig{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "test.db")}
+	if path := os.Getenv("CONTACT_LIST_TEST_CONFIG"); path != "" {
+		cfg, err := config.LoadTestConfig(path)
+		if err != nil {
+			t.Fatalf("Error loading configuration: %v", err)
+		}
+		dbConfig = cfg.DB
 	}
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Name)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := database.Open(dbConfig)
 	if err != nil {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
-	err = db.Migrator().DropTable(&models.List{}, &models.Contact{})
+	err = db.Migrator().DropTable(&models.List{}, &models.Contact{}, &models.ListMembership{}, &models.ContactMerge{}, &models.ContactImport{}, &models.ContactImportRow{}, &models.AddressBookSnapshot{}, &models.AddressBookSnapshotMember{}, &models.ContactDuplicateKey{}, &migrations.SchemaMigration{})
 	if err != nil {
 		t.Fatalf("Failed to drop tables:%v", err)
 	}
-	err = db.AutoMigrate(&models.List{}, &models.Contact{})
+	_, err = migrations.Up(db)
 	if err != nil {
 		t.Fatalf("Failed to migrate tables:%v", err)
 	}